@@ -0,0 +1,198 @@
+// Package audit keeps an append-only, machine-local log of every change
+// dotman makes to the filesystem. Each line of the log is a JSON Entry with
+// the state of the touched paths before and after the change.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Merith-TK/dotman/internal/digest"
+)
+
+const FileName = "audit.jsonl"
+
+// State kinds
+const (
+	KindMissing = "missing"
+	KindFile    = "file"
+	KindDir     = "dir"
+	KindSymlink = "symlink"
+	KindOther   = "other"
+)
+
+// State describes what was at a path
+type State struct {
+	Kind   string `json:"kind"`
+	Mode   string `json:"mode,omitempty"` // Permission bits in octal
+	Size   int64  `json:"size,omitempty"`
+	Target string `json:"target,omitempty"` // Where a symlink points
+	Digest string `json:"digest,omitempty"` // Content digest of a regular file
+}
+
+// Entry is one recorded change
+type Entry struct {
+	Time    time.Time        `json:"time"`
+	PID     int              `json:"pid"`
+	Command []string         `json:"command"`
+	Op      string           `json:"op"`
+	Path    string           `json:"path"`
+	Dest    string           `json:"dest,omitempty"` // Second path of a rename or copy
+	Before  map[string]State `json:"before"`
+	After   map[string]State `json:"after"`
+	OK      bool             `json:"ok"`
+	Error   string           `json:"error,omitempty"`
+}
+
+var (
+	mu      sync.Mutex
+	logPath string
+	command []string
+	file    *os.File
+)
+
+// Path returns the path of the audit log in stateDir
+func Path(stateDir string) string {
+	return filepath.Join(stateDir, FileName)
+}
+
+// Start records changes made by this process to the audit log in stateDir,
+// attributed to the command line args. The log is created on the first
+// change, so read-only commands leave no trace.
+func Start(stateDir string, args []string) {
+	mu.Lock()
+	defer mu.Unlock()
+	logPath = Path(stateDir)
+	command = args
+}
+
+// Stop closes the audit log
+func Stop() error {
+	mu.Lock()
+	defer mu.Unlock()
+	logPath = ""
+	if file == nil {
+		return nil
+	}
+	err := file.Close()
+	file = nil
+	return err
+}
+
+// Enabled reports whether changes are being recorded
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return logPath != ""
+}
+
+// Capture returns the state of each path
+func Capture(paths ...string) map[string]State {
+	states := make(map[string]State, len(paths))
+	for _, path := range paths {
+		states[path] = stat(path)
+	}
+	return states
+}
+
+func stat(path string) State {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return State{Kind: KindMissing}
+	}
+
+	state := State{Mode: fmt.Sprintf("%04o", info.Mode().Perm())}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		state.Kind = KindSymlink
+		state.Mode = ""
+		state.Target, _ = os.Readlink(path)
+	case info.IsDir():
+		state.Kind = KindDir
+	case info.Mode().IsRegular():
+		state.Kind = KindFile
+		state.Size = info.Size()
+		state.Digest, _ = digest.Path(path)
+	default:
+		state.Kind = KindOther
+	}
+	return state
+}
+
+// Record appends entry to the audit log, filling in the time and command.
+// A log that can't be written is reported but doesn't fail the change.
+func Record(entry Entry) {
+	mu.Lock()
+	defer mu.Unlock()
+	if logPath == "" {
+		return
+	}
+
+	entry.Time = time.Now()
+	entry.PID = os.Getpid()
+	entry.Command = command
+	entry.OK = entry.Error == ""
+
+	data, err := json.Marshal(entry)
+	if err == nil {
+		err = appendLine(data)
+	}
+	if err != nil {
+		slog.Warn("failed to write audit log", "path", logPath, "error", err)
+	}
+}
+
+// appendLine writes one line to the log in a single write, opening it first
+// if needed
+func appendLine(data []byte) error {
+	if file == nil {
+		if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+		file = f
+	}
+	_, err := file.Write(append(data, '\n'))
+	return err
+}
+
+// Read returns the entries of the audit log in stateDir recorded at or after
+// since, oldest first. A missing log has no entries.
+func Read(stateDir string, since time.Time) ([]Entry, error) {
+	f, err := os.Open(Path(stateDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// A line cut short by a crash shouldn't hide the rest
+			slog.Warn("skipping unreadable audit log line", "line", line, "error", err)
+			continue
+		}
+		if !entry.Time.Before(since) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,318 @@
+// Package backup keeps copies of content dotman is about to replace or delete
+// in a machine-local store under the state directory. Each dotman run that
+// backs something up gets one snapshot, named after the time it was taken:
+//
+//	~/.local/state/dotman/backups/20240501-120000/manifest.json
+//	~/.local/state/dotman/backups/20240501-120000/files/home/me/.bashrc
+//
+// Files are stored below files/ by their absolute path.
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+const (
+	DirName      = "backups"
+	ManifestName = "manifest.json"
+	FilesDirName = "files"
+
+	// KeepEnv and MaxAgeEnv override the default retention policy
+	KeepEnv   = "DOTMAN_BACKUP_KEEP"
+	MaxAgeEnv = "DOTMAN_BACKUP_MAX_AGE"
+
+	idLayout = "20060102-150405"
+)
+
+// Retention decides which snapshots prune removes: those older than MaxAge,
+// except for the newest Keep
+type Retention struct {
+	Keep   int
+	MaxAge time.Duration
+}
+
+// DefaultRetention keeps the last 10 snapshots and anything from the last
+// 30 days, unless DOTMAN_BACKUP_KEEP or DOTMAN_BACKUP_MAX_AGE say otherwise
+func DefaultRetention() (Retention, error) {
+	retention := Retention{Keep: 10, MaxAge: 30 * 24 * time.Hour}
+	if value := os.Getenv(KeepEnv); value != "" {
+		keep, err := strconv.Atoi(value)
+		if err != nil || keep < 0 {
+			return retention, fmt.Errorf("invalid %s: %q", KeepEnv, value)
+		}
+		retention.Keep = keep
+	}
+	if value := os.Getenv(MaxAgeEnv); value != "" {
+		maxAge, err := config.ParseDuration(value)
+		if err != nil {
+			return retention, fmt.Errorf("invalid %s: %w", MaxAgeEnv, err)
+		}
+		retention.MaxAge = maxAge
+	}
+	return retention, nil
+}
+
+// Entry is one backed up path
+type Entry struct {
+	Path string         `json:"path"`
+	Type types.FileType `json:"type"`
+}
+
+// Snapshot is everything one dotman run backed up
+type Snapshot struct {
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	Entries []Entry   `json:"entries"`
+
+	dir string
+}
+
+// Location returns where the content backed up for path is stored
+func (s *Snapshot) Location(path string) string {
+	return filepath.Join(s.dir, FilesDirName, strings.TrimPrefix(filepath.Clean(path), string(filepath.Separator)))
+}
+
+// Covering returns the entry that holds path itself or a directory above it
+func (s *Snapshot) Covering(path string) (Entry, bool) {
+	for _, entry := range s.Entries {
+		if entry.Path == path || strings.HasPrefix(path, entry.Path+string(filepath.Separator)) {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Store is the backup store of one machine
+type Store struct {
+	dir string
+}
+
+var (
+	mu sync.Mutex
+	// current is the snapshot of this process in each store
+	current = map[string]*Snapshot{}
+)
+
+// New returns the backup store in the state directory of cfg
+func New(cfg *types.Config) *Store {
+	return &Store{dir: filepath.Join(cfg.StateDir, DirName)}
+}
+
+// Dir returns the directory of the store
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Save copies path into the snapshot of this run and returns where it went.
+// A path saved earlier in the same run is kept as it was then.
+func (s *Store) Save(path string) (string, error) {
+	if _, err := os.Lstat(path); err != nil {
+		return "", fmt.Errorf("nothing to back up at %s: %w", path, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	snapshot := current[s.dir]
+	if snapshot == nil {
+		var err error
+		if snapshot, err = s.startSnapshot(); err != nil {
+			return "", err
+		}
+	}
+
+	location := snapshot.Location(path)
+	if _, found := snapshot.Covering(path); found {
+		return location, nil
+	}
+
+	if err := fileops.BackupPath(path, location); err != nil {
+		return "", fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+
+	snapshot.Entries = append(snapshot.Entries, Entry{Path: path, Type: fileops.GetFileType(path)})
+	if err := writeManifest(snapshot); err != nil {
+		return "", err
+	}
+	return location, nil
+}
+
+// startSnapshot creates the snapshot directory of this run and prunes old
+// snapshots according to the default retention policy
+func (s *Store) startSnapshot() (*Snapshot, error) {
+	now := time.Now().UTC()
+	id := now.Format(idLayout)
+	dir := filepath.Join(s.dir, id)
+	for n := 2; fileops.PathExists(dir); n++ {
+		id = fmt.Sprintf("%s-%d", now.Format(idLayout), n)
+		dir = filepath.Join(s.dir, id)
+	}
+
+	if err := fileops.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create backup snapshot: %w", err)
+	}
+	snapshot := &Snapshot{ID: id, Time: now, dir: dir}
+	if err := writeManifest(snapshot); err != nil {
+		return nil, err
+	}
+	current[s.dir] = snapshot
+
+	retention, err := DefaultRetention()
+	if err == nil {
+		_, err = s.prune(retention, false)
+	}
+	if err != nil {
+		slog.Warn("failed to prune backups", "error", err)
+	}
+	return snapshot, nil
+}
+
+func writeManifest(snapshot *Snapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal backup manifest: %w", err)
+	}
+	if err := fileops.WriteFile(filepath.Join(snapshot.dir, ManifestName), data, 0600); err != nil {
+		return fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+	return nil
+}
+
+// List returns the snapshots in the store, oldest first
+func (s *Store) List() ([]Snapshot, error) {
+	dirs, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read backup store: %w", err)
+	}
+
+	var snapshots []Snapshot
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		dir := filepath.Join(s.dir, d.Name())
+		data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+		if err != nil {
+			slog.Warn("skipping backup snapshot without manifest", "dir", dir, "error", err)
+			continue
+		}
+		var snapshot Snapshot
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			slog.Warn("skipping backup snapshot with unreadable manifest", "dir", dir, "error", err)
+			continue
+		}
+		snapshot.dir = dir
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Time.Before(snapshots[j].Time)
+	})
+	return snapshots, nil
+}
+
+// Find returns the newest snapshot taken at or before at that holds path,
+// directly or inside a backed up directory. A zero at means any time.
+func (s *Store) Find(path string, at time.Time) (*Snapshot, error) {
+	snapshots, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		snapshot := snapshots[i]
+		if !at.IsZero() && snapshot.Time.After(at) {
+			continue
+		}
+		if _, found := snapshot.Covering(path); found {
+			return &snapshot, nil
+		}
+	}
+	if at.IsZero() {
+		return nil, fmt.Errorf("no backup of %s", path)
+	}
+	return nil, fmt.Errorf("no backup of %s taken at or before %s", path, at.Local().Format("2006-01-02 15:04:05"))
+}
+
+// Restore puts the content of path from snapshot back in place. Whatever is
+// at path now is saved to the store first.
+func (s *Store) Restore(snapshot *Snapshot, path string) error {
+	location := snapshot.Location(path)
+	if _, err := os.Lstat(location); err != nil {
+		return fmt.Errorf("backup of %s is missing from snapshot %s", path, snapshot.ID)
+	}
+
+	if _, err := os.Lstat(path); err == nil {
+		if _, err := s.Save(path); err != nil {
+			return err
+		}
+		if err := fileops.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+
+	if err := fileops.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create parent directory: %w", err)
+	}
+	if target, err := os.Readlink(location); err == nil {
+		err = fileops.Symlink(target, path)
+		if err != nil {
+			return fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+		return nil
+	}
+	if err := fileops.CopyPath(location, path); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", path, err)
+	}
+	return nil
+}
+
+// Prune removes the snapshots the retention policy lets go and returns them.
+// The snapshot of this run is never removed; nothing is removed in a dry
+// run.
+func (s *Store) Prune(retention Retention, dryRun bool) ([]Snapshot, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	return s.prune(retention, dryRun)
+}
+
+func (s *Store) prune(retention Retention, dryRun bool) ([]Snapshot, error) {
+	snapshots, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-retention.MaxAge)
+	var pruned []Snapshot
+	for i, snapshot := range snapshots {
+		if len(snapshots)-i <= retention.Keep || !snapshot.Time.Before(cutoff) {
+			continue
+		}
+		if own := current[s.dir]; own != nil && snapshot.ID == own.ID {
+			continue
+		}
+		if !dryRun {
+			if err := fileops.RemoveAll(snapshot.dir); err != nil {
+				return pruned, fmt.Errorf("failed to remove snapshot %s: %w", snapshot.ID, err)
+			}
+		}
+		pruned = append(pruned, snapshot)
+	}
+	return pruned, nil
+}
@@ -0,0 +1,304 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+const (
+	// FormatVersion is bumped when the bundle layout changes incompatibly
+	FormatVersion = 1
+
+	ManifestName  = "manifest.json"
+	RepoPrefix    = "repo/"
+	StateFileName = "bundle.json"
+)
+
+// Manifest describes the content of a bundle
+type Manifest struct {
+	Format    int       `json:"format"`
+	CreatedAt time.Time `json:"created_at"`
+	Hostname  string    `json:"hostname,omitempty"`
+	// HomeDir is the home directory the index's original paths refer to
+	HomeDir string `json:"home_dir"`
+	// Commit, Branch and Remote describe the git state the bundle was made
+	// from, so the tree can become a clone of it later
+	Commit  string `json:"commit,omitempty"`
+	Branch  string `json:"branch,omitempty"`
+	Remote  string `json:"remote,omitempty"`
+	Entries int    `json:"entries"`
+	Files   int    `json:"files"`
+}
+
+// Create writes the repository tree (without .git) and a manifest to a
+// gzip-compressed tar file. The manifest's Files count is filled in.
+func Create(repoDir, out string, manifest *Manifest) error {
+	f, err := os.Create(out)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	// Collect the tree first so the manifest, written first, can count it
+	var paths []string
+	err = filepath.Walk(repoDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if path != repoDir {
+			paths = append(paths, path)
+			if !info.IsDir() {
+				manifest.Files++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read repository: %w", err)
+	}
+
+	manifest.Format = FormatVersion
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	header := &tar.Header{Name: ManifestName, Mode: 0644, Size: int64(len(data)), ModTime: manifest.CreatedAt}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	for _, path := range paths {
+		if err := addPath(tw, repoDir, path); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finish bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to finish bundle: %w", err)
+	}
+	return f.Close()
+}
+
+// addPath writes a single file, directory or symlink below repoDir
+func addPath(tw *tar.Writer, repoDir, path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	link := ""
+	if info.Mode()&os.ModeSymlink != 0 {
+		if link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", path, err)
+	}
+	rel, err := filepath.Rel(repoDir, path)
+	if err != nil {
+		return err
+	}
+	header.Name = RepoPrefix + filepath.ToSlash(rel)
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	// Ownership means nothing on the target machine
+	header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to add %s: %w", path, err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.Copy(tw, f); err != nil {
+		return fmt.Errorf("failed to add %s: %w", path, err)
+	}
+	return nil
+}
+
+// Extract unpacks a bundle's repository tree into repoDir, which must not
+// exist yet, and returns its manifest
+func Extract(bundlePath, repoDir string) (*Manifest, error) {
+	if _, err := os.Lstat(repoDir); err == nil {
+		return nil, fmt.Errorf("directory already exists: %s", repoDir)
+	}
+
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	defer gz.Close()
+
+	var manifest *Manifest
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fileops.RemoveAll(repoDir)
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+
+		if header.Name == ManifestName {
+			manifest = &Manifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("invalid bundle manifest: %w", err)
+			}
+			if manifest.Format > FormatVersion {
+				return nil, fmt.Errorf("bundle format %d is newer than this dotman supports (%d)", manifest.Format, FormatVersion)
+			}
+			continue
+		}
+
+		if err := extractEntry(tr, header, repoDir); err != nil {
+			// Don't leave a half-extracted repository behind
+			fileops.RemoveAll(repoDir)
+			return nil, err
+		}
+	}
+
+	if manifest == nil {
+		fileops.RemoveAll(repoDir)
+		return nil, fmt.Errorf("bundle has no %s; is it a dotman bundle?", ManifestName)
+	}
+	return manifest, nil
+}
+
+// extractEntry writes one tar entry below repoDir, refusing paths that would
+// escape it
+func extractEntry(tr *tar.Reader, header *tar.Header, repoDir string) error {
+	if !strings.HasPrefix(header.Name, RepoPrefix) {
+		return nil
+	}
+	rel := filepath.FromSlash(strings.TrimPrefix(header.Name, RepoPrefix))
+	if rel == "" || rel == "." {
+		return nil
+	}
+	if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(filepath.Clean(rel), ".."+string(filepath.Separator)) {
+		return fmt.Errorf("bundle entry escapes the repository: %s", header.Name)
+	}
+	target := filepath.Join(repoDir, rel)
+
+	if err := fileops.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// A symlink extracted earlier must not redirect later entries
+	parent, err := filepath.EvalSymlinks(filepath.Dir(target))
+	if err != nil {
+		return err
+	}
+	root, err := filepath.EvalSymlinks(repoDir)
+	if err != nil {
+		return err
+	}
+	if parent != root && !strings.HasPrefix(parent, root+string(filepath.Separator)) {
+		return fmt.Errorf("bundle entry escapes the repository: %s", header.Name)
+	}
+
+	mode := os.FileMode(header.Mode).Perm()
+	switch header.Typeflag {
+	case tar.TypeDir:
+		if err := fileops.MkdirAll(target, mode|0700); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+	case tar.TypeSymlink:
+		if err := fileops.Symlink(header.Linkname, target); err != nil {
+			return fmt.Errorf("failed to create symlink: %w", err)
+		}
+	case tar.TypeReg:
+		out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", target, err)
+		}
+		if _, err := io.Copy(out, tr); err != nil {
+			out.Close()
+			return fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported bundle entry: %s", header.Name)
+	}
+
+	return nil
+}
+
+// StatePath returns where the manifest of a bootstrapped bundle is kept, so a
+// later 'dotman init' can connect the tree to its original remote
+func StatePath(cfg *types.Config) string {
+	return filepath.Join(cfg.StateDir, StateFileName)
+}
+
+// SaveState records the manifest of a bootstrapped bundle
+func SaveState(cfg *types.Config, manifest *Manifest) error {
+	if err := config.EnsureStateDir(cfg); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	return fileops.WriteFile(StatePath(cfg), data, 0644)
+}
+
+// LoadState returns the manifest of the bootstrapped bundle, or nil if the
+// repository didn't come from a bundle
+func LoadState(cfg *types.Config) (*Manifest, error) {
+	data, err := os.ReadFile(StatePath(cfg))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read bundle state: %w", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle state: %w", err)
+	}
+	return &manifest, nil
+}
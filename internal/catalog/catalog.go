@@ -0,0 +1,72 @@
+// Package catalog lists where common applications keep their configuration,
+// for 'dotman discover'. Only configuration is listed: no caches, history or
+// state, and no files that usually hold secrets (keys, tokens, credentials).
+package catalog
+
+// App is an application and the configuration files and directories it
+// reads
+type App struct {
+	Name string
+	// Home are paths relative to $HOME
+	Home []string
+	// Config are paths relative to $XDG_CONFIG_HOME (~/.config)
+	Config []string
+}
+
+// Apps is the built-in catalog. Paths of one app don't nest.
+var Apps = []App{
+	// Shells and the terminal
+	{Name: "bash", Home: []string{".bashrc", ".bash_profile", ".bash_aliases", ".bash_logout"}},
+	{Name: "sh", Home: []string{".profile"}},
+	{Name: "zsh", Home: []string{".zshrc", ".zshenv", ".zprofile", ".zlogin", ".p10k.zsh"}},
+	{Name: "fish", Config: []string{"fish/config.fish", "fish/functions", "fish/conf.d"}},
+	{Name: "readline", Home: []string{".inputrc"}},
+	{Name: "starship", Config: []string{"starship.toml"}},
+	{Name: "atuin", Config: []string{"atuin/config.toml"}},
+	{Name: "direnv", Config: []string{"direnv/direnvrc", "direnv/direnv.toml"}},
+	{Name: "tmux", Home: []string{".tmux.conf"}, Config: []string{"tmux/tmux.conf"}},
+	{Name: "screen", Home: []string{".screenrc"}},
+	{Name: "zellij", Config: []string{"zellij"}},
+
+	// Terminal emulators
+	{Name: "alacritty", Config: []string{"alacritty"}},
+	{Name: "kitty", Config: []string{"kitty"}},
+	{Name: "wezterm", Home: []string{".wezterm.lua"}, Config: []string{"wezterm"}},
+	{Name: "foot", Config: []string{"foot"}},
+	{Name: "ghostty", Config: []string{"ghostty"}},
+
+	// Editors
+	{Name: "vim", Home: []string{".vimrc", ".gvimrc"}},
+	{Name: "neovim", Config: []string{"nvim"}},
+	{Name: "emacs", Home: []string{".emacs", ".emacs.d/init.el", ".emacs.d/early-init.el"}, Config: []string{"emacs/init.el", "emacs/early-init.el"}},
+	{Name: "helix", Config: []string{"helix"}},
+	{Name: "vscode", Config: []string{"Code/User/settings.json", "Code/User/keybindings.json", "Code/User/snippets"}},
+
+	// Development tools
+	{Name: "git", Home: []string{".gitconfig", ".gitignore_global"}, Config: []string{"git/config", "git/ignore", "git/attributes"}},
+	{Name: "lazygit", Config: []string{"lazygit/config.yml"}},
+	{Name: "ssh", Home: []string{".ssh/config"}},
+	{Name: "aws", Home: []string{".aws/config"}},
+	{Name: "curl", Home: []string{".curlrc"}},
+	{Name: "wget", Home: []string{".wgetrc"}},
+
+	// Desktop
+	{Name: "X11", Home: []string{".Xresources", ".xinitrc", ".xprofile"}},
+	{Name: "i3", Config: []string{"i3"}},
+	{Name: "sway", Config: []string{"sway"}},
+	{Name: "hyprland", Config: []string{"hypr"}},
+	{Name: "waybar", Config: []string{"waybar"}},
+	{Name: "polybar", Config: []string{"polybar"}},
+	{Name: "rofi", Config: []string{"rofi"}},
+	{Name: "dunst", Config: []string{"dunst"}},
+	{Name: "picom", Config: []string{"picom.conf", "picom"}},
+
+	// Utilities
+	{Name: "htop", Config: []string{"htop/htoprc"}},
+	{Name: "btop", Config: []string{"btop/btop.conf"}},
+	{Name: "bat", Config: []string{"bat/config"}},
+	{Name: "ranger", Config: []string{"ranger/rc.conf", "ranger/rifle.conf"}},
+	{Name: "yazi", Config: []string{"yazi"}},
+	{Name: "mpv", Config: []string{"mpv/mpv.conf", "mpv/input.conf"}},
+	{Name: "zathura", Config: []string{"zathura/zathurarc"}},
+}
@@ -2,15 +2,11 @@ package cli
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 
 	"github.com/spf13/cobra"
 
 	"github.com/Merith-TK/dotman/internal/config"
-	"github.com/Merith-TK/dotman/internal/fileops"
-	"github.com/Merith-TK/dotman/internal/git"
-	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
 )
 
 var addCmd = &cobra.Command{
@@ -19,138 +15,84 @@ var addCmd = &cobra.Command{
 	Long: `Add files to dotman management. Files are moved to the dotman repo
 and symlinks are created in their original locations.
 
+With --host, the file is stored in this machine's overlay layer
+(.dotman/layers/hosts/<hostname>/... in the repo) instead of the shared
+base. An already managed file gets a host-specific copy that shadows the
+base one on this machine only.
+
+A path inside a managed directory is already tracked as part of it; with
+--split, the directory link is replaced by a real directory whose contents
+are linked one by one, so the path becomes an entry of its own. Adding a
+directory whose children are already managed needs --merge, which folds
+those entries and the rest of the directory into one directory entry.
+
+With --include, a directory is managed partially: it stays a real directory
+and only files matching the include patterns (and none of the --exclude
+patterns) are moved into the repo and linked one by one. Patterns without a
+slash match file names at any depth; ** matches any number of directories.
+Newly created matching files are listed by 'dotman status' as untracked
+candidates and can be tracked with 'dotman add <file>'.
+
+With --glob, the target is a pattern resolved on every deploy, for
+locations with generated names. The first existing match is moved into the
+repo (unless the repo path already exists) and every target selected by
+--policy is linked to it.
+
 Examples:
   dotman add ~/.config/sway
   dotman add ~/.bashrc ~/.bash_aliases
-  dotman add ~/.bash*`,
-	Args: cobra.MinimumNArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		return runAddMultiple(args)
+  dotman add ~/.bash*
+  dotman add --host ~/.gitconfig
+  dotman add --split ~/.config/nvim/lua/plugins.lua
+  dotman add --merge ~/.config/fish
+  dotman add ~/.config/Code/User --include '*.json' --include 'snippets/**' --exclude globalStorage
+  dotman add --glob '~/.mozilla/firefox/*.default-release/chrome/userChrome.css'`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if glob, _ := cmd.Flags().GetString("glob"); glob != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
 	},
-}
-
-func runAdd(path string) error {
-	// Expand the path
-	expandedPath, err := config.ExpandPath(cfg, path)
-	if err != nil {
-		return fmt.Errorf("failed to expand path: %w", err)
-	}
-
-	// Check if path exists
-	if !fileops.PathExists(expandedPath) {
-		return fmt.Errorf("path does not exist: %s", expandedPath)
-	}
-
-	// Check if path is inside home directory
-	if !config.IsInsideHome(cfg, expandedPath) {
-		return fmt.Errorf("path must be inside home directory: %s", expandedPath)
-	}
-
-	// Ensure dotman directory exists
-	if err := config.EnsureDotmanDir(cfg); err != nil {
-		return fmt.Errorf("failed to create dotman directory: %w", err)
-	}
-
-	// Ensure git repository is initialized
-	if err := git.EnsureRepo(cfg.DotmanDir); err != nil {
-		return fmt.Errorf("failed to initialize git repository: %w", err)
-	}
-
-	// Load index
-	idx, err := index.Load(cfg.IndexFile)
-	if err != nil {
-		return fmt.Errorf("failed to load index: %w", err)
-	}
-
-	// Check if already managed
-	if index.IsManaged(idx, expandedPath) {
-		return fmt.Errorf("path is already managed: %s", expandedPath)
-	}
-
-	// Calculate repo path
-	relativePath, err := config.RelativeToHome(cfg, expandedPath)
-	if err != nil {
-		return fmt.Errorf("failed to get relative path: %w", err)
-	}
-
-	// Prevent tracking repository metadata like .dotman and README.md
-	if config.ShouldIgnoreRepoPath(cfg, relativePath) {
-		return fmt.Errorf("refusing to track repository metadata: %s", relativePath)
-	}
-
-	repoPath := filepath.Join(cfg.DotmanDir, relativePath)
-
-	// Get file type
-	fileType := fileops.GetFileType(expandedPath)
-
-	fmt.Printf("Adding %s to dotman management...\n", expandedPath)
-
-	// Move file to repo
-	if err := fileops.MoveToRepo(expandedPath, repoPath); err != nil {
-		return fmt.Errorf("failed to move file to repo: %w", err)
-	}
-
-	// Create symlink
-	if err := fileops.CreateSymlink(expandedPath, repoPath); err != nil {
-		// Try to restore the file if symlink creation fails
-		os.Rename(repoPath, expandedPath)
-		return fmt.Errorf("failed to create symlink: %w", err)
-	}
-
-	// Add to index
-	index.AddFile(idx, expandedPath, relativePath, fileType)
-
-	// Save index
-	if err := index.Save(idx, cfg.IndexFile); err != nil {
-		return fmt.Errorf("failed to save index: %w", err)
-	}
-
-	// Commit changes
-	if err := git.Add(cfg.DotmanDir); err != nil {
-		return fmt.Errorf("failed to stage changes: %w", err)
-	}
-
-	// Convert to $HOME relative path for commit message
-	homeRelPath, err := config.RelativeToHome(cfg, expandedPath)
-	if err != nil {
-		// Fallback to repo path if conversion fails
-		homeRelPath = relativePath
-	}
-	homePath := "$HOME/" + homeRelPath
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := acquireLock("add"); err != nil {
+			return err
+		}
 
-	commitMsg := fmt.Sprintf("Add %s to dotman management", homePath)
-	if err := git.Commit(cfg.DotmanDir, commitMsg); err != nil {
-		return fmt.Errorf("failed to commit changes: %w", err)
-	}
+		opts := types.AddOptions{}
+		opts.DryRun, _ = cmd.Flags().GetBool("dry-run")
+		opts.Backup, _ = cmd.Flags().GetBool("backup")
+		opts.Merge, _ = cmd.Flags().GetBool("merge")
+		opts.Split, _ = cmd.Flags().GetBool("split")
+		if host, _ := cmd.Flags().GetBool("host"); host {
+			if cfg.Hostname == "" {
+				return fmt.Errorf("cannot determine host name; set DOTMAN_HOST")
+			}
+			opts.Layer = config.HostLayer(cfg.Hostname)
+		}
+		include, _ := cmd.Flags().GetStringSlice("include")
+		exclude, _ := cmd.Flags().GetStringSlice("exclude")
+		if len(include) > 0 || len(exclude) > 0 {
+			opts.Partial = &types.PartialDir{Include: include, Exclude: exclude}
+		}
 
-	fmt.Printf("Successfully added %s to dotman management\n", path)
-	return nil
+		if glob, _ := cmd.Flags().GetString("glob"); glob != "" {
+			policy, _ := cmd.Flags().GetString("policy")
+			opts.Policy = types.MatchPolicy(policy)
+			opts.RepoPath, _ = cmd.Flags().GetString("repo-path")
+			return manager().AddGlob(glob, opts)
+		}
+		return runAddMultiple(args, opts)
+	},
 }
 
-func runAddMultiple(paths []string) error {
-	var successCount int
-	var failures []string
-
-	for _, path := range paths {
-		err := runAdd(path)
-		if err != nil {
-			failures = append(failures, fmt.Sprintf("%s: %v", path, err))
-		} else {
-			successCount++
-		}
+func runAddMultiple(paths []string, opts types.AddOptions) error {
+	result, err := manager().Add(paths, opts)
+	if result == nil {
+		return err
 	}
 
-	if len(failures) > 0 {
-		fmt.Printf("\nCompleted with %d successes and %d failures:\n", successCount, len(failures))
-		for _, failure := range failures {
-			fmt.Printf("  Error: %s\n", failure)
-		}
-		if successCount == 0 {
-			return fmt.Errorf("all operations failed")
-		}
-	} else if successCount > 1 {
-		fmt.Printf("\nSuccessfully added %d files to dotman management\n", successCount)
+	if !printFailures(result) && !opts.DryRun && result.Succeeded() > 1 {
+		fmt.Printf("\nSuccessfully added %d files to dotman management\n", result.Succeeded())
 	}
-
-	return nil
+	return err
 }
@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Merith-TK/dotman/internal/backup"
+	"github.com/Merith-TK/dotman/internal/config"
+)
+
+var backupsCmd = &cobra.Command{
+	Use:   "backups",
+	Short: "List, restore and prune backups of replaced files",
+	Long: `Content dotman replaces or deletes is saved to a machine-local backup
+store first: files in the way of a link ('deploy --backup', 'status --fix
+--backup', 'import --force'), repo copies dropped by 'remove --delete' and,
+with 'add --backup', files before they are moved into the repo.
+
+Each dotman run that saves something gets one snapshot in
+~/.local/state/dotman/backups (or $XDG_STATE_HOME/dotman/backups), named
+after the time it was taken, with the files stored by their absolute path.
+
+When a new snapshot is taken, snapshots older than 30 days are pruned,
+except for the newest 10. DOTMAN_BACKUP_MAX_AGE and DOTMAN_BACKUP_KEEP
+change these limits.`,
+}
+
+var backupsListCmd = &cobra.Command{
+	Use:   "list [path]",
+	Short: "List backup snapshots",
+	Long: `List the backup snapshots and what they hold, oldest first. With a
+path, only snapshots holding it (or a directory above it) are listed.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := ""
+		if len(args) == 1 {
+			path = args[0]
+		}
+		return runBackupsList(path)
+	},
+}
+
+var backupsRestoreCmd = &cobra.Command{
+	Use:   "restore <path>",
+	Short: "Restore a path from the backup store",
+	Long: `Put a backed up file or directory back in place. The newest backup is
+used, or with --at the newest taken at or before that time. --at takes a
+duration ago (30m, 12h, 7d), a date (2006-01-02) or a timestamp.
+
+Whatever is at the path now, including a dotman link, is saved to the
+backup store before it is replaced. Paths inside a backed up directory can be
+restored on their own.
+
+Examples:
+  dotman backups restore ~/.bashrc
+  dotman backups restore ~/.config/nvim/init.lua --at 2d`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		atFlag, _ := cmd.Flags().GetString("at")
+		at, err := parseTime(atFlag, time.Now())
+		if err != nil {
+			return &UsageError{Err: err}
+		}
+
+		if err := acquireLock("backups restore"); err != nil {
+			return err
+		}
+		return runBackupsRestore(args[0], at)
+	},
+}
+
+var backupsPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove old backup snapshots",
+	Long: `Remove snapshots older than --max-age, keeping at least the newest --keep
+snapshots whatever their age. The defaults come from DOTMAN_BACKUP_MAX_AGE
+and DOTMAN_BACKUP_KEEP (30d and 10).
+
+Examples:
+  dotman backups prune --dry-run
+  dotman backups prune --max-age 7d --keep 3`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		retention, err := backup.DefaultRetention()
+		if err != nil {
+			return err
+		}
+		if cmd.Flags().Changed("keep") {
+			retention.Keep, _ = cmd.Flags().GetInt("keep")
+		}
+		if cmd.Flags().Changed("max-age") {
+			maxAge, _ := cmd.Flags().GetString("max-age")
+			if retention.MaxAge, err = config.ParseDuration(maxAge); err != nil {
+				return &UsageError{Err: err}
+			}
+		}
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if err := acquireLock("backups prune"); err != nil {
+			return err
+		}
+		return runBackupsPrune(retention, dryRun)
+	},
+}
+
+func init() {
+	backupsCmd.AddCommand(backupsListCmd)
+	backupsCmd.AddCommand(backupsRestoreCmd)
+	backupsCmd.AddCommand(backupsPruneCmd)
+
+	backupsRestoreCmd.Flags().String("at", "", "Restore the newest backup taken at or before this time")
+	backupsPruneCmd.Flags().Int("keep", 10, "Always keep this many of the newest snapshots")
+	backupsPruneCmd.Flags().String("max-age", "30d", "Remove snapshots older than this")
+	backupsPruneCmd.Flags().BoolP("dry-run", "n", false, "Show what would be removed without removing it")
+}
+
+func runBackupsList(path string) error {
+	store := backup.New(cfg)
+	snapshots, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	if path != "" {
+		expanded, err := expandFilterPath(path)
+		if err != nil {
+			return err
+		}
+		var matching []backup.Snapshot
+		for _, snapshot := range snapshots {
+			if _, found := snapshot.Covering(expanded); found {
+				matching = append(matching, snapshot)
+			}
+		}
+		snapshots = matching
+	}
+
+	if len(snapshots) == 0 {
+		fmt.Println("No backups.")
+		return nil
+	}
+
+	for i, snapshot := range snapshots {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s  %s\n", snapshot.ID, snapshot.Time.Local().Format("2006-01-02 15:04:05"))
+		for _, entry := range snapshot.Entries {
+			fmt.Printf("  %s (%s)\n", entry.Path, entry.Type)
+		}
+	}
+	return nil
+}
+
+func runBackupsRestore(path string, at time.Time) error {
+	expanded, err := expandFilterPath(path)
+	if err != nil {
+		return err
+	}
+
+	store := backup.New(cfg)
+	snapshot, err := store.Find(expanded, at)
+	if err != nil {
+		return err
+	}
+
+	if err := store.Restore(snapshot, expanded); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Restored %s from backup %s (%s)\n", expanded, snapshot.ID, snapshot.Time.Local().Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+func runBackupsPrune(retention backup.Retention, dryRun bool) error {
+	pruned, err := backup.New(cfg).Prune(retention, dryRun)
+	for _, snapshot := range pruned {
+		if dryRun {
+			fmt.Printf("Would remove %s (%d path(s))\n", snapshot.ID, len(snapshot.Entries))
+		} else {
+			fmt.Printf("Removed %s (%d path(s))\n", snapshot.ID, len(snapshot.Entries))
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(pruned) == 0 {
+		fmt.Println("Nothing to prune.")
+	}
+	return nil
+}
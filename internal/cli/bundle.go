@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export --bundle <file.tar.gz>",
+	Short: "Export the repo as a self-contained bundle",
+	Long: `Export packs the dotman repo tree, its index and a manifest into a
+gzip-compressed tar file. Git history is not included. Use 'dotman bootstrap'
+to deploy the bundle on a machine without git.
+
+Example:
+  dotman export --bundle dotfiles.tar.gz`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out, _ := cmd.Flags().GetString("bundle")
+		return runExport(out)
+	},
+}
+
+var bootstrapCmd = &cobra.Command{
+	Use:   "bootstrap <file.tar.gz>",
+	Short: "Unpack a bundle and deploy it without git",
+	Long: `Bootstrap unpacks a bundle created with 'dotman export --bundle' into the
+dotman directory and deploys it. Git is not needed. Run 'dotman init' later,
+or pass --git, to turn the tree into a git repository connected to the
+remote the bundle was made from.
+
+Example:
+  dotman bootstrap dotfiles.tar.gz`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		withGit, _ := cmd.Flags().GetBool("git")
+		if err := acquireLock("bootstrap"); err != nil {
+			return err
+		}
+		return runBootstrap(args[0], withGit)
+	},
+}
+
+func init() {
+	exportCmd.Flags().String("bundle", "", "Bundle file to write")
+	exportCmd.MarkFlagRequired("bundle")
+
+	bootstrapCmd.Flags().Bool("git", false, "Initialize a git repository after unpacking")
+}
+
+func runExport(out string) error {
+	info, err := manager().Export(out)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %d entries (%d files) to %s\n", info.Entries, info.Files, info.Path)
+	return nil
+}
+
+func runBootstrap(bundlePath string, withGit bool) error {
+	m := manager()
+	if _, err := m.Bootstrap(bundlePath, types.BootstrapOptions{Git: withGit}); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	_, err := m.Deploy(types.DeployOptions{})
+	return err
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Merith-TK/dotman/internal/git"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [<path>]",
+	Short: "Show changes to managed files",
+	Long: `Show changes to managed files that haven't been committed yet, or,
+with --since, all changes since the given revision.
+
+Without a path, the whole dotman repo is compared.
+
+Examples:
+  dotman diff
+  dotman diff ~/.zshrc
+  dotman diff ~/.config/nvim --since HEAD~5`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		since, _ := cmd.Flags().GetString("since")
+		path := ""
+		if len(args) == 1 {
+			path = args[0]
+		}
+		return runDiff(path, since)
+	},
+}
+
+func init() {
+	diffCmd.Flags().String("since", "HEAD", "Revision to compare the working tree against")
+}
+
+func runDiff(path, since string) error {
+	args, err := manager().DiffArgs(path, since)
+	if err != nil {
+		return err
+	}
+
+	if err := git.RunAttached(cfg.DotmanDir, args...); err != nil {
+		return fmt.Errorf("git diff failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/pkg/dotman"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+var discoverCmd = &cobra.Command{
+	Use:   "discover",
+	Short: "Find unmanaged config files of common applications",
+	Long: `Look for the configuration of common applications (shells, editors,
+terminals, git, desktop tools, ...) in your home directory and
+$XDG_CONFIG_HOME, using a catalog built into dotman. Paths that are already
+managed are left out.
+
+Each candidate is listed with its size, number of files and last
+modification, and you pick the ones to add by number. They are added like
+with 'dotman add', in a single commit.
+
+Paths holding cache directories, binary files or a git repository, and
+paths larger than --max-size, are skipped; --all lists them with the reason.
+Files usually holding secrets (ssh keys, tokens) are not in the catalog.
+
+Examples:
+  dotman discover
+  dotman discover --max-size 5M
+  dotman discover -n --all
+  dotman discover -y`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		maxSize, err := config.ParseSize(cmd.Flag("max-size").Value.String())
+		if err != nil {
+			return &UsageError{Err: err}
+		}
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		yes, _ := cmd.Flags().GetBool("yes")
+		all, _ := cmd.Flags().GetBool("all")
+		if dryRun && yes {
+			return &UsageError{Err: fmt.Errorf("--dry-run and --yes can't be combined")}
+		}
+		return runDiscover(types.DiscoverOptions{MaxSize: maxSize}, dryRun, yes, all)
+	},
+}
+
+func init() {
+	discoverCmd.Flags().String("max-size", "1M", "Skip paths larger than this (K, M and G suffixes; 0 for no limit)")
+	discoverCmd.Flags().BoolP("dry-run", "n", false, "Only list what was found")
+	discoverCmd.Flags().BoolP("yes", "y", false, "Add every candidate without asking")
+	discoverCmd.Flags().Bool("all", false, "Also list skipped paths and why they were skipped")
+}
+
+func runDiscover(opts types.DiscoverOptions, dryRun, yes, all bool) error {
+	found, err := manager().Discover(opts)
+	if err != nil {
+		return err
+	}
+
+	var candidates, skipped []dotman.Candidate
+	for _, candidate := range found {
+		if candidate.Skip != "" {
+			skipped = append(skipped, candidate)
+		} else {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	if len(candidates) == 0 {
+		fmt.Println("No unmanaged configuration found.")
+	} else {
+		fmt.Printf("Found %d unmanaged path(s):\n", len(candidates))
+		for i, candidate := range candidates {
+			fmt.Printf("  %3d  %-40s %-10s %-16s %s\n", i+1, displayPath(candidate), candidate.App,
+				describeSize(candidate), candidate.ModTime.Format("2006-01-02 15:04"))
+		}
+	}
+
+	if all && len(skipped) > 0 {
+		fmt.Printf("\nSkipped %d path(s):\n", len(skipped))
+		for _, candidate := range skipped {
+			fmt.Printf("       %-40s %-10s %s\n", displayPath(candidate), candidate.App, candidate.Skip)
+		}
+	} else if len(skipped) > 0 {
+		fmt.Printf("\nSkipped %d path(s) holding caches, binary or large files; see --all\n", len(skipped))
+	}
+
+	if dryRun || len(candidates) == 0 {
+		return nil
+	}
+
+	var picked []int
+	if yes {
+		for i := range candidates {
+			picked = append(picked, i)
+		}
+	} else {
+		fmt.Print("\nAdd which? (e.g. 1 3-5, all; empty for none): ")
+		response, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		picked, err = parseSelection(response, len(candidates))
+		if err != nil {
+			return &UsageError{Err: err}
+		}
+	}
+	if len(picked) == 0 {
+		fmt.Println("Nothing added.")
+		return nil
+	}
+
+	if err := acquireLock("discover"); err != nil {
+		return err
+	}
+
+	paths := make([]string, 0, len(picked))
+	for _, i := range picked {
+		paths = append(paths, candidates[i].Path)
+	}
+	return runAddMultiple(paths, types.AddOptions{Batch: true})
+}
+
+// parseSelection parses space or comma separated numbers and ranges (1 3-5)
+// or "all" into indexes of n choices
+func parseSelection(input string, n int) ([]int, error) {
+	fields := strings.FieldsFunc(strings.ToLower(input), func(r rune) bool {
+		return r == ' ' || r == ',' || r == '\t' || r == '\n' || r == '\r'
+	})
+
+	var picked []int
+	seen := make(map[int]bool)
+	for _, field := range fields {
+		first, last := field, field
+		if field == "all" {
+			first, last = "1", strconv.Itoa(n)
+		} else if before, after, ok := strings.Cut(field, "-"); ok {
+			first, last = before, after
+		}
+
+		low, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection %q", field)
+		}
+		high, err := strconv.Atoi(last)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection %q", field)
+		}
+		if low < 1 || high > n || low > high {
+			return nil, fmt.Errorf("selection %q out of range 1-%d", field, n)
+		}
+
+		for i := low - 1; i < high; i++ {
+			if !seen[i] {
+				seen[i] = true
+				picked = append(picked, i)
+			}
+		}
+	}
+	return picked, nil
+}
+
+// displayPath shows a candidate relative to the home directory, with a
+// trailing slash for directories
+func displayPath(candidate dotman.Candidate) string {
+	path := candidate.Path
+	if rel, err := filepath.Rel(cfg.HomeDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		path = "~/" + rel
+	}
+	if candidate.Type == types.FileTypeDirectory {
+		path += "/"
+	}
+	return path
+}
+
+// describeSize shows the size of a candidate, and the number of files of a
+// directory
+func describeSize(candidate dotman.Candidate) string {
+	size := formatSize(candidate.Size)
+	if candidate.Type == types.FileTypeDirectory {
+		return fmt.Sprintf("%s, %d file(s)", size, candidate.Files)
+	}
+	return size
+}
+
+// formatSize formats a number of bytes with a binary unit (1.5K, 12M)
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	value, suffix := float64(size), ""
+	for _, s := range []string{"K", "M", "G"} {
+		if value < unit {
+			break
+		}
+		value /= unit
+		suffix = s
+	}
+	return fmt.Sprintf("%.1f%s", value, suffix)
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Merith-TK/dotman/pkg/dotman"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the health of the dotman setup",
+	Long: `Doctor runs a series of health checks against the index, the repo,
+the deployed symlinks, git and the dotman lock, and suggests a command to fix
+each problem it finds.
+
+With --fix-safe, problems that can be repaired without risk of losing data
+(missing symlinks, redundant or duplicate index entries) are repaired
+automatically. Stale locks are replaced by the next command that needs the lock.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fixSafe, _ := cmd.Flags().GetBool("fix-safe")
+		return runDoctor(fixSafe)
+	},
+}
+
+func init() {
+	doctorCmd.Flags().Bool("fix-safe", false, "Automatically repair problems that are safe to fix")
+}
+
+func runDoctor(fixSafe bool) error {
+	checks := manager().Doctor()
+
+	problems := 0
+	warnings := 0
+	var repairs []dotman.DoctorCheck
+
+	for _, check := range checks {
+		symbol := "✓"
+		switch check.Severity {
+		case dotman.SeverityWarning:
+			symbol = "⚠️ "
+		case dotman.SeverityFailure:
+			symbol = "✗"
+		}
+
+		fmt.Printf("%s %s: %s\n", symbol, check.Name, check.Summary)
+		for _, detail := range check.Details {
+			fmt.Printf("    %s\n", detail)
+		}
+
+		if check.Severity == dotman.SeverityOK {
+			continue
+		}
+		if check.Severity == dotman.SeverityFailure {
+			problems++
+		} else {
+			warnings++
+		}
+		if check.Fix != "" {
+			fmt.Printf("    Fix: %s\n", check.Fix)
+		}
+		if check.Repair != nil {
+			repairs = append(repairs, check)
+		}
+	}
+
+	if fixSafe && len(repairs) > 0 {
+		fmt.Println("\nApplying safe repairs...")
+		if err := acquireLock("doctor"); err != nil {
+			return err
+		}
+
+		for _, check := range repairs {
+			fmt.Printf("🔧 %s\n", check.Name)
+			if err := check.Repair(); err != nil {
+				fmt.Printf("    Failed: %v\n", err)
+				continue
+			}
+			if check.Severity == dotman.SeverityFailure {
+				problems--
+			} else {
+				warnings--
+			}
+		}
+	} else if len(repairs) > 0 {
+		fmt.Printf("\n%d problem(s) can be repaired automatically with 'dotman doctor --fix-safe'\n", len(repairs))
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("%d problem(s) found", problems)
+	}
+
+	if warnings > 0 {
+		fmt.Printf("\nNo problems found (%d warning(s)).\n", warnings)
+	} else {
+		fmt.Println("\nNo problems found.")
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/pkg/dotman"
+)
+
+var fsckCmd = &cobra.Command{
+	Use:   "fsck",
+	Short: "Verify managed content against recorded digests",
+	Long: `Fsck verifies the content of every managed file and directory, including
+its copies in overlay layers, against the digest recorded in the index at the
+last dotman commit, and against the git HEAD, and reports what was changed
+outside dotman.
+
+With --update, the current content is committed and its digests recorded.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		update, _ := cmd.Flags().GetBool("update")
+		if update {
+			if err := acquireLock("fsck"); err != nil {
+				return err
+			}
+		}
+		return runFsck(update)
+	},
+}
+
+func init() {
+	fsckCmd.Flags().Bool("update", false, "Commit the current content and record its digests")
+}
+
+func runFsck(update bool) error {
+	m := manager()
+	report, err := m.Fsck()
+	if err != nil {
+		return err
+	}
+
+	if report.Managed == 0 {
+		fmt.Println("No files are managed by dotman.")
+		return nil
+	}
+
+	for _, check := range report.Checks {
+		label := check.File.OriginalPath
+		if check.Layer != config.BaseLayer {
+			label = fmt.Sprintf("%s (layer %s)", check.File.OriginalPath, check.Layer)
+		}
+
+		switch check.State {
+		case dotman.ContentOK:
+			fmt.Printf("✓ %s\n", label)
+		case dotman.ContentUnrecorded:
+			fmt.Printf("? %s - %s\n", label, check.State)
+		case dotman.ContentHashFailed:
+			fmt.Printf("✗ %s - %s: %v\n", label, check.State, check.Err)
+		default:
+			fmt.Printf("✗ %s - %s\n", label, check.State)
+		}
+	}
+
+	changed := report.Changed()
+	if !update {
+		if changed > 0 || report.Unrecorded() > 0 {
+			fmt.Println("\nRun 'dotman fsck --update' to accept the current content.")
+		}
+		if changed > 0 {
+			return fmt.Errorf("%d managed path(s) changed outside dotman", changed)
+		}
+		return nil
+	}
+
+	committed, err := m.RecordDigests()
+	if err != nil {
+		return err
+	}
+	if !committed {
+		fmt.Println("\nRecorded content digests (not a git repository, nothing committed)")
+		return nil
+	}
+	fmt.Println("\nRecorded content digests for all managed paths")
+	return nil
+}
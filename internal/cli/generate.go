@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/internal/installscript"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate files from the index",
+}
+
+var generateInstallScriptCmd = &cobra.Command{
+	Use:   "install-script",
+	Short: "Generate a POSIX shell script that deploys without dotman",
+	Long: `Generate a POSIX shell script from index.json that deploys the repo the
+way 'dotman deploy' would on this machine: the same entries and overlay
+layers, with the pre-deploy and post-deploy hooks. Unlike deploy, the script
+backs up files that are in the way instead of skipping them.
+
+The script needs only the repo checkout. DOTMAN_REPO, DOTMAN_HOME,
+DOTMAN_PROFILE and DOTMAN_INSTALL_MODE (link or copy) can be overridden
+when it runs.
+
+Examples:
+  dotman generate install-script -o install.sh
+  dotman generate install-script --profile ci --copy > install.sh`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		copyFiles, _ := cmd.Flags().GetBool("copy")
+		return runGenerateInstallScript(output, copyFiles)
+	},
+}
+
+func init() {
+	generateCmd.AddCommand(generateInstallScriptCmd)
+
+	generateInstallScriptCmd.Flags().StringP("output", "o", "", "Write the script to a file instead of stdout")
+	generateInstallScriptCmd.Flags().Bool("copy", false, "Copy files instead of linking them by default")
+}
+
+func runGenerateInstallScript(output string, copyFiles bool) error {
+	if !config.DotmanDirExists(cfg) {
+		return types.Errorf(types.ErrRepoMissing, "dotman directory does not exist: %s", cfg.DotmanDir)
+	}
+
+	idx, err := index.Load(cfg.IndexFile)
+	if err != nil {
+		return fmt.Errorf("failed to load index: %w", err)
+	}
+
+	script, err := installscript.FromPlan(cfg, manager().PlanDeploy(idx), copyFiles)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := installscript.Write(&buf, script); err != nil {
+		return err
+	}
+
+	if output == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+
+	if err := fileops.WriteFile(output, buf.Bytes(), 0755); err != nil {
+		return fmt.Errorf("failed to write install script: %w", err)
+	}
+	fmt.Printf("Wrote install script for %d entries to %s\n", len(script.Entries), output)
+	return nil
+}
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/git"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+var gitCmd = &cobra.Command{
+	Use:   "git -- <args>...",
+	Short: "Run git inside the dotman repository",
+	Long: `Run any git command inside the dotman repository, with the terminal
+attached. The exit code of git is passed through.
+
+If the command moves HEAD (checkout, reset, merge, rebase, pull, ...), the
+deployed symlinks are reconciled with the new index afterwards, as after
+'dotman sync --pull'.
+
+Examples:
+  dotman git -- log --oneline
+  dotman git -- checkout HEAD~1 -- .zshrc
+  dotman git -- reset --hard origin/main`,
+	DisableFlagParsing: true,
+	SilenceUsage:       true,
+	SilenceErrors:      true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Flag parsing is off so that git gets its own flags; the root flags
+		// in front of the git arguments are taken here
+		rest, err := parseRootFlags(cmd, args)
+		if err != nil {
+			return &UsageError{Err: err}
+		}
+		gitArgs = rest
+		return rootCmd.PersistentPreRunE(cmd, rest)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(gitArgs) == 1 && (gitArgs[0] == "-h" || gitArgs[0] == "--help") {
+			return cmd.Help()
+		}
+		return runGit(gitArgs)
+	},
+}
+
+// gitArgs are the arguments for git, without the root flags before them
+var gitArgs []string
+
+// parseRootFlags sets the root flags (--source, -q, -v, ...) at the start of
+// args, up to "--" or the first argument that isn't one of them, and returns
+// the remaining arguments
+func parseRootFlags(cmd *cobra.Command, args []string) ([]string, error) {
+	flags := cmd.InheritedFlags()
+
+	for len(args) > 0 {
+		arg := args[0]
+		switch {
+		case arg == "--":
+			return args[1:], nil
+
+		case strings.HasPrefix(arg, "--"):
+			name, value, hasValue := strings.Cut(arg[2:], "=")
+			flag := flags.Lookup(name)
+			if flag == nil {
+				return args, nil
+			}
+			args = args[1:]
+
+			if !hasValue {
+				value = flag.NoOptDefVal
+				if value == "" {
+					if len(args) == 0 {
+						return nil, fmt.Errorf("flag needs an argument: --%s", name)
+					}
+					value, args = args[0], args[1:]
+				}
+			}
+			if err := flags.Set(name, value); err != nil {
+				return nil, fmt.Errorf("invalid argument %q for --%s: %w", value, name, err)
+			}
+
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// Only a group of root flags without values, like -vv or -q
+			var group []*pflag.Flag
+			for _, shorthand := range arg[1:] {
+				flag := flags.ShorthandLookup(string(shorthand))
+				if flag == nil || flag.NoOptDefVal == "" {
+					return args, nil
+				}
+				group = append(group, flag)
+			}
+			args = args[1:]
+
+			for _, flag := range group {
+				if err := flags.Set(flag.Name, flag.NoOptDefVal); err != nil {
+					return nil, err
+				}
+			}
+
+		default:
+			return args, nil
+		}
+	}
+	return args, nil
+}
+
+// ExitError carries an exit code out of Execute without printing anything
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+func runGit(args []string) error {
+	if !config.DotmanDirExists(cfg) {
+		return types.Errorf(types.ErrRepoMissing, "dotman directory does not exist: %s", cfg.DotmanDir)
+	}
+
+	if !git.IsGitRepo(cfg.DotmanDir) {
+		return types.Errorf(types.ErrRepoMissing, "dotman directory is not a git repository")
+	}
+
+	// An unreadable index only matters if HEAD moves
+	oldIdx, _ := index.Load(cfg.IndexFile)
+	oldHead := git.GetHead(cfg.DotmanDir)
+
+	runErr := git.RunAttached(cfg.DotmanDir, args...)
+
+	var exitErr *exec.ExitError
+	if runErr != nil && !errors.As(runErr, &exitErr) {
+		return fmt.Errorf("failed to run git: %w", runErr)
+	}
+
+	if git.GetHead(cfg.DotmanDir) != oldHead {
+		if runErr != nil {
+			fmt.Println("HEAD moved but git failed; run 'dotman status --fix' once the repository is consistent")
+		} else if oldIdx != nil {
+			if err := acquireLock("git"); err != nil {
+				return err
+			}
+			if _, err := manager().Reconcile(oldIdx); err != nil {
+				return err
+			}
+		}
+	}
+
+	if exitErr != nil {
+		return &ExitError{Code: exitErr.ExitCode()}
+	}
+	return nil
+}
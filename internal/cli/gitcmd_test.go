@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+func TestParseRootFlagsLeavesGitArguments(t *testing.T) {
+	tests := [][]string{
+		{"log", "--oneline"},
+		{"--version"},
+		{"-C", "sub", "status"},
+		{"log", "--source", "x"},
+	}
+	for _, args := range tests {
+		rest, err := parseRootFlags(gitCmd, args)
+		if err != nil {
+			t.Errorf("parseRootFlags(%q): %v", args, err)
+			continue
+		}
+		if !reflect.DeepEqual(rest, args) {
+			t.Errorf("parseRootFlags(%q) = %q, want the arguments unchanged", args, rest)
+		}
+	}
+}
+
+func TestParseRootFlagsTakesRootFlags(t *testing.T) {
+	flags := gitCmd.InheritedFlags()
+	t.Cleanup(func() { flags.Set("profile", "") })
+
+	tests := []struct {
+		args, want []string
+		profile    string
+	}{
+		{[]string{"--profile", "work", "log", "-1"}, []string{"log", "-1"}, "work"},
+		{[]string{"--profile=home", "--", "--help"}, []string{"--help"}, "home"},
+	}
+	for _, test := range tests {
+		rest, err := parseRootFlags(gitCmd, test.args)
+		if err != nil {
+			t.Errorf("parseRootFlags(%q): %v", test.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(rest, test.want) {
+			t.Errorf("parseRootFlags(%q) = %q, want %q", test.args, rest, test.want)
+		}
+		if profile := flags.Lookup("profile").Value.String(); profile != test.profile {
+			t.Errorf("parseRootFlags(%q) set --profile to %q, want %q", test.args, profile, test.profile)
+		}
+	}
+
+	if _, err := parseRootFlags(gitCmd, []string{"--profile"}); err == nil {
+		t.Error("parseRootFlags accepted --profile without a value")
+	}
+}
+
+func TestGitWithSourceFlag(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, name := range []string{"XDG_CONFIG_HOME", "XDG_STATE_HOME", "XDG_DATA_HOME", "DOTMAN_LOG_FILE", "DOTMAN_LOG"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, "gitconfig"))
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	defaultDir := filepath.Join(home, config.DotmanDirName)
+	otherDir := filepath.Join(home, "other")
+	for _, dir := range []string{defaultDir, otherDir} {
+		runTestGit(t, "", "init", "-q", dir)
+		runTestGit(t, dir, "commit", "-q", "--allow-empty", "-m", "initial")
+	}
+
+	testCfg, err := config.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SaveSources(testCfg, []types.Source{{Name: "x", Dir: otherDir}}); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd.SetArgs([]string{"--source", "x", "git", "--", "commit", "-q", "--allow-empty", "-m", "from dotman"})
+	if err := Execute(); err != nil {
+		t.Fatalf("dotman --source x git -- commit: %v", err)
+	}
+
+	if subject := runTestGit(t, otherDir, "log", "-1", "--format=%s"); subject != "from dotman" {
+		t.Errorf("last commit of source x = %q, want %q", subject, "from dotman")
+	}
+	if subject := runTestGit(t, defaultDir, "log", "-1", "--format=%s"); subject != "initial" {
+		t.Errorf("last commit of the default source = %q, want it untouched", subject)
+	}
+}
+
+// runTestGit runs git in dir and returns its trimmed output
+func runTestGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
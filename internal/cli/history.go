@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Merith-TK/dotman/internal/audit"
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/digest"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show what dotman changed on this machine",
+	Long: `Show the audit log of filesystem changes dotman made on this machine:
+every move, link, removal, copy and backup, with the state of each path before
+and after, the command that made it and whether it succeeded. Git history
+covers the repo content; this covers $HOME.
+
+The log is kept in the state directory (~/.local/state/dotman/audit.jsonl or
+$XDG_STATE_HOME/dotman/audit.jsonl) and is never shared between machines.
+
+--since takes a duration (30m, 12h, 7d, 2w), a date (2006-01-02) or a
+timestamp (2006-01-02 15:04 or RFC 3339).
+
+Examples:
+  dotman history --since 1h
+  dotman history --since 2024-05-01 --path ~/.config/nvim
+  dotman history --since 1d --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sinceFlag, _ := cmd.Flags().GetString("since")
+		path, _ := cmd.Flags().GetString("path")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		since, err := parseTime(sinceFlag, time.Now())
+		if err != nil {
+			return &UsageError{Err: err}
+		}
+		return runHistory(since, path, asJSON)
+	},
+}
+
+func init() {
+	historyCmd.Flags().String("since", "", "Only show changes after this time or duration ago")
+	historyCmd.Flags().String("path", "", "Only show changes to this path or below it")
+	historyCmd.Flags().Bool("json", false, "Print the raw JSON lines")
+}
+
+// parseTime turns a --since or --at value into a point in time: a duration
+// ago, a date or a timestamp
+func parseTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := config.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q; use a duration ago such as 12h or 7d, or a date", value)
+}
+
+func runHistory(since time.Time, path string, asJSON bool) error {
+	entries, err := audit.Read(cfg.StateDir, since)
+	if err != nil {
+		return err
+	}
+
+	if path != "" {
+		expanded, err := expandFilterPath(path)
+		if err != nil {
+			return err
+		}
+		var matching []audit.Entry
+		for _, entry := range entries {
+			if touches(entry, expanded) {
+				matching = append(matching, entry)
+			}
+		}
+		entries = matching
+	}
+
+	if asJSON {
+		for _, entry := range entries {
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return fmt.Errorf("failed to encode entry: %w", err)
+			}
+			fmt.Println(string(data))
+		}
+		return nil
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No recorded changes.")
+		return nil
+	}
+
+	for i, entry := range entries {
+		// Entries of one dotman invocation are listed under its command line
+		if i == 0 || entry.PID != entries[i-1].PID || strings.Join(entry.Command, " ") != strings.Join(entries[i-1].Command, " ") {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s  %s\n", entry.Time.Local().Format("2006-01-02 15:04:05"), strings.Join(append([]string{"dotman"}, entry.Command...), " "))
+		}
+		printHistoryEntry(entry)
+	}
+	return nil
+}
+
+// expandFilterPath makes a --path value absolute without requiring it to be
+// inside $HOME, since the repo and state directories may not be
+func expandFilterPath(path string) (string, error) {
+	if strings.HasPrefix(path, "~/") || path == "~" {
+		path = cfg.HomeDir + strings.TrimPrefix(path, "~")
+	}
+	return filepath.Abs(path)
+}
+
+// touches reports whether entry changed path or something below it
+func touches(entry audit.Entry, path string) bool {
+	for _, p := range []string{entry.Path, entry.Dest} {
+		if p != "" && (p == path || strings.HasPrefix(p, path+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+func printHistoryEntry(entry audit.Entry) {
+	mark := "✓"
+	if !entry.OK {
+		mark = "✗"
+	}
+
+	subject := entry.Path
+	if entry.Dest != "" {
+		subject += " -> " + entry.Dest
+	}
+	fmt.Printf("  %s %s %-10s %s\n", entry.Time.Local().Format("15:04:05"), mark, entry.Op, subject)
+
+	for _, path := range []string{entry.Path, entry.Dest} {
+		if path == "" {
+			continue
+		}
+		before, after := describeState(entry.Before[path]), describeState(entry.After[path])
+		if before != after {
+			fmt.Printf("      %s: %s => %s\n", path, before, after)
+		}
+	}
+	if entry.Error != "" {
+		fmt.Printf("      Error: %s\n", entry.Error)
+	}
+}
+
+// describeState summarizes a recorded path state in a few words
+func describeState(state audit.State) string {
+	switch state.Kind {
+	case "":
+		return "unknown"
+	case audit.KindSymlink:
+		return "symlink to " + state.Target
+	case audit.KindFile:
+		desc := fmt.Sprintf("file %s, %d bytes", state.Mode, state.Size)
+		if sum := strings.TrimPrefix(state.Digest, digest.Prefix); len(sum) >= 12 {
+			desc += ", " + sum[:12]
+		}
+		return desc
+	case audit.KindDir:
+		return "directory " + state.Mode
+	}
+	return state.Kind
+}
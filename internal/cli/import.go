@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/importer"
+	"github.com/Merith-TK/dotman/pkg/dotman"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import dotfiles from another dotfiles manager",
+	Long: `Import converts an existing dotfiles setup into dotman entries. The
+content is placed in the dotman repo and the targets are relinked to it in a
+single commit. The original setup is left in place so you can compare.
+
+Targets that already hold different content are skipped unless --force is
+given, in which case they are saved to the backup store first.
+Use --dry-run to see the full plan.`,
+}
+
+var importStowCmd = &cobra.Command{
+	Use:   "stow <dir> [package]...",
+	Short: "Import GNU Stow packages",
+	Long: `Import the packages of a GNU Stow directory (all of them unless named).
+Stow's links are replaced by dotman links. Directories stow folded into a
+single link are imported as directory entries.
+
+Example:
+  dotman import stow ~/dotfiles
+  dotman import stow ~/dotfiles zsh nvim --dotfiles`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		stowDir, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to resolve absolute path: %w", err)
+		}
+
+		// Like stow, the target defaults to the parent of the stow directory
+		target, _ := cmd.Flags().GetString("target")
+		if target == "" {
+			target = filepath.Dir(stowDir)
+		}
+		target, err = config.ExpandPath(cfg, target)
+		if err != nil {
+			return fmt.Errorf("failed to expand path: %w", err)
+		}
+
+		dotfiles, _ := cmd.Flags().GetBool("dotfiles")
+		return runImport(cmd, func(m *dotman.Manager, opts types.ImportOptions) (*dotman.ImportPlan, error) {
+			return m.PlanStowImport(stowDir, target, args[1:], dotfiles, opts)
+		})
+	},
+}
+
+var importBareCmd = &cobra.Command{
+	Use:   "bare <git-dir>",
+	Short: "Import a bare repository managed with git --git-dir",
+	Long: `Import the files tracked by a bare repository whose work tree is your home
+directory (the "git --git-dir=$HOME/.cfg --work-tree=$HOME" setup). Each file
+is moved into the dotman repo and replaced by a link. The bare repository is
+not changed; retire it once you are happy with the import.
+
+Example:
+  dotman import bare ~/.cfg`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		gitDir, err := config.ExpandPath(cfg, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to expand path: %w", err)
+		}
+
+		return runImport(cmd, func(m *dotman.Manager, opts types.ImportOptions) (*dotman.ImportPlan, error) {
+			return m.PlanBareImport(gitDir, opts)
+		})
+	},
+}
+
+var importChezmoiCmd = &cobra.Command{
+	Use:   "chezmoi [source-dir]",
+	Short: "Import a chezmoi source state",
+	Long: `Import a chezmoi source directory (default ~/.local/share/chezmoi).
+Names are translated from chezmoi's source state: dot_ becomes a leading dot,
+and private_, readonly_ and executable_ set the permissions of the repo copy.
+Templates, scripts, encrypted files and other special entries are skipped.
+
+Example:
+  dotman import chezmoi`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sourceDir := filepath.Join(cfg.HomeDir, ".local", "share", "chezmoi")
+		if len(args) == 1 {
+			expanded, err := config.ExpandPath(cfg, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to expand path: %w", err)
+			}
+			sourceDir = expanded
+		}
+
+		return runImport(cmd, func(m *dotman.Manager, opts types.ImportOptions) (*dotman.ImportPlan, error) {
+			return m.PlanChezmoiImport(sourceDir, opts)
+		})
+	},
+}
+
+func init() {
+	importCmd.AddCommand(importStowCmd)
+	importCmd.AddCommand(importBareCmd)
+	importCmd.AddCommand(importChezmoiCmd)
+
+	importCmd.PersistentFlags().BoolP("dry-run", "n", false, "Show the import plan without doing it")
+	importCmd.PersistentFlags().BoolP("force", "f", false, "Back up and replace targets that hold different content")
+
+	importStowCmd.Flags().StringP("target", "t", "", "Stow target directory (default: parent of the stow directory)")
+	importStowCmd.Flags().Bool("dotfiles", false, "Translate dot- prefixes like stow --dotfiles")
+}
+
+// runImport plans an import, shows the plan and carries it out
+func runImport(cmd *cobra.Command, planImport func(*dotman.Manager, types.ImportOptions) (*dotman.ImportPlan, error)) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	force, _ := cmd.Flags().GetBool("force")
+
+	if !dryRun {
+		if err := acquireLock("import"); err != nil {
+			return err
+		}
+	}
+
+	m := manager()
+	plan, err := planImport(m, types.ImportOptions{Force: force})
+	if err != nil {
+		return err
+	}
+	importer.Print(cfg, plan)
+
+	if dryRun || plan.Count() == 0 {
+		if plan.Count() == 0 {
+			fmt.Println("Nothing to import.")
+		}
+		return nil
+	}
+
+	fmt.Println()
+	result, err := m.Import(plan)
+	if result == nil {
+		return err
+	}
+
+	if !printFailures(result) {
+		fmt.Printf("\nSuccessfully imported %d file(s) from %s\n", result.Succeeded(), plan.Tool)
+	}
+	return err
+}
@@ -2,12 +2,11 @@ package cli
 
 import (
 	"fmt"
-	"os"
-	"os/exec"
 
 	"github.com/spf13/cobra"
 
 	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/fileops"
 	"github.com/Merith-TK/dotman/internal/git"
 	"github.com/Merith-TK/dotman/internal/index"
 	"github.com/Merith-TK/dotman/pkg/types"
@@ -53,6 +52,11 @@ func runInit() error {
 			if config.IndexFileExists(cfg) {
 				// Has index file, so initialize git
 				fmt.Println("Initializing git repository in existing dotman directory...")
+
+				// A tree unpacked with 'dotman bootstrap' is reconnected to its remote
+				if reconnected, err := manager().ReconnectBundle(); reconnected || err != nil {
+					return err
+				}
 				return git.EnsureRepo(cfg.DotmanDir)
 			} else {
 				// Directory exists but doesn't look like dotman - error
@@ -97,21 +101,20 @@ func runInit() error {
 func runClone(url string) error {
 	// Check if dotman directory already exists
 	if config.DotmanDirExists(cfg) {
-		return fmt.Errorf("dotman directory already exists: %s", cfg.DotmanDir)
+		return types.Errorf(types.ErrConflict, "dotman directory already exists: %s", cfg.DotmanDir)
 	}
 
 	// Clone the repository
 	fmt.Printf("Cloning dotfiles repo from %s...\n", url)
 
-	cmd := exec.Command("git", "clone", url, cfg.DotmanDir)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to clone repository: %s, %w", string(output), err)
+	if err := git.Clone(url, cfg.DotmanDir); err != nil {
+		return err
 	}
 
 	// Validate that the cloned repository has a valid index file
 	if !config.IndexFileExists(cfg) {
 		// Clean up the failed clone
-		os.RemoveAll(cfg.DotmanDir)
+		fileops.RemoveAll(cfg.DotmanDir)
 		return fmt.Errorf("cloned repository does not contain a valid dotman index.json file")
 	}
 
@@ -119,7 +122,7 @@ func runClone(url string) error {
 	_, err := index.Load(cfg.IndexFile)
 	if err != nil {
 		// Clean up the failed clone
-		os.RemoveAll(cfg.DotmanDir)
+		fileops.RemoveAll(cfg.DotmanDir)
 		return fmt.Errorf("cloned repository has invalid index.json: %w", err)
 	}
 
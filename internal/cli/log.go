@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Merith-TK/dotman/internal/git"
+)
+
+var logCmd = &cobra.Command{
+	Use:   "log <path>",
+	Short: "Show the history of a managed file",
+	Long: `Show the git history of a managed file or directory, given by its
+location in your home directory. Files inside managed directories work too.
+
+Examples:
+  dotman log ~/.zshrc
+  dotman log ~/.config/nvim/init.lua`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLog(args[0])
+	},
+}
+
+func runLog(path string) error {
+	args, err := manager().LogArgs(path)
+	if err != nil {
+		return err
+	}
+
+	if err := git.RunAttached(cfg.DotmanDir, args...); err != nil {
+		return fmt.Errorf("git log failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+var mvCmd = &cobra.Command{
+	Use:   "mv <old> <new> | mv --layer <layer> <path>...",
+	Short: "Move or rename a managed path",
+	Long: `Move a managed file or directory to a new location in your home
+directory. The repo copy is moved with git mv so its history stays
+connected, the index is updated and the symlink is recreated at the new
+location, all in one commit.
+
+With --keep-link, a compatibility symlink pointing to the new location is
+left at the old one.
+
+With --layer, the paths stay where they are and the repo copy this machine
+uses moves to another layer instead: base, host (hosts/<hostname>), os
+(os/<goos>) or a layer such as hosts/laptop. Overlay layers are kept under
+.dotman/layers in the repo.
+
+Examples:
+  dotman mv ~/.vimrc ~/.config/nvim/init.vim --keep-link
+  dotman mv --layer host ~/.config/monitors.conf`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if cmd.Flags().Changed("layer") {
+			return cobra.MinimumNArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keepLink, _ := cmd.Flags().GetBool("keep-link")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		var layer string
+		if cmd.Flags().Changed("layer") {
+			if keepLink {
+				return &UsageError{Err: fmt.Errorf("--keep-link can't be combined with --layer")}
+			}
+			name, _ := cmd.Flags().GetString("layer")
+			var err error
+			if layer, err = config.ParseLayer(cfg, name); err != nil {
+				return &UsageError{Err: err}
+			}
+		}
+
+		if !dryRun {
+			if err := acquireLock("mv"); err != nil {
+				return err
+			}
+		}
+		if layer != "" {
+			return runMoveToLayer(args, layer, dryRun)
+		}
+		return runMove(args[0], args[1], keepLink, dryRun)
+	},
+}
+
+func init() {
+	mvCmd.Flags().Bool("keep-link", false, "Leave a symlink to the new location at the old one")
+	mvCmd.Flags().String("layer", "", "Move the repo copy of the paths to this layer (base, host, os or e.g. hosts/laptop)")
+	mvCmd.Flags().BoolP("dry-run", "n", false, "Show what would be done without doing it")
+}
+
+func runMoveToLayer(paths []string, layer string, dryRun bool) error {
+	result, err := manager().MoveToLayer(paths, layer, types.MoveOptions{DryRun: dryRun})
+	if result == nil {
+		return err
+	}
+	printFailures(result)
+	return err
+}
+
+func runMove(oldPath, newPath string, keepLink, dryRun bool) error {
+	return manager().Move(oldPath, newPath, types.MoveOptions{DryRun: dryRun, KeepLink: keepLink})
+}
@@ -2,21 +2,26 @@ package cli
 
 import (
 	"fmt"
-	"os/exec"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/Merith-TK/dotman/internal/config"
 	"github.com/Merith-TK/dotman/internal/git"
+	"github.com/Merith-TK/dotman/pkg/types"
 )
 
 var remoteCmd = &cobra.Command{
 	Use:   "remote",
-	Short: "Manage git remote for dotman repository",
-	Long: `Manage the git remote repository for your dotman dotfiles.
+	Short: "Manage git remotes for dotman repository",
+	Long: `Manage the git remotes for your dotman dotfiles.
 
-Use 'remote set <url>' to set the remote repository URL.
-Use 'remote get' to show the current remote repository URL.`,
+Use 'remote list' to show all remotes and how sync uses them.
+Use 'remote add <name> <url>' to add a remote (--mirror to push to it on every sync).
+Use 'remote remove <name>' and 'remote rename <old> <new>' to manage remotes.
+Use 'remote use <name>' to choose the remote (and branch) sync pulls from and pushes to.
+Use 'remote set <url>' to set the origin remote URL.
+Use 'remote get' to show the origin remote URL.`,
 }
 
 var remoteSetCmd = &cobra.Command{
@@ -46,41 +51,127 @@ This is equivalent to running 'git remote get-url origin'.`,
 	},
 }
 
+var remoteListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List git remotes",
+	Long: `List all git remotes of the dotman repository, marking the remote
+sync uses and any push mirrors.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRemoteList()
+	},
+}
+
+var remoteAddCmd = &cobra.Command{
+	Use:   "add <name> <url>",
+	Short: "Add a git remote",
+	Long: `Add a git remote to the dotman repository.
+
+With --mirror, 'dotman sync --push' also pushes to this remote.
+
+Example:
+  dotman remote add backup git@example.com:me/dotfiles.git --mirror`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mirror, _ := cmd.Flags().GetBool("mirror")
+		return runRemoteAdd(args[0], args[1], mirror)
+	},
+}
+
+var remoteRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a git remote",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRemoteRemove(args[0])
+	},
+}
+
+var remoteRenameCmd = &cobra.Command{
+	Use:   "rename <old> <new>",
+	Short: "Rename a git remote",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRemoteRename(args[0], args[1])
+	},
+}
+
+var remoteUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Choose the remote and branch used by sync",
+	Long: `Choose the remote (and optionally the remote branch) that
+'dotman sync --pull' and 'dotman sync --push' use. The choice is stored in
+the repository's local git configuration, so it is per machine.
+
+Without a choice, sync uses the upstream of the current branch, or origin
+and the current branch name.
+
+Example:
+  dotman remote use work --branch laptop`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		branch, _ := cmd.Flags().GetString("branch")
+		return runRemoteUse(args[0], branch)
+	},
+}
+
+var remoteMirrorCmd = &cobra.Command{
+	Use:   "mirror <name>",
+	Short: "Mark a remote as a push mirror",
+	Long: `Mark a remote as a push mirror: 'dotman sync --push' pushes to it
+after pushing to the sync remote. Use --off to stop mirroring.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		off, _ := cmd.Flags().GetBool("off")
+		return runRemoteMirror(args[0], !off)
+	},
+}
+
 func init() {
 	remoteCmd.AddCommand(remoteSetCmd)
 	remoteCmd.AddCommand(remoteGetCmd)
+	remoteCmd.AddCommand(remoteListCmd)
+	remoteCmd.AddCommand(remoteAddCmd)
+	remoteCmd.AddCommand(remoteRemoveCmd)
+	remoteCmd.AddCommand(remoteRenameCmd)
+	remoteCmd.AddCommand(remoteUseCmd)
+	remoteCmd.AddCommand(remoteMirrorCmd)
+
+	remoteAddCmd.Flags().Bool("mirror", false, "Push to this remote on every sync --push")
+	remoteUseCmd.Flags().String("branch", "", "Remote branch to sync with (default: current branch)")
+	remoteMirrorCmd.Flags().Bool("off", false, "Stop mirroring to this remote")
 }
 
-func runRemoteSet(url string) error {
+// checkRemoteRepo verifies the dotman repository exists and is a git repository
+func checkRemoteRepo() error {
 	if !config.DotmanDirExists(cfg) {
-		return fmt.Errorf("dotman directory does not exist: %s", cfg.DotmanDir)
+		return types.Errorf(types.ErrRepoMissing, "dotman directory does not exist: %s", cfg.DotmanDir)
 	}
 
 	if !git.IsGitRepo(cfg.DotmanDir) {
-		return fmt.Errorf("dotman directory is not a git repository")
+		return types.Errorf(types.ErrRepoMissing, "dotman directory is not a git repository")
+	}
+
+	return nil
+}
+
+func runRemoteSet(url string) error {
+	if err := checkRemoteRepo(); err != nil {
+		return err
 	}
 
 	// Check if origin remote already exists
-	cmd := exec.Command("git", "remote", "get-url", "origin")
-	cmd.Dir = cfg.DotmanDir
-	
-	if err := cmd.Run(); err != nil {
+	if !git.HasRemote(cfg.DotmanDir, "origin") {
 		// Remote doesn't exist, add it
 		fmt.Printf("Adding remote origin: %s\n", url)
-		addCmd := exec.Command("git", "remote", "add", "origin", url)
-		addCmd.Dir = cfg.DotmanDir
-		
-		if output, err := addCmd.CombinedOutput(); err != nil {
-			return fmt.Errorf("failed to add remote: %s, %w", string(output), err)
+		if err := git.AddRemote(cfg.DotmanDir, "origin", url); err != nil {
+			return fmt.Errorf("failed to add remote: %w", err)
 		}
 	} else {
 		// Remote exists, update it
 		fmt.Printf("Updating remote origin: %s\n", url)
-		setCmd := exec.Command("git", "remote", "set-url", "origin", url)
-		setCmd.Dir = cfg.DotmanDir
-		
-		if output, err := setCmd.CombinedOutput(); err != nil {
-			return fmt.Errorf("failed to set remote URL: %s, %w", string(output), err)
+		if err := git.SetRemoteURL(cfg.DotmanDir, "origin", url); err != nil {
+			return fmt.Errorf("failed to set remote URL: %w", err)
 		}
 	}
 
@@ -89,23 +180,182 @@ func runRemoteSet(url string) error {
 }
 
 func runRemoteGet() error {
-	if !config.DotmanDirExists(cfg) {
-		return fmt.Errorf("dotman directory does not exist: %s", cfg.DotmanDir)
+	if err := checkRemoteRepo(); err != nil {
+		return err
 	}
 
-	if !git.IsGitRepo(cfg.DotmanDir) {
-		return fmt.Errorf("dotman directory is not a git repository")
+	// Get the remote URL
+	remoteURL, err := git.GetRemoteURL(cfg.DotmanDir)
+	if err != nil {
+		return err
 	}
 
-	// Get the remote URL
-	cmd := exec.Command("git", "remote", "get-url", "origin")
-	cmd.Dir = cfg.DotmanDir
-	
-	output, err := cmd.Output()
+	fmt.Printf("Remote origin: %s\n", remoteURL)
+	return nil
+}
+
+func runRemoteList() error {
+	if err := checkRemoteRepo(); err != nil {
+		return err
+	}
+
+	remotes, err := git.ListRemotes(cfg.DotmanDir)
+	if err != nil {
+		return err
+	}
+
+	if len(remotes) == 0 {
+		fmt.Println("No remotes configured. Use 'dotman remote add <name> <url>' to add one.")
+		return nil
+	}
+
+	m := manager()
+	syncRemote, syncBranch, _ := m.SyncTarget("", "")
+	for _, remote := range remotes {
+		var notes []string
+		if remote.Name == syncRemote {
+			notes = append(notes, "sync: "+syncBranch)
+		}
+		if m.IsMirror(remote.Name) {
+			notes = append(notes, "mirror")
+		}
+
+		marker := " "
+		if remote.Name == syncRemote {
+			marker = "*"
+		}
+		if len(notes) > 0 {
+			fmt.Printf("%s %s\t%s (%s)\n", marker, remote.Name, remote.URL, strings.Join(notes, ", "))
+		} else {
+			fmt.Printf("%s %s\t%s\n", marker, remote.Name, remote.URL)
+		}
+	}
+
+	return nil
+}
+
+func runRemoteAdd(name, url string, mirror bool) error {
+	if err := checkRemoteRepo(); err != nil {
+		return err
+	}
+
+	if git.HasRemote(cfg.DotmanDir, name) {
+		return fmt.Errorf("remote %s already exists", name)
+	}
+
+	if err := git.AddRemote(cfg.DotmanDir, name, url); err != nil {
+		return err
+	}
+
+	if mirror {
+		if err := git.SetConfig(cfg.DotmanDir, "remote."+name+"."+config.MirrorKey, "true"); err != nil {
+			return err
+		}
+		fmt.Printf("Added remote %s: %s (push mirror)\n", name, url)
+		return nil
+	}
+
+	fmt.Printf("Added remote %s: %s\n", name, url)
+	return nil
+}
+
+func runRemoteRemove(name string) error {
+	if err := checkRemoteRepo(); err != nil {
+		return err
+	}
+
+	if !git.HasRemote(cfg.DotmanDir, name) {
+		return fmt.Errorf("no such remote: %s", name)
+	}
+
+	if err := git.RemoveRemote(cfg.DotmanDir, name); err != nil {
+		return err
+	}
+
+	// Forget the sync choice if it named this remote
+	if remote, err := git.GetConfig(cfg.DotmanDir, config.SyncRemoteKey); err == nil && remote == name {
+		git.UnsetConfig(cfg.DotmanDir, config.SyncRemoteKey)
+		git.UnsetConfig(cfg.DotmanDir, config.SyncBranchKey)
+	}
+
+	fmt.Printf("Removed remote %s\n", name)
+	return nil
+}
+
+func runRemoteRename(oldName, newName string) error {
+	if err := checkRemoteRepo(); err != nil {
+		return err
+	}
+
+	if !git.HasRemote(cfg.DotmanDir, oldName) {
+		return fmt.Errorf("no such remote: %s", oldName)
+	}
+
+	// git remote rename moves remote.<name>.* (including the mirror flag)
+	if err := git.RenameRemote(cfg.DotmanDir, oldName, newName); err != nil {
+		return err
+	}
+
+	if remote, err := git.GetConfig(cfg.DotmanDir, config.SyncRemoteKey); err == nil && remote == oldName {
+		if err := git.SetConfig(cfg.DotmanDir, config.SyncRemoteKey, newName); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Renamed remote %s to %s\n", oldName, newName)
+	return nil
+}
+
+func runRemoteUse(name, branch string) error {
+	if err := checkRemoteRepo(); err != nil {
+		return err
+	}
+
+	if !git.HasRemote(cfg.DotmanDir, name) {
+		return fmt.Errorf("no such remote: %s", name)
+	}
+
+	if err := git.SetConfig(cfg.DotmanDir, config.SyncRemoteKey, name); err != nil {
+		return err
+	}
+
+	if branch != "" {
+		if err := git.SetConfig(cfg.DotmanDir, config.SyncBranchKey, branch); err != nil {
+			return err
+		}
+	} else if err := git.UnsetConfig(cfg.DotmanDir, config.SyncBranchKey); err != nil {
+		return err
+	}
+
+	remote, remoteBranch, err := manager().SyncTarget("", "")
 	if err != nil {
-		return fmt.Errorf("no remote origin configured")
+		return err
+	}
+	fmt.Printf("Sync now uses %s/%s\n", remote, remoteBranch)
+	return nil
+}
+
+func runRemoteMirror(name string, enable bool) error {
+	if err := checkRemoteRepo(); err != nil {
+		return err
 	}
 
-	fmt.Printf("Remote origin: %s", string(output))
+	if !git.HasRemote(cfg.DotmanDir, name) {
+		return fmt.Errorf("no such remote: %s", name)
+	}
+
+	key := "remote." + name + "." + config.MirrorKey
+	if !enable {
+		if err := git.UnsetConfig(cfg.DotmanDir, key); err != nil {
+			return err
+		}
+		fmt.Printf("Remote %s is no longer a push mirror\n", name)
+		return nil
+	}
+
+	if err := git.SetConfig(cfg.DotmanDir, key, "true"); err != nil {
+		return err
+	}
+	fmt.Printf("Remote %s is now a push mirror\n", name)
 	return nil
-}
\ No newline at end of file
+}
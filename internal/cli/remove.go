@@ -2,14 +2,11 @@ package cli
 
 import (
 	"fmt"
-	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 
-	"github.com/Merith-TK/dotman/internal/config"
-	"github.com/Merith-TK/dotman/internal/fileops"
-	"github.com/Merith-TK/dotman/internal/git"
-	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
 )
 
 var removeCmd = &cobra.Command{
@@ -18,98 +15,76 @@ var removeCmd = &cobra.Command{
 	Long: `Remove files from dotman management. Files are restored from the repo
 back to their original locations and removed from management.
 
+With --keep-in-repo, a copy is restored and the repo copy is kept as an
+archive in .dotman/archive, outside the index.
+With --delete, the file is deleted from both your home directory and the repo
+(git history still has it). You are asked to confirm unless --yes is given.
+With --index-only, your home directory is not touched; use it when the
+symlink was already replaced by a regular file. The repo copy is archived.
+
+All paths are removed in a single commit.
+
 Examples:
   dotman remove ~/.config/sway
   dotman remove ~/.bashrc ~/.bash_aliases
-  dotman remove ~/.bash*`,
+  dotman remove ~/.bash*
+  dotman remove --index-only ~/.zshrc`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runRemoveMultiple(args)
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		mode := types.RemoveRestore
+		selected := 0
+		for flag, m := range map[string]types.RemoveMode{"keep-in-repo": types.RemoveKeepInRepo, "delete": types.RemoveDelete, "index-only": types.RemoveIndexOnly} {
+			if set, _ := cmd.Flags().GetBool(flag); set {
+				mode = m
+				selected++
+			}
+		}
+		if selected > 1 {
+			return fmt.Errorf("--keep-in-repo, --delete and --index-only can't be combined")
+		}
+
+		if !dryRun {
+			if err := acquireLock("remove"); err != nil {
+				return err
+			}
+		}
+		return runRemoveMultiple(args, mode, dryRun, yes)
 	},
 }
 
-func runRemove(path string) error {
-	// Expand the path
-	expandedPath, err := config.ExpandPath(cfg, path)
-	if err != nil {
-		return fmt.Errorf("failed to expand path: %w", err)
-	}
-
-	// Load index
-	idx, err := index.Load(cfg.IndexFile)
-	if err != nil {
-		return fmt.Errorf("failed to load index: %w", err)
-	}
-
-	// Check if managed
-	managedFile, found := index.FindFile(idx, expandedPath)
-	if !found {
-		return fmt.Errorf("path is not managed by dotman: %s", expandedPath)
-	}
-
-	repoPath := filepath.Join(cfg.DotmanDir, managedFile.RepoPath)
-
-	fmt.Printf("Removing %s from dotman management...\n", expandedPath)
-
-	// Remove symlink and restore original
-	if err := fileops.RemoveSymlink(expandedPath, repoPath); err != nil {
-		return fmt.Errorf("failed to remove symlink and restore file: %w", err)
-	}
-
-	// Remove from index
-	index.RemoveFile(idx, expandedPath)
-
-	// Save index
-	if err := index.Save(idx, cfg.IndexFile); err != nil {
-		return fmt.Errorf("failed to save index: %w", err)
-	}
-
-	// Commit changes
-	if err := git.Add(cfg.DotmanDir); err != nil {
-		return fmt.Errorf("failed to stage changes: %w", err)
-	}
-
-	// Convert to $HOME relative path for commit message
-	homeRelPath, err := config.RelativeToHome(cfg, managedFile.OriginalPath)
-	if err != nil {
-		// Fallback to repo path if conversion fails
-		homeRelPath = managedFile.RepoPath
-	}
-	homePath := "$HOME/" + homeRelPath
-
-	commitMsg := fmt.Sprintf("Remove %s from dotman management", homePath)
-	if err := git.Commit(cfg.DotmanDir, commitMsg); err != nil {
-		return fmt.Errorf("failed to commit changes: %w", err)
-	}
-
-	fmt.Printf("Successfully removed %s from dotman management\n", path)
-	return nil
+func init() {
+	removeCmd.Flags().Bool("keep-in-repo", false, "Restore a copy and keep the repo copy as an unindexed archive")
+	removeCmd.Flags().Bool("delete", false, "Delete the file from both the home directory and the repo")
+	removeCmd.Flags().Bool("index-only", false, "Only drop the index entry (for links already replaced by a file)")
+	removeCmd.Flags().BoolP("dry-run", "n", false, "Show what would be done without doing it")
+	removeCmd.Flags().BoolP("yes", "y", false, "Don't ask for confirmation with --delete")
 }
 
-func runRemoveMultiple(paths []string) error {
-	var successCount int
-	var failures []string
-
-	for _, path := range paths {
-		err := runRemove(path)
-		if err != nil {
-			failures = append(failures, fmt.Sprintf("%s: %v", path, err))
-		} else {
-			successCount++
+func runRemoveMultiple(paths []string, mode types.RemoveMode, dryRun, yes bool) error {
+	if mode == types.RemoveDelete && !dryRun && !yes {
+		fmt.Println("This deletes the following from your home directory and the repo:")
+		for _, path := range paths {
+			fmt.Printf("  %s\n", path)
+		}
+		fmt.Print("Continue? (y/N): ")
+		var response string
+		fmt.Scanln(&response)
+		if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+			fmt.Println("Remove cancelled.")
+			return nil
 		}
 	}
 
-	if len(failures) > 0 {
-		fmt.Printf("\nCompleted with %d successes and %d failures:\n", successCount, len(failures))
-		for _, failure := range failures {
-			fmt.Printf("  Error: %s\n", failure)
-		}
-		if successCount == 0 {
-			return fmt.Errorf("all operations failed")
-		}
-	} else if successCount > 1 {
-		fmt.Printf("\nSuccessfully removed %d files from dotman management\n", successCount)
+	result, err := manager().Remove(paths, types.RemoveOptions{Mode: mode, DryRun: dryRun})
+	if result == nil {
+		return err
 	}
 
-	return nil
+	if !printFailures(result) && !dryRun {
+		fmt.Printf("Successfully removed %d path(s) from dotman management\n", result.Succeeded())
+	}
+	return err
 }
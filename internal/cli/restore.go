@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <path> --rev <rev>",
+	Short: "Restore a managed file from an earlier revision",
+	Long: `Restore a managed file or directory to its content at an earlier
+revision and commit the result. The path is given by its location in your
+home directory; files inside managed directories work too.
+
+Example:
+  dotman restore ~/.zshrc --rev HEAD~3`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rev, _ := cmd.Flags().GetString("rev")
+		if err := acquireLock("restore"); err != nil {
+			return err
+		}
+		return runRestore(args[0], rev)
+	},
+}
+
+func init() {
+	restoreCmd.Flags().String("rev", "", "Revision to restore from (required)")
+	restoreCmd.MarkFlagRequired("rev")
+}
+
+func runRestore(path, rev string) error {
+	return manager().Restore(path, rev)
+}
@@ -1,21 +1,170 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
 
 	"github.com/spf13/cobra"
 
+	"github.com/Merith-TK/dotman/internal/audit"
 	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/lock"
+	"github.com/Merith-TK/dotman/internal/logging"
+	"github.com/Merith-TK/dotman/pkg/dotman"
 	"github.com/Merith-TK/dotman/pkg/types"
 )
 
 var (
-	cfg *types.Config
+	cfg      *types.Config
+	lockHeld bool
+	// quiet is set by --quiet, which discards stdout
+	quiet bool
+	// started is set once the command line has been accepted
+	started bool
+	// closeLog closes the JSON log file, if one is open
+	closeLog = func() error { return nil }
 )
 
-// Execute runs the root command
+// ExitUsage is the exit code for a command line cobra rejected
+const ExitUsage = 2
+
+// Execute runs the root command. Errors are not printed.
 func Execute() error {
-	return rootCmd.Execute()
+	defer releaseLock()
+	defer func() { closeLog() }()
+	defer audit.Stop()
+	err := rootCmd.Execute()
+	if err != nil && !started {
+		return &UsageError{Err: err}
+	}
+	return err
+}
+
+// UsageError is an invalid command line
+type UsageError struct {
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code the dotman command uses for err
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	var usageErr *UsageError
+	switch {
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	case errors.As(err, &usageErr):
+		return ExitUsage
+	}
+	return types.ExitCode(err)
+}
+
+// acquireLock takes the dotman lock for commands that change the repo or $HOME.
+// It is released when Execute returns.
+func acquireLock(command string) error {
+	if err := lock.Acquire(cfg, command); err != nil {
+		return err
+	}
+	lockHeld = true
+	return nil
+}
+
+// releaseLock releases the dotman lock if this process holds it
+func releaseLock() {
+	if lockHeld {
+		lock.Release(cfg)
+		lockHeld = false
+	}
+}
+
+// manager returns the library manager for the selected source. Its events
+// are printed as they happen.
+func manager() *dotman.Manager {
+	return dotman.New(cfg, dotman.WithShadowed(shadowed), dotman.WithEvents(dotman.EventFunc(printEvent)))
+}
+
+// printEvent prints a progress event of the manager
+func printEvent(e dotman.Event) {
+	// -q keeps only what needs attention
+	if quiet && e.Kind != dotman.EventFailed && e.Kind != dotman.EventProblem {
+		return
+	}
+
+	switch e.Kind {
+	case dotman.EventWarning:
+		fmt.Printf("Warning: %s\n", e.Message)
+	case dotman.EventProblem:
+		fmt.Printf("⚠️  %s\n", e.Message)
+	case dotman.EventFixed:
+		fmt.Printf("🔧 %s\n", e.Message)
+	default:
+		fmt.Println(e.Message)
+	}
+}
+
+// setupLogging picks the log level from -v, -q or DOTMAN_LOG and opens the
+// log file. With -q, printEvent also mutes progress events.
+func setupLogging(cmd *cobra.Command) error {
+	level := slog.LevelWarn
+	if name := os.Getenv(logging.LevelEnv); name != "" {
+		parsed, err := logging.ParseLevel(name)
+		if err != nil {
+			return err
+		}
+		level = parsed
+	}
+
+	verbose, _ := cmd.Flags().GetCount("verbose")
+	quiet, _ = cmd.Flags().GetBool("quiet")
+	switch {
+	case quiet && verbose > 0:
+		return &UsageError{Err: fmt.Errorf("--quiet and --verbose can't be combined")}
+	case quiet:
+		level = slog.LevelError
+	case verbose == 1:
+		level = slog.LevelInfo
+	case verbose > 1:
+		level = slog.LevelDebug
+	}
+
+	file, _ := cmd.Flags().GetString("log-file")
+	if file == "" {
+		file = os.Getenv(logging.FileEnv)
+	}
+
+	closeFile, err := logging.Setup(logging.Options{Level: level, File: file})
+	if err != nil {
+		return err
+	}
+	closeLog = closeFile
+
+	slog.Debug("command", "args", os.Args[1:])
+	return nil
+}
+
+// printFailures lists the paths a multi-path operation failed on and reports
+// whether there were any
+func printFailures(result *dotman.Result) bool {
+	failed := result.Failed()
+	if len(failed) == 0 || len(result.Operations) == 1 {
+		// A single path's error is returned as is
+		return len(failed) > 0
+	}
+
+	fmt.Printf("\nCompleted with %d successes and %d failures:\n", result.Succeeded(), len(failed))
+	for _, op := range failed {
+		fmt.Printf("  Error: %s: %v\n", op.Path, op.Error)
+	}
+	return true
 }
 
 var rootCmd = &cobra.Command{
@@ -29,13 +178,26 @@ while keeping them in their expected locations for applications to find them.`,
 	CompletionOptions: cobra.CompletionOptions{
 		DisableDefaultCmd: true,
 	},
+	SilenceErrors: true,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Flags and arguments are valid; later errors don't need the usage
+		started = true
+		cmd.SilenceUsage = true
+
+		if err := setupLogging(cmd); err != nil {
+			return err
+		}
+
 		var err error
 		cfg, err = config.New()
 		if err != nil {
 			return fmt.Errorf("failed to initialize config: %w", err)
 		}
-		return nil
+		if profile, _ := cmd.Flags().GetString("profile"); profile != "" {
+			cfg.Profile = profile
+		}
+		audit.Start(cfg.StateDir, os.Args[1:])
+		return selectSource(cmd)
 	},
 }
 
@@ -48,16 +210,48 @@ func init() {
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(removeCmd)
+	rootCmd.AddCommand(mvCmd)
 	rootCmd.AddCommand(deployCmd)
 	rootCmd.AddCommand(syncCmd)
 	rootCmd.AddCommand(remoteCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(fsckCmd)
+	rootCmd.AddCommand(logCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(restoreCmd)
+	rootCmd.AddCommand(gitCmd)
+	rootCmd.AddCommand(sourceCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(bootstrapCmd)
+	rootCmd.AddCommand(generateCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(serviceCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(backupsCmd)
+	rootCmd.AddCommand(uiCmd)
+	rootCmd.AddCommand(discoverCmd)
+
+	rootCmd.PersistentFlags().String("profile", "", "Deploy profile exposed to hooks (default $DOTMAN_PROFILE or \"default\")")
+	rootCmd.PersistentFlags().StringSlice("source", nil, "Operate on the named source(s) only")
+	rootCmd.PersistentFlags().CountP("verbose", "v", "Log filesystem changes and git commands to stderr (-vv for debug output)")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "Mute progress output and only log errors")
+	rootCmd.PersistentFlags().String("log-file", "", "Append a JSON debug log to this file (default $DOTMAN_LOG_FILE)")
 
 	// Add flags
 	addCmd.Flags().BoolP("force", "f", false, "Force operation even if conflicts exist")
 	addCmd.Flags().BoolP("dry-run", "n", false, "Show what would happen without doing it")
-	addCmd.Flags().BoolP("backup", "b", false, "Create backup before operation")
+	addCmd.Flags().BoolP("backup", "b", false, "Save a copy to the backup store before moving it into the repo")
+	addCmd.Flags().Bool("host", false, "Store the file in this machine's overlay layer (.dotman/layers/hosts/<hostname> in the repo)")
+	addCmd.Flags().String("glob", "", "Manage a target discovered with a glob pattern")
+	addCmd.Flags().String("policy", string(types.MatchFirst), "Glob match policy: first-match, all-matches or fail-if-none")
+	addCmd.Flags().String("repo-path", "", "Repo path for a --glob entry (default: pattern without wildcard components)")
+	addCmd.Flags().Bool("merge", false, "Merge managed entries below the path into one directory entry")
+	addCmd.Flags().Bool("split", false, "Split the managed directory containing the path into separate entries")
+	addCmd.Flags().StringSlice("include", nil, "Manage the directory partially, tracking files matching these globs")
+	addCmd.Flags().StringSlice("exclude", nil, "Never track files or directories matching these globs (with --include)")
 
 	deployCmd.Flags().BoolP("force", "f", false, "Force deployment even if conflicts exist")
 	deployCmd.Flags().BoolP("dry-run", "n", false, "Show what would be done without doing it")
-	deployCmd.Flags().BoolP("backup", "b", false, "Create backup before operation")
+	deployCmd.Flags().BoolP("backup", "b", false, "Back up and replace files in the way of a link")
 }
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Merith-TK/dotman/internal/service"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+var serviceCmd = &cobra.Command{
+	Use:   "service",
+	Short: "Manage systemd user units that run dotman",
+}
+
+var serviceInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Generate and enable systemd user units for sync or watch",
+	Long: `Generate systemd --user units under ~/.config/systemd/user and enable
+them. By default a timer runs 'dotman sync --pull' (and with --push also
+'dotman sync --push') every --interval. With --watch, a long-running
+'dotman watch' service is installed instead.
+
+The unit files are managed by dotman like any other file, so they are
+committed and deployed to other machines. Run install there to enable them;
+it regenerates the files for that machine's dotman binary.
+
+Examples:
+  dotman service install --interval 1h --push
+  dotman service install --watch`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		interval, _ := cmd.Flags().GetDuration("interval")
+		push, _ := cmd.Flags().GetBool("push")
+		watchMode, _ := cmd.Flags().GetBool("watch")
+		debounce, _ := cmd.Flags().GetDuration("debounce")
+		noEnable, _ := cmd.Flags().GetBool("no-enable")
+
+		if err := acquireLock("service"); err != nil {
+			return err
+		}
+		return runServiceInstall(types.ServiceOptions{
+			Interval: interval,
+			Push:     push,
+			Watch:    watchMode,
+			Debounce: debounce,
+			Enable:   !noEnable,
+		})
+	},
+}
+
+var serviceStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the state of dotman's systemd user units",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runServiceStatus()
+	},
+}
+
+var serviceUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Disable and remove dotman's systemd user units",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := acquireLock("service"); err != nil {
+			return err
+		}
+		return runServiceUninstall()
+	},
+}
+
+func init() {
+	serviceCmd.AddCommand(serviceInstallCmd)
+	serviceCmd.AddCommand(serviceStatusCmd)
+	serviceCmd.AddCommand(serviceUninstallCmd)
+
+	serviceInstallCmd.Flags().Duration("interval", time.Hour, "How often the timer syncs")
+	serviceInstallCmd.Flags().Bool("push", false, "Also push local commits on every sync")
+	serviceInstallCmd.Flags().Bool("watch", false, "Install a long-running 'dotman watch' service instead of the timer")
+	serviceInstallCmd.Flags().Duration("debounce", 2*time.Second, "Debounce passed to 'dotman watch'")
+	serviceInstallCmd.Flags().Bool("no-enable", false, "Only write the units, don't enable them")
+}
+
+func runServiceInstall(opts types.ServiceOptions) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the dotman executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	opts.Executable = exe
+
+	install, err := manager().InstallService(opts)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !opts.Enable:
+		fmt.Printf("Enable the units with: systemctl --user daemon-reload && systemctl --user enable --now %s\n", install.Unit)
+	case install.Enabled:
+		fmt.Printf("Enabled %s\n", install.Unit)
+	}
+	return nil
+}
+
+func runServiceStatus() error {
+	report, err := manager().ServiceStatus()
+	if err != nil {
+		return err
+	}
+
+	if !report.Available {
+		fmt.Println("systemctl is not available; showing unit files only")
+	}
+
+	for _, unit := range report.Units {
+		managed := "managed"
+		if !unit.Managed {
+			managed = "not managed"
+		}
+
+		if !report.Available {
+			fmt.Printf("- %s (%s)\n", unit.Name, managed)
+			continue
+		}
+
+		status := "✓"
+		// The sync service is started by the timer, not enabled
+		if unit.Name != service.SyncService && unit.Enabled != "enabled" {
+			status = "✗"
+		}
+		fmt.Printf("%s %s (%s) - %s, %s\n", status, unit.Name, managed, unit.Enabled, unit.Active)
+	}
+
+	if len(report.Units) == 0 {
+		fmt.Println("No dotman units are installed. Run 'dotman service install' to add them.")
+	}
+	return nil
+}
+
+func runServiceUninstall() error {
+	removed, err := manager().UninstallService()
+	if err == nil && len(removed) == 0 {
+		fmt.Println("No dotman units are installed.")
+	}
+	return err
+}
@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+var (
+	sources      []types.Source
+	sourceFilter []string
+	// shadowed maps original paths of the source being processed to the
+	// higher-priority source that wins them
+	shadowed map[string]string
+)
+
+// iterateSources marks commands that run once per source, or work on the
+// source list, instead of operating on the single selected source
+var iterateSources = map[string]string{"sources": "all"}
+
+var sourceCmd = &cobra.Command{
+	Use:   "source",
+	Short: "Manage the dotfiles repositories composed into your home",
+	Long: `Dotman can compose several dotfiles repositories (sources) into one
+home directory, for example a shared company repo and a personal one. Each
+source has its own index and git remote. When two sources manage the same
+target, the source with the higher priority wins.
+
+The default source is ~/.dotman. Other sources are listed in
+~/.config/dotman/sources.json.
+
+Use --source <name> to make any command operate on a single source.
+status, deploy and sync run for every source unless filtered with --source.`,
+}
+
+var sourceListCmd = &cobra.Command{
+	Use:         "list",
+	Short:       "List sources and target collisions",
+	Args:        cobra.NoArgs,
+	Annotations: iterateSources,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSourceList()
+	},
+}
+
+var sourceAddCmd = &cobra.Command{
+	Use:   "add <name> <url|dir>",
+	Short: "Add a source",
+	Long: `Add a source. An existing directory is registered as is; anything
+else is cloned into ~/.local/share/dotman/sources/<name>.
+
+Example:
+  dotman source add company git@example.com:corp/dotfiles.git --priority -10`,
+	Args:        cobra.ExactArgs(2),
+	Annotations: iterateSources,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		priority, _ := cmd.Flags().GetInt("priority")
+		return runSourceAdd(args[0], args[1], priority)
+	},
+}
+
+var sourceRemoveCmd = &cobra.Command{
+	Use:         "remove <name>",
+	Short:       "Remove a source from the list (its repository is kept)",
+	Args:        cobra.ExactArgs(1),
+	Annotations: iterateSources,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSourceRemove(args[0])
+	},
+}
+
+func init() {
+	sourceCmd.AddCommand(sourceListCmd)
+	sourceCmd.AddCommand(sourceAddCmd)
+	sourceCmd.AddCommand(sourceRemoveCmd)
+
+	sourceAddCmd.Flags().Int("priority", 0, "Priority in target collisions (higher wins, default source is 0)")
+}
+
+// selectSource points cfg at the source chosen with --source for commands
+// that operate on a single source
+func selectSource(cmd *cobra.Command) error {
+	var err error
+	sources, err = config.LoadSources(cfg)
+	if err != nil {
+		return err
+	}
+
+	sourceFilter, _ = cmd.Flags().GetStringSlice("source")
+	for _, name := range sourceFilter {
+		if _, found := config.FindSource(sources, name); !found {
+			return fmt.Errorf("unknown source: %s", name)
+		}
+	}
+
+	if cmd.Annotations["sources"] == "all" {
+		return nil
+	}
+	if len(sourceFilter) > 1 {
+		return fmt.Errorf("%s operates on a single source", cmd.Name())
+	}
+
+	name := config.DefaultSource
+	if len(sourceFilter) == 1 {
+		name = sourceFilter[0]
+	}
+	if len(sources) > 1 {
+		shadowed = manager().Collisions(sources)[name]
+	}
+
+	source, _ := config.FindSource(sources, name)
+	cfg = config.ForSource(cfg, source)
+	return nil
+}
+
+// forEachSource runs fn with cfg pointed at each selected source in priority
+// order. Errors don't stop the remaining sources.
+func forEachSource(fn func() error) error {
+	base := cfg
+	defer func() {
+		cfg = base
+		shadowed = nil
+	}()
+
+	var selected []types.Source
+	for _, source := range sources {
+		if len(sourceFilter) == 0 || containsString(sourceFilter, source.Name) {
+			selected = append(selected, source)
+		}
+	}
+
+	collisions := manager().Collisions(sources)
+
+	var failures []string
+	for i, source := range selected {
+		if len(sources) > 1 {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("== Source %s (%s) ==\n", source.Name, source.Dir)
+		}
+
+		cfg = config.ForSource(base, source)
+		shadowed = collisions[source.Name]
+		if err := fn(); err != nil {
+			if len(selected) == 1 {
+				return err
+			}
+			fmt.Printf("Error: %v\n", err)
+			failures = append(failures, source.Name)
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("failed for source(s): %s", strings.Join(failures, ", "))
+	}
+	return nil
+}
+
+func runSourceList() error {
+	collisions := manager().Collisions(sources)
+
+	for _, source := range sources {
+		state := ""
+		if !fileops.PathExists(source.Dir) {
+			state = " (missing)"
+		}
+		fmt.Printf("%s\t%s\tpriority %d%s\n", source.Name, source.Dir, source.Priority, state)
+	}
+
+	total := 0
+	for _, source := range sources {
+		for path, winner := range collisions[source.Name] {
+			if total == 0 {
+				fmt.Println("\nTarget collisions:")
+			}
+			fmt.Printf("  %s: %s is shadowed by %s\n", source.Name, path, winner)
+			total++
+		}
+	}
+
+	return nil
+}
+
+func runSourceAdd(name, location string, priority int) error {
+	source, err := manager().AddSource(name, location, priority)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Added source %s (%s, priority %d)\n", source.Name, source.Dir, source.Priority)
+	fmt.Println("Use 'dotman deploy --source " + source.Name + "' to deploy it.")
+	return nil
+}
+
+func runSourceRemove(name string) error {
+	if err := manager().RemoveSource(name); err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed source %s; its repository and deployed links were left in place\n", name)
+	return nil
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
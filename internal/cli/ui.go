@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/lock"
+	"github.com/Merith-TK/dotman/internal/ui"
+	"github.com/Merith-TK/dotman/pkg/dotman"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Browse managed files and fix them interactively",
+	Long: `Show the managed entries of the main repository with their link state,
+whether their repo content has uncommitted changes (M) and the state of the
+repo, and act on one or several of them at once:
+
+  space, a     select the entry under the cursor, or all entries
+  enter, d     preview the uncommitted changes of the entry, and how a file
+               in the way of its link differs from the repo copy
+  f, F         fix the links of the selected entries; F backs up and
+               replaces files in the way, like 'status --fix --backup'
+  x            remove the selected entries, like 'remove'
+  l            move the selected entries to another layer, like 'mv --layer'
+  c            commit the changes in the repo
+  r            reload the status
+  q            quit
+
+Actions work on the selected entries, or on the entry under the cursor when
+none are selected. The dotman lock is only held while an action runs.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !config.DotmanDirExists(cfg) {
+			return types.Errorf(types.ErrRepoMissing, "dotman directory does not exist: %s", cfg.DotmanDir)
+		}
+
+		layers := append([]string{config.BaseLayer}, config.OverlayLayers(cfg)...)
+		return ui.Run(uiActions{}, layers)
+	},
+}
+
+// uiActions runs the actions of the UI on the main repository. Progress
+// events are collected for the UI instead of being printed.
+type uiActions struct{}
+
+func (uiActions) Status() (*dotman.StatusReport, error) {
+	return dotman.New(cfg).Status()
+}
+
+func (uiActions) Diff(path string) (string, error) {
+	return dotman.New(cfg).Diff(path)
+}
+
+func (a uiActions) Fix(paths []string, backup bool) ([]string, error) {
+	return a.change("ui fix", func(m *dotman.Manager) error {
+		result, err := m.Fix(types.FixOptions{Backup: backup, Paths: paths})
+		if err == nil && len(result.Fixed) == 0 && len(result.Problems) == 0 {
+			return fmt.Errorf("nothing to fix")
+		}
+		return err
+	})
+}
+
+func (a uiActions) Remove(paths []string) ([]string, error) {
+	return a.change("ui remove", func(m *dotman.Manager) error {
+		_, err := m.Remove(paths, types.RemoveOptions{Mode: types.RemoveRestore})
+		return err
+	})
+}
+
+func (a uiActions) MoveToLayer(paths []string, layer string) ([]string, error) {
+	return a.change("ui mv", func(m *dotman.Manager) error {
+		_, err := m.MoveToLayer(paths, layer, types.MoveOptions{})
+		return err
+	})
+}
+
+func (a uiActions) Commit(message string) ([]string, error) {
+	var subject string
+	messages, err := a.change("ui commit", func(m *dotman.Manager) error {
+		var err error
+		subject, err = m.Commit(message)
+		return err
+	})
+	if err != nil {
+		return messages, err
+	}
+	if subject == "" {
+		return append(messages, "Nothing to commit."), nil
+	}
+	return append(messages, "Committed "+subject), nil
+}
+
+// change runs fn with the dotman lock held and returns the messages of the
+// events it reported
+func (uiActions) change(command string, fn func(m *dotman.Manager) error) ([]string, error) {
+	if err := lock.Acquire(cfg, command); err != nil {
+		return nil, err
+	}
+	defer lock.Release(cfg)
+
+	var messages []string
+	m := dotman.New(cfg, dotman.WithEvents(dotman.EventFunc(func(e dotman.Event) {
+		messages = append(messages, strings.TrimSpace(e.Message))
+	})))
+	err := fn(m)
+	return messages, err
+}
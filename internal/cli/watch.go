@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/git"
+	"github.com/Merith-TK/dotman/internal/lock"
+	"github.com/Merith-TK/dotman/internal/watch"
+	"github.com/Merith-TK/dotman/pkg/dotman"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Commit edits to managed files automatically",
+	Long: `Watch the dotman repository and commit edits to managed files as they
+happen. Edits made through the symlinks in $HOME change the repo, so they are
+picked up without watching $HOME itself. Bursts of writes are collected until
+the repo has been quiet for --debounce, then committed at once with a message
+naming the $HOME paths that changed.
+
+With --push-interval, new commits are pushed periodically the way
+'dotman sync --push' would.
+
+Watch takes the dotman lock only while it commits or pushes, so other dotman
+commands keep working; changes made while another command holds the lock
+are committed once it is released. Only one watcher runs at a time. Stop it
+with Ctrl-C or SIGTERM; pending changes are committed before it exits.
+
+Examples:
+  dotman watch
+  dotman watch --debounce 10s --push-interval 30m`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		debounce, _ := cmd.Flags().GetDuration("debounce")
+		pushInterval, _ := cmd.Flags().GetDuration("push-interval")
+		remote, _ := cmd.Flags().GetString("remote")
+		branch, _ := cmd.Flags().GetString("branch")
+		return runWatch(debounce, pushInterval, remote, branch)
+	},
+}
+
+func init() {
+	watchCmd.Flags().Duration("debounce", 2*time.Second, "Quiet period before changes are committed")
+	watchCmd.Flags().Duration("push-interval", 0, "Push new commits this often (0 disables pushing)")
+	watchCmd.Flags().String("remote", "", "Remote to push to")
+	watchCmd.Flags().String("branch", "", "Remote branch to push to")
+}
+
+func runWatch(debounce, pushInterval time.Duration, remote, branch string) error {
+	if !config.DotmanDirExists(cfg) {
+		return types.Errorf(types.ErrRepoMissing, "dotman directory does not exist: %s", cfg.DotmanDir)
+	}
+	if !git.IsGitRepo(cfg.DotmanDir) {
+		return types.Errorf(types.ErrRepoMissing, "dotman directory is not a git repository")
+	}
+	if debounce <= 0 {
+		return fmt.Errorf("--debounce must be positive")
+	}
+
+	if err := lock.AcquireWatch(cfg); err != nil {
+		return fmt.Errorf("failed to start watching: %w", err)
+	}
+	defer lock.ReleaseWatch(cfg)
+
+	watcher, err := watch.New(cfg.DotmanDir)
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Commits and pushes both run git in the repo
+	var mu sync.Mutex
+	m := manager()
+
+	if pushInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(pushInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					mu.Lock()
+					watchPush(m, remote, branch)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	fmt.Printf("Watching %s (debounce %s", cfg.DotmanDir, debounce)
+	if pushInterval > 0 {
+		fmt.Printf(", push every %s", pushInterval)
+	}
+	fmt.Println("). Press Ctrl-C to stop.")
+
+	// Changes made while another command holds the lock are retried
+	waiting := false
+	err = watcher.Run(ctx, debounce, func(paths []string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err := lock.Acquire(cfg, "watch"); err != nil {
+			if !waiting {
+				fmt.Printf("Waiting for the lock: %v\n", err)
+				waiting = true
+			}
+			return false
+		}
+		defer lock.Release(cfg)
+		waiting = false
+
+		watchCommit(m)
+		return true
+	}, func(err error) {
+		fmt.Printf("Warning: %v\n", err)
+	})
+
+	fmt.Println("Stopped watching.")
+	return err
+}
+
+// watchCommit commits the changes in the repo; the caller holds the lock
+func watchCommit(m *dotman.Manager) {
+	subject, err := m.Commit("")
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return
+	}
+	// Manual commands commit their own changes
+	if subject == "" {
+		return
+	}
+
+	fmt.Printf("[%s] Committed %s\n", time.Now().Format("15:04:05"), subject)
+}
+
+// watchPush pushes commits that are not on the remote yet
+func watchPush(m *dotman.Manager, remote, branch string) {
+	if ahead, _, err := git.AheadBehind(cfg.DotmanDir); err == nil && ahead == 0 {
+		return
+	}
+
+	if err := lock.Acquire(cfg, "watch"); err != nil {
+		fmt.Printf("Skipping push: %v\n", err)
+		return
+	}
+	defer lock.Release(cfg)
+
+	if _, err := m.Sync(types.SyncOptions{Mode: types.SyncPush, Remote: remote, Branch: branch}); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
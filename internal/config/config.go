@@ -4,8 +4,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Merith-TK/dotman/internal/fileops"
 	"github.com/Merith-TK/dotman/pkg/types"
 )
 
@@ -13,6 +18,21 @@ const (
 	DotmanDirName  = ".dotman"
 	IndexFileName  = "index.json"
 	DefaultVersion = "1.0"
+	StateDirName   = "dotman"
+	DefaultProfile = "default"
+	ArchiveDirName = "archive"
+
+	// Overlay layers live in the repo metadata directory, so that any path
+	// in $HOME can be managed; files in a layer shadow base repo paths
+	LayersDirName = "layers"
+	HostsLayerDir = "hosts"
+	OSLayerDir    = "os"
+	BaseLayer     = "base"
+
+	// Git config keys (in the dotman repo's .git/config) used by sync
+	SyncRemoteKey = "dotman.remote"
+	SyncBranchKey = "dotman.branch"
+	MirrorKey     = "dotmanMirror" // remote.<name>.dotmanMirror
 )
 
 // New creates a new Config with default values
@@ -25,16 +45,81 @@ func New() (*types.Config, error) {
 	dotmanDir := filepath.Join(homeDir, DotmanDirName)
 	indexFile := filepath.Join(dotmanDir, IndexFileName)
 
+	profile := os.Getenv("DOTMAN_PROFILE")
+	if profile == "" {
+		profile = DefaultProfile
+	}
+
 	return &types.Config{
 		DotmanDir: dotmanDir,
 		HomeDir:   homeDir,
 		IndexFile: indexFile,
+		StateDir:  stateDir(homeDir),
+		ConfigDir: configDir(homeDir),
+		Profile:   profile,
+		Hostname:  hostname(),
 	}, nil
 }
 
+// hostname returns the short host name ($DOTMAN_HOST overrides it)
+func hostname() string {
+	if host := os.Getenv("DOTMAN_HOST"); host != "" {
+		return host
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	if i := strings.Index(host, "."); i > 0 {
+		host = host[:i]
+	}
+	return host
+}
+
+// stateDir returns the machine-local state directory, following the XDG
+// base directory spec ($XDG_STATE_HOME/dotman or ~/.local/state/dotman)
+func stateDir(homeDir string) string {
+	if xdg := os.Getenv("XDG_STATE_HOME"); xdg != "" && filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, StateDirName)
+	}
+	return filepath.Join(homeDir, ".local", "state", StateDirName)
+}
+
+// configDir returns the machine-local configuration directory
+// ($XDG_CONFIG_HOME/dotman or ~/.config/dotman)
+func configDir(homeDir string) string {
+	return filepath.Join(ConfigHome(homeDir), StateDirName)
+}
+
+// ConfigHome returns the base directory of user configuration files
+// ($XDG_CONFIG_HOME or ~/.config)
+func ConfigHome(homeDir string) string {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" && filepath.IsAbs(xdg) {
+		return xdg
+	}
+	return filepath.Join(homeDir, ".config")
+}
+
+// EnsureStateDir creates the machine-local state directory if it doesn't exist
+func EnsureStateDir(cfg *types.Config) error {
+	return fileops.MkdirAll(cfg.StateDir, 0755)
+}
+
+// MetadataDir returns the path of the repository metadata directory
+// (<repo>/.dotman), which holds hooks and other non-deployed files
+func MetadataDir(cfg *types.Config) string {
+	return filepath.Join(cfg.DotmanDir, DotmanDirName)
+}
+
+// ArchiveDir returns where repo copies of entries that were dropped from the
+// index without deleting them are kept (<repo>/.dotman/archive)
+func ArchiveDir(cfg *types.Config) string {
+	return filepath.Join(MetadataDir(cfg), ArchiveDirName)
+}
+
 // EnsureDotmanDir creates the .dotman directory if it doesn't exist
 func EnsureDotmanDir(cfg *types.Config) error {
-	return os.MkdirAll(cfg.DotmanDir, 0755)
+	return fileops.MkdirAll(cfg.DotmanDir, 0755)
 }
 
 // DotmanDirExists checks if the .dotman directory exists
@@ -69,7 +154,7 @@ func ExpandPath(cfg *types.Config, path string) (string, error) {
 
 	// Security check: ensure path is within home directory
 	if !IsInsideHome(cfg, expandedPath) {
-		return "", fmt.Errorf("path must be inside home directory: %s", expandedPath)
+		return "", types.Errorf(types.ErrOutsideHome, "path must be inside home directory: %s", expandedPath)
 	}
 
 	return expandedPath, nil
@@ -79,7 +164,7 @@ func ExpandPath(cfg *types.Config, path string) (string, error) {
 func RelativeToHome(cfg *types.Config, absolutePath string) (string, error) {
 	// Ensure the path is inside home first
 	if !IsInsideHome(cfg, absolutePath) {
-		return "", fmt.Errorf("path is outside home directory: %s", absolutePath)
+		return "", types.Errorf(types.ErrOutsideHome, "path is outside home directory: %s", absolutePath)
 	}
 
 	return filepath.Rel(cfg.HomeDir, absolutePath)
@@ -132,3 +217,196 @@ func ShouldIgnoreRepoPath(cfg *types.Config, repoRelPath string) bool {
 
 	return false
 }
+
+// ValidMatchPolicy reports whether the policy is one dotman understands
+func ValidMatchPolicy(policy types.MatchPolicy) bool {
+	switch policy {
+	case types.MatchFirst, types.MatchAll, types.MatchFailIfNone:
+		return true
+	}
+	return false
+}
+
+// ResolveGlobTarget expands a glob entry into the absolute target paths it
+// should be deployed to. Only the leading part of the pattern up to the last
+// wildcard component has to exist; the remainder (e.g. chrome/userChrome.css)
+// is appended to every match and created on deploy.
+func ResolveGlobTarget(cfg *types.Config, glob *types.GlobTarget) ([]string, error) {
+	parts := strings.Split(filepath.ToSlash(filepath.Clean(glob.Pattern)), "/")
+
+	last := -1
+	for i, part := range parts {
+		if strings.ContainsAny(part, "*?[") {
+			last = i
+		}
+	}
+
+	var targets []string
+	if last == -1 {
+		targets = []string{filepath.Join(cfg.HomeDir, filepath.FromSlash(glob.Pattern))}
+	} else {
+		prefix := filepath.Join(append([]string{cfg.HomeDir}, parts[:last+1]...)...)
+		matches, err := filepath.Glob(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %s: %w", glob.Pattern, err)
+		}
+		sort.Strings(matches)
+
+		rest := filepath.Join(parts[last+1:]...)
+		for _, match := range matches {
+			// Something has to be created below the match, so it must be a directory
+			if rest != "" {
+				if info, err := os.Stat(match); err != nil || !info.IsDir() {
+					continue
+				}
+			}
+			target := filepath.Join(match, rest)
+			if !IsInsideHome(cfg, target) {
+				continue
+			}
+			targets = append(targets, target)
+		}
+	}
+
+	if len(targets) == 0 {
+		if glob.Policy == types.MatchFailIfNone {
+			return nil, fmt.Errorf("no targets match %s", glob.Pattern)
+		}
+		return nil, nil
+	}
+
+	if glob.Policy == types.MatchFirst {
+		targets = targets[:1]
+	}
+
+	return targets, nil
+}
+
+// GlobRepoPath derives a default repo path for a glob pattern by dropping the
+// wildcard components (.mozilla/firefox/*.default-release/chrome/userChrome.css
+// becomes .mozilla/firefox/chrome/userChrome.css)
+func GlobRepoPath(pattern string) string {
+	var kept []string
+	for _, part := range strings.Split(filepath.ToSlash(filepath.Clean(pattern)), "/") {
+		if !strings.ContainsAny(part, "*?[") {
+			kept = append(kept, part)
+		}
+	}
+	return filepath.Join(kept...)
+}
+
+// HostLayer returns the overlay layer for the given host (hosts/<hostname>)
+func HostLayer(hostname string) string {
+	return filepath.Join(HostsLayerDir, hostname)
+}
+
+// OverlayLayers returns the overlay layers that apply to this machine, highest
+// priority first: hosts/<hostname>, then os/<goos>
+func OverlayLayers(cfg *types.Config) []string {
+	var layers []string
+	if cfg.Hostname != "" {
+		layers = append(layers, HostLayer(cfg.Hostname))
+	}
+	return append(layers, filepath.Join(OSLayerDir, runtime.GOOS))
+}
+
+// ParseLayer resolves a layer name as given on the command line: base, host
+// (hosts/<hostname> of this machine), os (os/<goos>) or a layer such as
+// hosts/laptop
+func ParseLayer(cfg *types.Config, name string) (string, error) {
+	switch name {
+	case "", BaseLayer:
+		return BaseLayer, nil
+	case "host":
+		if cfg.Hostname == "" {
+			return "", fmt.Errorf("the host name of this machine is unknown")
+		}
+		return HostLayer(cfg.Hostname), nil
+	case "os":
+		return filepath.Join(OSLayerDir, runtime.GOOS), nil
+	}
+
+	parts := strings.Split(filepath.Clean(name), string(filepath.Separator))
+	if len(parts) == 2 && (parts[0] == HostsLayerDir || parts[0] == OSLayerDir) && parts[1] != ".." {
+		return filepath.Join(parts...), nil
+	}
+	return "", fmt.Errorf("invalid layer %q; use base, host, os, hosts/<hostname> or os/<goos>", name)
+}
+
+// LayersRoot is the repo-relative directory holding the overlay layers
+// (.dotman/layers)
+var LayersRoot = filepath.Join(DotmanDirName, LayersDirName)
+
+// IsOverlayPath reports whether a repo-relative path is inside an overlay layer
+func IsOverlayPath(repoRelPath string) bool {
+	rel := filepath.Clean(repoRelPath)
+	return rel == LayersRoot || strings.HasPrefix(rel, LayersRoot+string(filepath.Separator))
+}
+
+// LayerPath returns the repo-relative path of an entry within a layer: the
+// repo root for the base layer, .dotman/layers/<layer> for overlays
+func LayerPath(layer, repoRelPath string) string {
+	if layer == "" || layer == BaseLayer {
+		return repoRelPath
+	}
+	return filepath.Join(LayersRoot, layer, repoRelPath)
+}
+
+// ResolveLayer finds the layer that supplies a repo path on this machine and
+// returns it along with the layer's repo-relative path. The base layer is used
+// when no overlay has a copy.
+func ResolveLayer(cfg *types.Config, repoRelPath string) (string, string) {
+	for _, layer := range OverlayLayers(cfg) {
+		layered := LayerPath(layer, repoRelPath)
+		if _, err := os.Lstat(filepath.Join(cfg.DotmanDir, layered)); err == nil {
+			return layer, layered
+		}
+	}
+	return BaseLayer, repoRelPath
+}
+
+// ParseDuration parses a Go duration, also accepting whole days and weeks
+// (7d, 2w)
+func ParseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if len(value) > 1 {
+		if n, err := strconv.Atoi(value[:len(value)-1]); err == nil {
+			switch value[len(value)-1] {
+			case 'd':
+				return time.Duration(n) * 24 * time.Hour, nil
+			case 'w':
+				return time.Duration(n) * 7 * 24 * time.Hour, nil
+			}
+		}
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q; use a value such as 12h, 7d or 2w", value)
+	}
+	return d, nil
+}
+
+// ParseSize parses a size in bytes, also accepting K, M and G suffixes for
+// KiB, MiB and GiB (512K, 2M)
+func ParseSize(input string) (int64, error) {
+	value := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(input)), "B")
+	shift := 0
+	if value != "" {
+		switch value[len(value)-1] {
+		case 'K':
+			shift = 10
+		case 'M':
+			shift = 20
+		case 'G':
+			shift = 30
+		}
+		if shift > 0 {
+			value = value[:len(value)-1]
+		}
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q; use a value such as 512K or 2M", input)
+	}
+	return n << shift, nil
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// ValidatePartial checks the include and exclude patterns of a partial
+// directory
+func ValidatePartial(partial *types.PartialDir) error {
+	if len(partial.Include) == 0 {
+		return fmt.Errorf("a partial directory needs at least one include pattern")
+	}
+	for _, pattern := range append(append([]string{}, partial.Include...), partial.Exclude...) {
+		if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		if strings.HasPrefix(pattern, "/") || strings.HasPrefix(path.Clean(pattern), "..") {
+			return fmt.Errorf("pattern must be relative to the directory: %s", pattern)
+		}
+	}
+	return nil
+}
+
+// PartialIncluded reports whether a file of a partial directory, given by
+// its slash-separated path relative to the directory, is a tracking candidate
+func PartialIncluded(partial *types.PartialDir, rel string) bool {
+	if PartialExcluded(partial, rel) {
+		return false
+	}
+	for _, pattern := range partial.Include {
+		if matchPartialPattern(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// PartialExcluded reports whether a path of a partial directory or one of
+// its parent directories matches an exclude pattern
+func PartialExcluded(partial *types.PartialDir, rel string) bool {
+	for prefix := rel; prefix != "." && prefix != "/" && prefix != ""; prefix = path.Dir(prefix) {
+		for _, pattern := range partial.Exclude {
+			if matchPartialPattern(pattern, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchPartialPattern matches a pattern against a relative path. Patterns
+// without a slash match the last path component at any depth, like
+// gitignore; others match the whole path, with ** matching any number of
+// directories.
+func matchPartialPattern(pattern, rel string) bool {
+	pattern = strings.TrimSuffix(pattern, "/")
+	if !strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, path.Base(rel))
+		return matched
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+// matchSegments matches path components, letting ** consume zero or more
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], parts[0]); !matched {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+const (
+	SourcesFileName = "sources.json"
+	DefaultSource   = "default"
+)
+
+// SourcesFile returns the path of the machine-local source list
+func SourcesFile(cfg *types.Config) string {
+	return filepath.Join(cfg.ConfigDir, SourcesFileName)
+}
+
+// SourcesDir returns the directory new sources are cloned into
+// ($XDG_DATA_HOME/dotman/sources or ~/.local/share/dotman/sources)
+func SourcesDir(cfg *types.Config) string {
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" && filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, StateDirName, "sources")
+	}
+	return filepath.Join(cfg.HomeDir, ".local", "share", StateDirName, "sources")
+}
+
+// LoadSources returns all configured sources, highest priority first. The
+// default source (the configured dotman directory) is always included.
+func LoadSources(cfg *types.Config) ([]types.Source, error) {
+	var sources []types.Source
+
+	data, err := os.ReadFile(SourcesFile(cfg))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read sources file: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &sources); err != nil {
+			return nil, fmt.Errorf("failed to parse sources file: %w", err)
+		}
+	}
+
+	hasDefault := false
+	for _, source := range sources {
+		if source.Name == DefaultSource {
+			hasDefault = true
+		}
+	}
+	if !hasDefault {
+		sources = append([]types.Source{{Name: DefaultSource, Dir: cfg.DotmanDir}}, sources...)
+	}
+
+	// Stable, so equal priorities keep file order
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].Priority > sources[j].Priority
+	})
+
+	return sources, nil
+}
+
+// SaveSources writes the source list
+func SaveSources(cfg *types.Config, sources []types.Source) error {
+	if err := fileops.MkdirAll(cfg.ConfigDir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(sources, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal sources: %w", err)
+	}
+
+	if err := fileops.WriteFile(SourcesFile(cfg), data, 0644); err != nil {
+		return fmt.Errorf("failed to write sources file: %w", err)
+	}
+
+	return nil
+}
+
+// FindSource returns the source with the given name
+func FindSource(sources []types.Source, name string) (types.Source, bool) {
+	for _, source := range sources {
+		if source.Name == name {
+			return source, true
+		}
+	}
+	return types.Source{}, false
+}
+
+// ForSource returns a copy of cfg that operates on the given source
+func ForSource(cfg *types.Config, source types.Source) *types.Config {
+	sourceCfg := *cfg
+	sourceCfg.DotmanDir = source.Dir
+	sourceCfg.IndexFile = filepath.Join(source.Dir, IndexFileName)
+	return &sourceCfg
+}
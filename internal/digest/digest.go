@@ -0,0 +1,95 @@
+package digest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const Prefix = "sha256:"
+
+// Path returns the content digest of a file or directory. Files hash their
+// content; directories hash the sorted list of their children's names, kinds
+// and digests (a Merkle tree), so any change below them changes the digest.
+func Path(path string) (string, error) {
+	sum, err := hashPath(path)
+	if err != nil {
+		return "", err
+	}
+	return Prefix + hex.EncodeToString(sum), nil
+}
+
+func hashPath(path string) ([]byte, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256([]byte(target))
+		return sum[:], nil
+	case info.IsDir():
+		return hashDir(path)
+	default:
+		return hashFile(path)
+	}
+}
+
+func hashFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+func hashDir(path string) ([]byte, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	kinds := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		// Nested repositories are not part of the managed content
+		if entry.Name() == ".git" {
+			continue
+		}
+		kind := "file"
+		switch {
+		case entry.Type()&os.ModeSymlink != 0:
+			kind = "link"
+		case entry.IsDir():
+			kind = "dir"
+		}
+		names = append(names, entry.Name())
+		kinds[entry.Name()] = kind
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		sum, err := hashPath(filepath.Join(path, name))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(h, "%s %x %s\n", kinds[name], sum, name)
+	}
+	return h.Sum(nil), nil
+}
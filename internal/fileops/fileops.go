@@ -3,22 +3,99 @@ package fileops
 import (
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/Merith-TK/dotman/internal/audit"
+	"github.com/Merith-TK/dotman/internal/logging"
 	"github.com/Merith-TK/dotman/pkg/types"
 )
 
+// Rename is os.Rename, logged and audited
+func Rename(from, to string) error {
+	return mutate("rename", from, to, func() error {
+		return os.Rename(from, to)
+	}, "from", from, "to", to)
+}
+
+// Remove is os.Remove, logged and audited
+func Remove(path string) error {
+	return mutate("remove", path, "", func() error {
+		return os.Remove(path)
+	}, "path", path)
+}
+
+// RemoveAll is os.RemoveAll, logged and audited
+func RemoveAll(path string) error {
+	return mutate("remove all", path, "", func() error {
+		return os.RemoveAll(path)
+	}, "path", path)
+}
+
+// Symlink is os.Symlink, logged and audited
+func Symlink(target, link string) error {
+	return mutate("symlink", link, "", func() error {
+		return os.Symlink(target, link)
+	}, "link", link, "target", target)
+}
+
+// MkdirAll is os.MkdirAll, logged and audited when it creates something
+func MkdirAll(path string, perm os.FileMode) error {
+	if IsDirectory(path) {
+		return nil
+	}
+	return mutate("mkdir", path, "", func() error {
+		return os.MkdirAll(path, perm)
+	}, "path", path)
+}
+
+// WriteFile is os.WriteFile, logged and audited
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	return mutate("write", path, "", func() error {
+		return os.WriteFile(path, data, perm)
+	}, "path", path, "bytes", len(data))
+}
+
+// mutate runs a change to path (and dest, for two-path operations), logs it
+// and records it in the audit log with the state before and after
+func mutate(op, path, dest string, change func() error, attrs ...any) error {
+	paths := []string{path}
+	if dest != "" {
+		paths = append(paths, dest)
+	}
+
+	auditing := audit.Enabled()
+	var before map[string]audit.State
+	if auditing {
+		before = audit.Capture(paths...)
+	}
+
+	start := time.Now()
+	err := change()
+	logging.Done(slog.LevelInfo, op, start, err, attrs...)
+
+	if auditing {
+		entry := audit.Entry{Op: op, Path: path, Dest: dest, Before: before, After: audit.Capture(paths...)}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		audit.Record(entry)
+	}
+	return err
+}
+
 // MoveToRepo moves a file or directory from its original location to the dotman repo
 func MoveToRepo(originalPath, repoPath string) error {
 	// Ensure the destination directory exists
 	destDir := filepath.Dir(repoPath)
-	if err := os.MkdirAll(destDir, 0755); err != nil {
+	if err := MkdirAll(destDir, 0755); err != nil {
 		return fmt.Errorf("failed to create destination directory: %w", err)
 	}
 
 	// Move the file/directory
-	if err := os.Rename(originalPath, repoPath); err != nil {
+	if err := Rename(originalPath, repoPath); err != nil {
 		return fmt.Errorf("failed to move %s to %s: %w", originalPath, repoPath, err)
 	}
 
@@ -29,12 +106,12 @@ func MoveToRepo(originalPath, repoPath string) error {
 func CreateSymlink(originalPath, repoPath string) error {
 	// Ensure the parent directory of the symlink exists
 	parentDir := filepath.Dir(originalPath)
-	if err := os.MkdirAll(parentDir, 0755); err != nil {
+	if err := MkdirAll(parentDir, 0755); err != nil {
 		return fmt.Errorf("failed to create parent directory for symlink: %w", err)
 	}
 
 	// Create the symlink
-	if err := os.Symlink(repoPath, originalPath); err != nil {
+	if err := Symlink(repoPath, originalPath); err != nil {
 		return fmt.Errorf("failed to create symlink from %s to %s: %w", originalPath, repoPath, err)
 	}
 
@@ -54,12 +131,12 @@ func RemoveSymlink(originalPath, repoPath string) error {
 	}
 
 	// Remove the symlink
-	if err := os.Remove(originalPath); err != nil {
+	if err := Remove(originalPath); err != nil {
 		return fmt.Errorf("failed to remove symlink: %w", err)
 	}
 
 	// Move the file back from repo to original location
-	if err := os.Rename(repoPath, originalPath); err != nil {
+	if err := Rename(repoPath, originalPath); err != nil {
 		return fmt.Errorf("failed to restore file from repo: %w", err)
 	}
 
@@ -98,21 +175,37 @@ func GetFileType(path string) types.FileType {
 	return types.FileTypeFile
 }
 
-// BackupPath creates a backup of a file or directory by copying it with a .backup suffix
-func BackupPath(path string) error {
-	backupPath := path + ".backup"
-	
-	// Remove existing backup if it exists
-	if PathExists(backupPath) {
-		if err := os.RemoveAll(backupPath); err != nil {
-			return fmt.Errorf("failed to remove existing backup: %w", err)
-		}
+// BackupPath copies a file, directory or symlink to backupPath in the backup
+// store. Symlinks are copied as links, not followed.
+func BackupPath(path, backupPath string) error {
+	if err := MkdirAll(filepath.Dir(backupPath), 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
 	}
 
-	if IsDirectory(path) {
-		return copyDir(path, backupPath)
+	return mutate("backup", path, backupPath, func() error {
+		if IsSymlink(path) {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(target, backupPath)
+		}
+		return copyPath(path, backupPath)
+	}, "from", path, "to", backupPath)
+}
+
+// CopyPath copies a file or directory, preserving permissions
+func CopyPath(src, dst string) error {
+	return mutate("copy", src, dst, func() error {
+		return copyPath(src, dst)
+	}, "from", src, "to", dst)
+}
+
+func copyPath(src, dst string) error {
+	if IsDirectory(src) {
+		return copyDir(src, dst)
 	}
-	return copyFile(path, backupPath)
+	return copyFile(src, dst)
 }
 
 // copyFile copies a single file
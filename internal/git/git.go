@@ -6,20 +6,47 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/logging"
+	"github.com/Merith-TK/dotman/pkg/types"
 )
 
+// runCmd, cmdOutput and combinedOutput execute a git command and log it
+func runCmd(cmd *exec.Cmd) error {
+	_, err := logging.Command(cmd, func() ([]byte, error) { return nil, cmd.Run() })
+	return err
+}
+
+func cmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return logging.Command(cmd, cmd.Output)
+}
+
+func combinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	return logging.Command(cmd, cmd.CombinedOutput)
+}
+
 // InitRepo initializes a git repository in the specified directory
 func InitRepo(repoPath string) error {
 	cmd := exec.Command("git", "init", "-b", "main")
 	cmd.Dir = repoPath
 
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to initialize git repo: %s, %w", string(output), err)
+	if output, err := combinedOutput(cmd); err != nil {
+		return types.Errorf(types.ErrGit, "failed to initialize git repo: %s, %w", string(output), err)
 	}
 
 	return nil
 }
 
+// Clone clones the repository at url into dir
+func Clone(url, dir string) error {
+	cmd := exec.Command("git", "clone", url, dir)
+	if output, err := combinedOutput(cmd); err != nil {
+		return types.Errorf(types.ErrGit, "failed to clone repository: %s, %w", string(output), err)
+	}
+	return nil
+}
+
 // IsGitRepo checks if the directory is a git repository
 func IsGitRepo(repoPath string) bool {
 	gitDir := filepath.Join(repoPath, ".git")
@@ -38,8 +65,8 @@ func Add(repoPath string, files ...string) error {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = repoPath
 
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to add files to git: %s, %w", string(output), err)
+	if output, err := combinedOutput(cmd); err != nil {
+		return types.Errorf(types.ErrGit, "failed to add files to git: %s, %w", string(output), err)
 	}
 
 	return nil
@@ -50,13 +77,13 @@ func Commit(repoPath, message string) error {
 	cmd := exec.Command("git", "commit", "-m", message)
 	cmd.Dir = repoPath
 
-	if output, err := cmd.CombinedOutput(); err != nil {
+	if output, err := combinedOutput(cmd); err != nil {
 		// Check if the error is because there's nothing to commit
 		if cmd.ProcessState.ExitCode() == 1 {
 			// This might be "nothing to commit" which is not really an error
 			return nil
 		}
-		return fmt.Errorf("failed to commit: %s, %w", string(output), err)
+		return types.Errorf(types.ErrGit, "failed to commit: %s, %w", string(output), err)
 	}
 
 	return nil
@@ -67,14 +94,28 @@ func Status(repoPath string) (string, error) {
 	cmd := exec.Command("git", "status", "--porcelain")
 	cmd.Dir = repoPath
 
-	output, err := cmd.Output()
+	output, err := cmdOutput(cmd)
 	if err != nil {
-		return "", fmt.Errorf("failed to get git status: %w", err)
+		return "", types.Errorf(types.ErrGit, "failed to get git status: %w", err)
 	}
 
 	return string(output), nil
 }
 
+// PathHasChanges checks if a path differs from HEAD (modified, deleted,
+// untracked or staged)
+func PathHasChanges(repoPath, path string) (bool, error) {
+	cmd := exec.Command("git", "status", "--porcelain", "--", path)
+	cmd.Dir = repoPath
+
+	output, err := cmdOutput(cmd)
+	if err != nil {
+		return false, types.Errorf(types.ErrGit, "failed to get git status: %w", err)
+	}
+
+	return len(output) > 0, nil
+}
+
 // HasChanges checks if there are any uncommitted changes
 func HasChanges(repoPath string) (bool, error) {
 	status, err := Status(repoPath)
@@ -100,63 +141,78 @@ Thumbs.db
 `
 
 	gitignorePath := filepath.Join(repoPath, ".gitignore")
-	if err := os.WriteFile(gitignorePath, []byte(gitignoreContent), 0644); err != nil {
+	if err := fileops.WriteFile(gitignorePath, []byte(gitignoreContent), 0644); err != nil {
 		return fmt.Errorf("failed to create .gitignore: %w", err)
 	}
 
 	return nil
 }
 
-// Pull pulls changes from the remote repository
-func Pull(repoPath string) error {
-	cmd := exec.Command("git", "pull")
+// Pull pulls a branch from a remote repository into the current branch
+func Pull(repoPath, remote, branch string) error {
+	cmd := exec.Command("git", "pull", remote, branch)
 	cmd.Dir = repoPath
 
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to pull from remote: %s, %w", string(output), err)
+	if output, err := combinedOutput(cmd); err != nil {
+		return types.Errorf(types.ErrGit, "failed to pull from remote: %s, %w", string(output), err)
 	}
 
 	return nil
 }
 
-// Push pushes changes to the remote repository
-func Push(repoPath string) error {
-	cmd := exec.Command("git", "push")
+// Push pushes the current branch to a remote branch. If the current branch
+// has no upstream yet, the pushed branch becomes its upstream.
+func Push(repoPath, remote, branch string) error {
+	args := []string{"push"}
+	if _, err := GetUpstream(repoPath); err != nil {
+		args = append(args, "--set-upstream")
+	}
+	args = append(args, remote, "HEAD:"+branch)
+
+	cmd := exec.Command("git", args...)
 	cmd.Dir = repoPath
 
-	if output, err := cmd.CombinedOutput(); err != nil {
-		// Check if this is the first push that needs upstream setup
-		if strings.Contains(string(output), "no upstream branch") {
-			// Get current branch and set upstream
-			branch, branchErr := GetCurrentBranch(repoPath)
-			if branchErr != nil {
-				return fmt.Errorf("failed to push to remote: %s, %w", string(output), err)
-			}
+	if output, err := combinedOutput(cmd); err != nil {
+		return types.Errorf(types.ErrGit, "failed to push to remote: %s, %w", string(output), err)
+	}
 
-			// Push with set-upstream
-			upstreamCmd := exec.Command("git", "push", "--set-upstream", "origin", branch)
-			upstreamCmd.Dir = repoPath
+	return nil
+}
 
-			if upstreamOutput, upstreamErr := upstreamCmd.CombinedOutput(); upstreamErr != nil {
-				return fmt.Errorf("failed to push to remote: %s, %w", string(upstreamOutput), upstreamErr)
-			}
+// PushMirror pushes the current branch to a remote branch without touching
+// upstream tracking
+func PushMirror(repoPath, remote, branch string) error {
+	cmd := exec.Command("git", "push", remote, "HEAD:"+branch)
+	cmd.Dir = repoPath
 
-			return nil
-		}
-		return fmt.Errorf("failed to push to remote: %s, %w", string(output), err)
+	if output, err := combinedOutput(cmd); err != nil {
+		return types.Errorf(types.ErrGit, "failed to push to %s: %s, %w", remote, string(output), err)
 	}
 
 	return nil
 }
 
+// GetHead returns the commit hash HEAD points to, or "" if there are no commits
+func GetHead(repoPath string) string {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", "HEAD")
+	cmd.Dir = repoPath
+
+	output, err := cmdOutput(cmd)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(output))
+}
+
 // GetCurrentBranch returns the current branch name
 func GetCurrentBranch(repoPath string) (string, error) {
 	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
 	cmd.Dir = repoPath
 
-	output, err := cmd.Output()
+	output, err := cmdOutput(cmd)
 	if err != nil {
-		return "", fmt.Errorf("failed to get current branch: %w", err)
+		return "", types.Errorf(types.ErrGit, "failed to get current branch: %w", err)
 	}
 
 	branch := string(output)
@@ -167,12 +223,132 @@ func GetCurrentBranch(repoPath string) (string, error) {
 	return branch, nil
 }
 
+// Remote describes a configured git remote
+type Remote struct {
+	Name string
+	URL  string
+}
+
+// ListRemotes returns all configured remotes in git's order
+func ListRemotes(repoPath string) ([]Remote, error) {
+	cmd := exec.Command("git", "remote")
+	cmd.Dir = repoPath
+
+	output, err := cmdOutput(cmd)
+	if err != nil {
+		return nil, types.Errorf(types.ErrGit, "failed to list remotes: %w", err)
+	}
+
+	var remotes []Remote
+	for _, name := range splitLines(string(output)) {
+		url, _ := GetConfig(repoPath, "remote."+name+".url")
+		remotes = append(remotes, Remote{Name: name, URL: url})
+	}
+
+	return remotes, nil
+}
+
+// HasRemote checks if a remote with the given name exists
+func HasRemote(repoPath, name string) bool {
+	_, err := GetConfig(repoPath, "remote."+name+".url")
+	return err == nil
+}
+
+// AddRemote adds a new remote
+func AddRemote(repoPath, name, url string) error {
+	return runRemote(repoPath, "add", name, url)
+}
+
+// SetRemoteURL changes the URL of an existing remote
+func SetRemoteURL(repoPath, name, url string) error {
+	return runRemote(repoPath, "set-url", name, url)
+}
+
+// RemoveRemote removes a remote and its remote-tracking branches
+func RemoveRemote(repoPath, name string) error {
+	return runRemote(repoPath, "remove", name)
+}
+
+// RenameRemote renames a remote, updating tracking configuration
+func RenameRemote(repoPath, oldName, newName string) error {
+	return runRemote(repoPath, "rename", oldName, newName)
+}
+
+func runRemote(repoPath string, args ...string) error {
+	cmd := exec.Command("git", append([]string{"remote"}, args...)...)
+	cmd.Dir = repoPath
+
+	if output, err := combinedOutput(cmd); err != nil {
+		return types.Errorf(types.ErrGit, "git remote %s failed: %s, %w", args[0], strings.TrimSpace(string(output)), err)
+	}
+
+	return nil
+}
+
+// GetBranchUpstream returns the remote and remote branch the given local
+// branch tracks, read from the branch configuration
+func GetBranchUpstream(repoPath, branch string) (string, string, error) {
+	remote, err := GetConfig(repoPath, "branch."+branch+".remote")
+	if err != nil {
+		return "", "", fmt.Errorf("no upstream branch configured")
+	}
+
+	merge, err := GetConfig(repoPath, "branch."+branch+".merge")
+	if err != nil {
+		return "", "", fmt.Errorf("no upstream branch configured")
+	}
+
+	return remote, strings.TrimPrefix(merge, "refs/heads/"), nil
+}
+
+// GetConfig reads a value from the repository's git configuration
+func GetConfig(repoPath, key string) (string, error) {
+	cmd := exec.Command("git", "config", "--get", key)
+	cmd.Dir = repoPath
+
+	output, err := cmdOutput(cmd)
+	if err != nil {
+		return "", fmt.Errorf("%s is not set", key)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// SetConfig writes a value to the repository's git configuration
+func SetConfig(repoPath, key, value string) error {
+	cmd := exec.Command("git", "config", key, value)
+	cmd.Dir = repoPath
+
+	if output, err := combinedOutput(cmd); err != nil {
+		return types.Errorf(types.ErrGit, "failed to set %s: %s, %w", key, string(output), err)
+	}
+
+	return nil
+}
+
+// UnsetConfig removes a value from the repository's git configuration.
+// Removing a key that isn't set is not an error.
+func UnsetConfig(repoPath, key string) error {
+	cmd := exec.Command("git", "config", "--unset", key)
+	cmd.Dir = repoPath
+
+	if output, err := combinedOutput(cmd); err != nil {
+		// Exit code 5 means the key wasn't set
+		if cmd.ProcessState != nil && cmd.ProcessState.ExitCode() == 5 {
+			return nil
+		}
+		return types.Errorf(types.ErrGit, "failed to unset %s: %s, %w", key, string(output), err)
+	}
+
+	return nil
+}
+
 // GetRemoteURL returns the remote origin URL
 func GetRemoteURL(repoPath string) (string, error) {
 	cmd := exec.Command("git", "remote", "get-url", "origin")
 	cmd.Dir = repoPath
 
-	output, err := cmd.Output()
+	output, err := cmdOutput(cmd)
 	if err != nil {
 		return "", fmt.Errorf("no remote origin configured")
 	}
@@ -190,7 +366,7 @@ func GetCommitCount(repoPath string) (string, error) {
 	cmd := exec.Command("git", "rev-list", "--count", "HEAD")
 	cmd.Dir = repoPath
 
-	output, err := cmd.Output()
+	output, err := cmdOutput(cmd)
 	if err != nil {
 		return "0", nil // No commits yet
 	}
@@ -210,9 +386,11 @@ func EnsureRepo(repoPath string) error {
 			return err
 		}
 
-		// Create initial .gitignore
-		if err := CreateGitignore(repoPath); err != nil {
-			return err
+		// Create initial .gitignore, keeping one that came with the tree
+		if _, err := os.Stat(filepath.Join(repoPath, ".gitignore")); os.IsNotExist(err) {
+			if err := CreateGitignore(repoPath); err != nil {
+				return err
+			}
 		}
 
 		// Make initial commit
@@ -227,3 +405,196 @@ func EnsureRepo(repoPath string) error {
 
 	return nil
 }
+
+// Version returns the version string reported by the git binary
+func Version() (string, error) {
+	cmd := exec.Command("git", "version")
+
+	output, err := cmdOutput(cmd)
+	if err != nil {
+		return "", types.Errorf(types.ErrGit, "failed to run git: %w", err)
+	}
+
+	return strings.TrimSpace(strings.TrimPrefix(string(output), "git version")), nil
+}
+
+// GetUpstream returns the upstream branch of the current branch (e.g. origin/main)
+func GetUpstream(repoPath string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}")
+	cmd.Dir = repoPath
+
+	output, err := cmdOutput(cmd)
+	if err != nil {
+		return "", fmt.Errorf("no upstream branch configured")
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// AheadBehind returns how many commits HEAD is ahead of and behind its upstream
+func AheadBehind(repoPath string) (int, int, error) {
+	cmd := exec.Command("git", "rev-list", "--left-right", "--count", "HEAD...@{upstream}")
+	cmd.Dir = repoPath
+
+	output, err := cmdOutput(cmd)
+	if err != nil {
+		return 0, 0, types.Errorf(types.ErrGit, "failed to compare with upstream: %w", err)
+	}
+
+	var ahead, behind int
+	if _, err := fmt.Sscanf(string(output), "%d %d", &ahead, &behind); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse ahead/behind counts: %w", err)
+	}
+
+	return ahead, behind, nil
+}
+
+// IsTracked checks if a path is tracked in the repository
+func IsTracked(repoPath, path string) bool {
+	cmd := exec.Command("git", "ls-files", "--error-unmatch", "--", path)
+	cmd.Dir = repoPath
+
+	return runCmd(cmd) == nil
+}
+
+// CheckIgnored returns the given paths that are matched by ignore rules,
+// whether or not they are tracked
+func CheckIgnored(repoPath string, paths ...string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	args := append([]string{"check-ignore", "--no-index", "--"}, paths...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoPath
+
+	output, err := cmdOutput(cmd)
+	if err != nil {
+		// Exit code 1 means none of the paths are ignored
+		if cmd.ProcessState != nil && cmd.ProcessState.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, types.Errorf(types.ErrGit, "failed to check ignore rules: %w", err)
+	}
+
+	return splitLines(string(output)), nil
+}
+
+// IgnoredFiles returns untracked files below the given paths that are hidden
+// by ignore rules
+func IgnoredFiles(repoPath string, paths ...string) ([]string, error) {
+	args := append([]string{"ls-files", "--others", "--ignored", "--exclude-standard", "--"}, paths...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoPath
+
+	output, err := cmdOutput(cmd)
+	if err != nil {
+		return nil, types.Errorf(types.ErrGit, "failed to list ignored files: %w", err)
+	}
+
+	return splitLines(string(output)), nil
+}
+
+// splitLines splits command output into non-empty lines
+func splitLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// RunAttached runs a git command in the repository with the terminal's
+// stdin, stdout and stderr attached, so paging and colors work as usual
+func RunAttached(repoPath string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoPath
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return runCmd(cmd)
+}
+
+// Diff returns the output of git diff with args. With --no-index, exit
+// status 1 only means that the files differ.
+func Diff(repoPath string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"diff", "--no-color"}, args...)...)
+	cmd.Dir = repoPath
+
+	output, err := cmdOutput(cmd)
+	if err != nil && !(cmd.ProcessState != nil && cmd.ProcessState.ExitCode() == 1) {
+		return "", types.Errorf(types.ErrGit, "failed to diff: %w", err)
+	}
+
+	return string(output), nil
+}
+
+// VerifyRevision checks that rev names a commit and returns its full hash
+func VerifyRevision(repoPath, rev string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	cmd.Dir = repoPath
+
+	output, err := cmdOutput(cmd)
+	if err != nil {
+		return "", fmt.Errorf("unknown revision: %s", rev)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ExistsAt checks whether a path exists in the given revision
+func ExistsAt(repoPath, rev, path string) bool {
+	cmd := exec.Command("git", "cat-file", "-e", rev+":"+filepath.ToSlash(path))
+	cmd.Dir = repoPath
+
+	return runCmd(cmd) == nil
+}
+
+// RestorePath restores a path in the index and working tree to its content at rev
+func RestorePath(repoPath, rev, path string) error {
+	cmd := exec.Command("git", "restore", "--source="+rev, "--staged", "--worktree", "--", path)
+	cmd.Dir = repoPath
+
+	if output, err := combinedOutput(cmd); err != nil {
+		return types.Errorf(types.ErrGit, "failed to restore %s: %s, %w", path, string(output), err)
+	}
+
+	return nil
+}
+
+// ListBareFiles returns the files tracked by a separate git directory whose
+// work tree is workTree (the "git --git-dir=$HOME/.cfg" setup), relative to
+// the work tree
+func ListBareFiles(gitDir, workTree string) ([]string, error) {
+	cmd := exec.Command("git", "--git-dir="+gitDir, "--work-tree="+workTree, "ls-files", "-z")
+	cmd.Dir = workTree
+
+	output, err := cmdOutput(cmd)
+	if err != nil {
+		return nil, types.Errorf(types.ErrGit, "failed to list files in %s: %w", gitDir, err)
+	}
+
+	var files []string
+	for _, file := range strings.Split(string(output), "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// Move renames a tracked path in the repository with git mv, keeping its
+// history connected
+func Move(repoPath, from, to string) error {
+	cmd := exec.Command("git", "mv", "--", from, to)
+	cmd.Dir = repoPath
+
+	if output, err := combinedOutput(cmd); err != nil {
+		return types.Errorf(types.ErrGit, "failed to move %s to %s: %s, %w", from, to, string(output), err)
+	}
+
+	return nil
+}
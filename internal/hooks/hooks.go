@@ -0,0 +1,264 @@
+package hooks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/logging"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+const (
+	HooksDirName  = "hooks"
+	StateFileName = "hooks.json"
+
+	PreDeploy  = "pre-deploy"
+	PostDeploy = "post-deploy"
+	PostAdd    = "post-add"
+	PostPull   = "post-pull"
+
+	RunOncePrefix     = "run_once_"
+	RunOnChangePrefix = "run_onchange_"
+)
+
+// State records which run-once and run-on-change scripts have already been
+// executed on this machine. It lives in the machine-local state directory.
+type State struct {
+	// RunOnce maps a script content hash to the time it was run
+	RunOnce map[string]ScriptRun `json:"run_once"`
+	// RunOnChange maps a script name to the content hash it was last run with
+	RunOnChange map[string]ScriptRun `json:"run_onchange"`
+}
+
+// ScriptRun describes a single recorded script execution
+type ScriptRun struct {
+	Name  string    `json:"name"`
+	Hash  string    `json:"hash"`
+	RanAt time.Time `json:"ran_at"`
+}
+
+// Dir returns the hooks directory inside the repository (<repo>/.dotman/hooks)
+func Dir(cfg *types.Config) string {
+	return filepath.Join(config.MetadataDir(cfg), HooksDirName)
+}
+
+// StatePath returns the path of the machine-local hook state file
+func StatePath(cfg *types.Config) string {
+	return filepath.Join(cfg.StateDir, StateFileName)
+}
+
+// LoadState reads the hook state file, returning an empty state if it doesn't exist
+func LoadState(cfg *types.Config) (*State, error) {
+	state := &State{
+		RunOnce:     make(map[string]ScriptRun),
+		RunOnChange: make(map[string]ScriptRun),
+	}
+
+	data, err := os.ReadFile(StatePath(cfg))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed to read hook state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse hook state: %w", err)
+	}
+	if state.RunOnce == nil {
+		state.RunOnce = make(map[string]ScriptRun)
+	}
+	if state.RunOnChange == nil {
+		state.RunOnChange = make(map[string]ScriptRun)
+	}
+
+	return state, nil
+}
+
+// SaveState writes the hook state file
+func SaveState(cfg *types.Config, state *State) error {
+	if err := config.EnsureStateDir(cfg); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal hook state: %w", err)
+	}
+
+	if err := fileops.WriteFile(StatePath(cfg), data, 0644); err != nil {
+		return fmt.Errorf("failed to write hook state: %w", err)
+	}
+
+	return nil
+}
+
+// Report receives a progress message of Run and RunScripts: the hook or
+// script about to run, or in a dry run that would run
+type Report func(message string)
+
+// Find returns the script for the named hook, or "" if there is none.
+// A hook may be named exactly (pre-deploy) or carry an extension (pre-deploy.sh).
+func Find(cfg *types.Config, name string) string {
+	entries, err := os.ReadDir(Dir(cfg))
+	if err != nil {
+		return ""
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		base := entry.Name()
+		if base == name || strings.TrimSuffix(base, filepath.Ext(base)) == name {
+			return filepath.Join(Dir(cfg), base)
+		}
+	}
+	return ""
+}
+
+// Run executes the named hook if it exists. Missing hooks are not an error.
+// In a dry run the hook is only reported.
+func Run(cfg *types.Config, name string, dryRun bool, report Report) error {
+	script := Find(cfg, name)
+	if script == "" {
+		return nil
+	}
+
+	if dryRun {
+		report(fmt.Sprintf("Would run %s hook: %s", name, filepath.Base(script)))
+		return nil
+	}
+
+	report(fmt.Sprintf("Running %s hook...", name))
+	if err := execute(cfg, script); err != nil {
+		return fmt.Errorf("%s hook failed: %w", name, err)
+	}
+	return nil
+}
+
+// RunScripts executes pending run_once_* and run_onchange_* scripts in name order.
+// A run_once_ script runs once per machine for each distinct content; a
+// run_onchange_ script runs again whenever its content changes. In a dry run
+// the pending scripts are only reported.
+func RunScripts(cfg *types.Config, dryRun bool, report Report) error {
+	entries, err := os.ReadDir(Dir(cfg))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read hooks directory: %w", err)
+	}
+
+	state, err := LoadState(cfg)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasPrefix(name, RunOncePrefix) || strings.HasPrefix(name, RunOnChangePrefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var failures []string
+	for _, name := range names {
+		script := filepath.Join(Dir(cfg), name)
+		hash, err := hashFile(script)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+
+		once := strings.HasPrefix(name, RunOncePrefix)
+		if once {
+			if _, ran := state.RunOnce[hash]; ran {
+				continue
+			}
+		} else if prev, ran := state.RunOnChange[name]; ran && prev.Hash == hash {
+			continue
+		}
+
+		if dryRun {
+			report("Would run script: " + name)
+			continue
+		}
+
+		report(fmt.Sprintf("Running script %s...", name))
+		if err := execute(cfg, script); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+
+		run := ScriptRun{Name: name, Hash: hash, RanAt: time.Now()}
+		if once {
+			state.RunOnce[hash] = run
+		} else {
+			state.RunOnChange[name] = run
+		}
+
+		// Save after every script so a later failure doesn't cause a re-run
+		if err := SaveState(cfg, state); err != nil {
+			return err
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d script(s) failed: %s", len(failures), strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// Env returns the environment passed to hooks and scripts
+func Env(cfg *types.Config) []string {
+	return append(os.Environ(),
+		"DOTMAN_REPO="+cfg.DotmanDir,
+		"DOTMAN_HOME="+cfg.HomeDir,
+		"DOTMAN_PROFILE="+cfg.Profile,
+	)
+}
+
+// execute runs a script from the repository root. Executable scripts are run
+// directly; anything else is handed to sh.
+func execute(cfg *types.Config, script string) error {
+	var cmd *exec.Cmd
+	if info, err := os.Stat(script); err == nil && info.Mode()&0111 != 0 {
+		cmd = exec.Command(script)
+	} else {
+		cmd = exec.Command("sh", script)
+	}
+	cmd.Dir = cfg.DotmanDir
+	cmd.Env = Env(cfg)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	_, err := logging.Command(cmd, func() ([]byte, error) { return nil, cmd.Run() })
+	return err
+}
+
+// hashFile returns the hex-encoded SHA-256 of a file's content
+func hashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,45 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/git"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// Bare plans the import of a bare repository used with a separate work tree
+// (git --git-dir=$HOME/.cfg --work-tree=$HOME). Tracked files are live in the
+// work tree, so they are moved into the dotman repo file by file. The bare
+// repository itself is left untouched.
+func Bare(gitDir, workTree string) (*Plan, error) {
+	if !fileops.IsDirectory(gitDir) {
+		return nil, fmt.Errorf("git directory does not exist: %s", gitDir)
+	}
+
+	files, err := git.ListBareFiles(gitDir, workTree)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Tool: "bare repository"}
+	for _, file := range files {
+		target := filepath.Join(workTree, file)
+
+		info, err := os.Lstat(target)
+		switch {
+		case err != nil:
+			plan.skip(target, target, "missing from the work tree")
+		case info.Mode()&os.ModeSymlink != 0:
+			plan.skip(target, target, "tracked symlink")
+		case !info.Mode().IsRegular():
+			plan.skip(target, target, "not a regular file")
+		default:
+			plan.Items = append(plan.Items, Item{Target: target, Source: target, Type: types.FileTypeFile, Move: true})
+		}
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,180 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// ChezmoiAttrs are the attributes encoded in a chezmoi source state name
+type ChezmoiAttrs struct {
+	Private    bool
+	ReadOnly   bool
+	Executable bool
+	// Unsupported names an attribute dotman can't represent (templates,
+	// scripts, encrypted files, ...)
+	Unsupported string
+}
+
+// Mode returns the permissions chezmoi would give a file with these attributes
+func (a ChezmoiAttrs) Mode() os.FileMode {
+	mode := os.FileMode(0644)
+	if a.Executable {
+		mode = 0755
+	}
+	if a.Private {
+		mode &= 0700
+	}
+	if a.ReadOnly {
+		mode &^= 0222
+	}
+	return mode
+}
+
+// DirMode returns the permissions chezmoi would give a directory with these
+// attributes
+func (a ChezmoiAttrs) DirMode() os.FileMode {
+	mode := os.FileMode(0755)
+	if a.Private {
+		mode &= 0700
+	}
+	if a.ReadOnly {
+		mode &^= 0222
+	}
+	return mode
+}
+
+// chezmoiFilePrefixes and chezmoiDirPrefixes list the attribute prefixes in
+// the order chezmoi accepts them
+var (
+	chezmoiFilePrefixes = []string{"encrypted_", "private_", "readonly_", "empty_", "executable_", "dot_"}
+	chezmoiDirPrefixes  = []string{"remove_", "external_", "exact_", "private_", "readonly_", "dot_"}
+
+	// chezmoiSpecialPrefixes mark source entries that are not plain files
+	chezmoiSpecialPrefixes = []string{"run_", "once_", "onchange_", "before_", "after_", "create_", "modify_", "remove_", "symlink_"}
+)
+
+// ParseChezmoiName translates one chezmoi source state name into the target
+// name and its attributes
+func ParseChezmoiName(name string, isDir bool) (string, ChezmoiAttrs) {
+	var attrs ChezmoiAttrs
+
+	prefixes := chezmoiFilePrefixes
+	if isDir {
+		prefixes = chezmoiDirPrefixes
+	} else {
+		for _, prefix := range chezmoiSpecialPrefixes {
+			if strings.HasPrefix(name, prefix) {
+				attrs.Unsupported = strings.TrimSuffix(prefix, "_")
+				return name, attrs
+			}
+		}
+	}
+
+	if strings.HasPrefix(name, "literal_") {
+		return strings.TrimPrefix(name, "literal_"), attrs
+	}
+
+	for _, prefix := range prefixes {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		name = strings.TrimPrefix(name, prefix)
+		switch prefix {
+		case "encrypted_", "remove_", "external_":
+			attrs.Unsupported = strings.TrimSuffix(prefix, "_")
+		case "private_":
+			attrs.Private = true
+		case "readonly_":
+			attrs.ReadOnly = true
+		case "executable_":
+			attrs.Executable = true
+		case "dot_":
+			name = "." + name
+		}
+		if strings.HasPrefix(name, "literal_") {
+			name = strings.TrimPrefix(name, "literal_")
+			break
+		}
+	}
+
+	if !isDir {
+		switch {
+		case strings.HasSuffix(name, ".literal"):
+			name = strings.TrimSuffix(name, ".literal")
+		case strings.HasSuffix(name, ".tmpl"):
+			attrs.Unsupported = "template"
+		}
+	}
+
+	return name, attrs
+}
+
+// Chezmoi plans the import of a chezmoi source directory into targetDir.
+// Files are copied from the source state with the permissions their
+// attributes describe; templates, scripts and encrypted files are skipped.
+func Chezmoi(sourceDir, targetDir string) (*Plan, error) {
+	if !fileops.IsDirectory(sourceDir) {
+		return nil, fmt.Errorf("chezmoi source directory does not exist: %s", sourceDir)
+	}
+
+	plan := &Plan{Tool: "chezmoi"}
+	if err := planChezmoiDir(plan, sourceDir, targetDir, 0); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// planChezmoiDir adds the items below one source directory. dirMode is the
+// permission chezmoi gives the directory itself, 0 for the default.
+func planChezmoiDir(plan *Plan, sourceDir, targetDir string, dirMode os.FileMode) error {
+	entries, err := os.ReadDir(sourceDir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", sourceDir, err)
+	}
+
+	for _, entry := range entries {
+		source := filepath.Join(sourceDir, entry.Name())
+
+		// chezmoi ignores names starting with a dot; .chezmoi* files are its
+		// own configuration
+		if strings.HasPrefix(entry.Name(), ".") {
+			if strings.HasPrefix(entry.Name(), ".chezmoi") {
+				plan.skip(source, source, "chezmoi configuration")
+			}
+			continue
+		}
+
+		name, attrs := ParseChezmoiName(entry.Name(), entry.IsDir())
+		target := filepath.Join(targetDir, name)
+
+		if attrs.Unsupported != "" {
+			plan.skip(target, source, "unsupported chezmoi attribute: "+attrs.Unsupported)
+			continue
+		}
+
+		if entry.IsDir() {
+			mode := os.FileMode(0)
+			if attrs.Private || attrs.ReadOnly {
+				mode = attrs.DirMode()
+			}
+			if err := planChezmoiDir(plan, source, target, mode); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if entry.Type()&os.ModeSymlink != 0 {
+			plan.skip(target, source, "symlink in source state")
+			continue
+		}
+
+		plan.Items = append(plan.Items, Item{Target: target, Source: source, Type: types.FileTypeFile, Mode: attrs.Mode(), DirMode: dirMode})
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/digest"
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// Item is one target to bring under dotman management
+type Item struct {
+	// Target is the absolute path in $HOME that will become a symlink
+	Target string
+	// Source holds the content to import
+	Source string
+	// Type of the imported content
+	Type types.FileType
+	// Move moves Source into the repo instead of copying it
+	Move bool
+	// Mode sets the permissions of the repo copy; 0 keeps the source mode
+	Mode os.FileMode
+	// DirMode sets the permissions of the target's parent directory; 0
+	// leaves them alone
+	DirMode os.FileMode
+	// Replace means Target holds something other than Source that is
+	// removed (after a backup) when the item is imported
+	Replace bool
+	// Skip is the reason the item is not imported, if any
+	Skip string
+}
+
+// Plan lists what an import would do
+type Plan struct {
+	Tool  string
+	Items []Item
+}
+
+// Count returns the number of items that will be imported
+func (p *Plan) Count() int {
+	count := 0
+	for _, item := range p.Items {
+		if item.Skip == "" {
+			count++
+		}
+	}
+	return count
+}
+
+// skip adds an item that won't be imported
+func (p *Plan) skip(target, source, reason string) {
+	p.Items = append(p.Items, Item{Target: target, Source: source, Skip: reason})
+}
+
+// Check decides for every item whether it can be imported into the
+// repository described by cfg and idx. Targets holding different content are
+// only replaced when force is set.
+func Check(cfg *types.Config, idx *types.Index, plan *Plan, force bool) {
+	seen := make(map[string]bool)
+
+	for i := range plan.Items {
+		item := &plan.Items[i]
+		if item.Skip != "" {
+			continue
+		}
+
+		relativePath, err := config.RelativeToHome(cfg, item.Target)
+		if err != nil || !config.IsInsideHome(cfg, item.Target) {
+			item.Skip = "outside home directory"
+			continue
+		}
+
+		switch {
+		case seen[item.Target]:
+			item.Skip = "duplicate target"
+		case index.IsManaged(idx, item.Target):
+			item.Skip = "already managed"
+		case config.ShouldIgnoreRepoPath(cfg, relativePath):
+			item.Skip = "repository metadata"
+		case fileops.PathExists(filepath.Join(cfg.DotmanDir, relativePath)):
+			item.Skip = "already exists in the repo"
+		case !item.Move:
+			checkTarget(item, force)
+		}
+		seen[item.Target] = true
+	}
+}
+
+// checkTarget decides what happens to the current target of a copied item.
+// Links into the source and identical copies are replaced silently.
+func checkTarget(item *Item, force bool) {
+	if _, err := os.Lstat(item.Target); err != nil {
+		return
+	}
+
+	if resolved, err := filepath.EvalSymlinks(item.Target); err == nil {
+		if source, err := filepath.EvalSymlinks(item.Source); err == nil && resolved == source {
+			return
+		}
+	}
+
+	if !fileops.IsSymlink(item.Target) && sameContent(item.Target, item.Source) {
+		return
+	}
+
+	if !force {
+		item.Skip = "target exists with different content (use --force to back it up and replace it)"
+		return
+	}
+	item.Replace = true
+}
+
+// sameContent reports whether two paths hold identical content
+func sameContent(a, b string) bool {
+	digestA, err := digest.Path(a)
+	if err != nil {
+		return false
+	}
+	digestB, err := digest.Path(b)
+	return err == nil && digestA == digestB
+}
+
+// Print writes the plan in a human readable form
+func Print(cfg *types.Config, plan *Plan) {
+	fmt.Printf("Import plan from %s (%d of %d item(s)):\n", plan.Tool, plan.Count(), len(plan.Items))
+
+	for _, item := range plan.Items {
+		target := item.Target
+		if rel, err := config.RelativeToHome(cfg, item.Target); err == nil {
+			target = "$HOME/" + rel
+		}
+
+		if item.Skip != "" {
+			fmt.Printf("  skip     %s (%s)\n", target, item.Skip)
+			continue
+		}
+
+		action := "copy"
+		if item.Move {
+			action = "move"
+		}
+		note := ""
+		if item.Mode != 0 {
+			note = fmt.Sprintf(" [mode %04o]", item.Mode)
+		}
+		if item.DirMode != 0 {
+			note += fmt.Sprintf(" [directory mode %04o]", item.DirMode)
+		}
+		if item.Replace {
+			note += " [backup and replace existing target]"
+		}
+
+		if item.Move {
+			fmt.Printf("  %-8s %s%s\n", action, target, note)
+		} else {
+			fmt.Printf("  %-8s %s <- %s%s\n", action, target, item.Source, note)
+		}
+	}
+}
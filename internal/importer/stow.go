@@ -0,0 +1,189 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// StowIgnoreFile lists per-package ignore patterns, as in GNU Stow
+const StowIgnoreFile = ".stow-local-ignore"
+
+// defaultStowIgnore is GNU Stow's built-in ignore list, used when a package
+// has no .stow-local-ignore
+var defaultStowIgnore = []string{
+	`RCS`, `.+,v`, `CVS`, `\.\#.+`, `\.cvsignore`, `\.svn`, `_darcs`, `\.hg`,
+	`\.git`, `\.gitignore`, `\.gitmodules`, `.+~`, `\#.*\#`,
+	`^/README.*`, `^/LICENSE.*`, `^/COPYING`,
+}
+
+// Stow plans the import of GNU Stow packages. Every package directory in
+// stowDir mirrors targetDir; packages limits the import to the named ones.
+// With dotfiles set, "dot-" name prefixes are translated like stow --dotfiles.
+// Directories that stow folded into a single symlink are imported as one
+// directory entry, everything else file by file.
+func Stow(stowDir, targetDir string, packages []string, dotfiles bool) (*Plan, error) {
+	if !fileops.IsDirectory(stowDir) {
+		return nil, fmt.Errorf("stow directory does not exist: %s", stowDir)
+	}
+
+	if len(packages) == 0 {
+		entries, err := os.ReadDir(stowDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stow directory: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+				packages = append(packages, entry.Name())
+			}
+		}
+	}
+
+	plan := &Plan{Tool: "stow"}
+	for _, pkg := range packages {
+		pkgDir := filepath.Join(stowDir, pkg)
+		if !fileops.IsDirectory(pkgDir) {
+			return nil, fmt.Errorf("stow package does not exist: %s", pkgDir)
+		}
+
+		ignore, err := stowIgnorePatterns(pkgDir)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := planStowDir(plan, pkgDir, "", targetDir, ignore, dotfiles); err != nil {
+			return nil, err
+		}
+	}
+
+	return plan, nil
+}
+
+// planStowDir adds the items of one package directory, relative to the
+// package root
+func planStowDir(plan *Plan, pkgDir, rel, targetDir string, ignore []*regexp.Regexp, dotfiles bool) error {
+	entries, err := os.ReadDir(filepath.Join(pkgDir, rel))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", filepath.Join(pkgDir, rel), err)
+	}
+
+	for _, entry := range entries {
+		entryRel := filepath.Join(rel, entry.Name())
+		if rel == "" && entry.Name() == StowIgnoreFile {
+			continue
+		}
+		if stowIgnored(ignore, entryRel) {
+			continue
+		}
+
+		source := filepath.Join(pkgDir, entryRel)
+		target := filepath.Join(targetDir, stowTargetPath(entryRel, dotfiles))
+
+		if entry.IsDir() {
+			// A folded directory is a single link to the package directory
+			if linksTo(target, source) {
+				plan.Items = append(plan.Items, Item{Target: target, Source: source, Type: types.FileTypeDirectory})
+				continue
+			}
+			if err := planStowDir(plan, pkgDir, entryRel, targetDir, ignore, dotfiles); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if entry.Type()&os.ModeSymlink != 0 {
+			plan.skip(target, source, "symlink in package")
+			continue
+		}
+
+		plan.Items = append(plan.Items, Item{Target: target, Source: source, Type: types.FileTypeFile})
+	}
+
+	return nil
+}
+
+// stowTargetPath translates "dot-" prefixes like stow --dotfiles
+func stowTargetPath(rel string, dotfiles bool) string {
+	if !dotfiles {
+		return rel
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+	for i, part := range parts {
+		if strings.HasPrefix(part, "dot-") {
+			parts[i] = "." + strings.TrimPrefix(part, "dot-")
+		}
+	}
+	return filepath.Join(parts...)
+}
+
+// stowIgnorePatterns reads the package's .stow-local-ignore, falling back to
+// stow's default list
+func stowIgnorePatterns(pkgDir string) ([]*regexp.Regexp, error) {
+	lines := defaultStowIgnore
+
+	if f, err := os.Open(filepath.Join(pkgDir, StowIgnoreFile)); err == nil {
+		defer f.Close()
+		lines = nil
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" && !strings.HasPrefix(line, "#") {
+				lines = append(lines, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", StowIgnoreFile, err)
+		}
+	}
+
+	var patterns []*regexp.Regexp
+	for _, line := range lines {
+		// Patterns anchored with ^/ match the path from the package root,
+		// others match a single name
+		expr := "^(" + line + ")$"
+		if strings.HasPrefix(line, "^/") {
+			expr = "^/(" + strings.TrimPrefix(line, "^/") + ")$"
+		}
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern %q in %s: %w", line, pkgDir, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	return patterns, nil
+}
+
+// stowIgnored reports whether a package-relative path matches an ignore pattern
+func stowIgnored(patterns []*regexp.Regexp, rel string) bool {
+	slashPath := "/" + filepath.ToSlash(rel)
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern.String(), "^/") {
+			if pattern.MatchString(slashPath) {
+				return true
+			}
+		} else if pattern.MatchString(filepath.Base(rel)) {
+			return true
+		}
+	}
+	return false
+}
+
+// linksTo reports whether path is a symlink resolving to target
+func linksTo(path, target string) bool {
+	if !fileops.IsSymlink(path) {
+		return false
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	want, err := filepath.EvalSymlinks(target)
+	return err == nil && resolved == want
+}
@@ -0,0 +1,72 @@
+package index
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// ManagedDirectories returns the original paths of all directory entries
+func ManagedDirectories(idx *types.Index) []string {
+	var dirs []string
+	for _, file := range GetAllFiles(idx) {
+		if file.Type == types.FileTypeDirectory {
+			dirs = append(dirs, file.OriginalPath)
+		}
+	}
+	return dirs
+}
+
+// LinkedDirectories returns the directory entries that are linked as a
+// whole, leaving out partial directories
+func LinkedDirectories(idx *types.Index) []string {
+	var dirs []string
+	for _, file := range GetAllFiles(idx) {
+		if file.Type == types.FileTypeDirectory && file.Partial == nil {
+			dirs = append(dirs, file.OriginalPath)
+		}
+	}
+	return dirs
+}
+
+// IsWithinDirectory checks if a path is below any of the directories
+func IsWithinDirectory(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		// Check if the path starts with the directory path followed by a separator
+		if strings.HasPrefix(path, dir+"/") || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainingDirectory returns the outermost directory entry that contains
+// path, which is the one linked in $HOME
+func ContainingDirectory(idx *types.Index, path string) (*types.ManagedFile, bool) {
+	var outer *types.ManagedFile
+	for i, file := range idx.ManagedFiles {
+		if file.Type != types.FileTypeDirectory || file.Glob != nil {
+			continue
+		}
+		if !IsWithinDirectory(path, []string{file.OriginalPath}) {
+			continue
+		}
+		if outer == nil || len(file.OriginalPath) < len(outer.OriginalPath) {
+			outer = &idx.ManagedFiles[i]
+		}
+	}
+	return outer, outer != nil
+}
+
+// Children returns the entries below a directory, the inverse of
+// IsWithinDirectory
+func Children(idx *types.Index, dir string) []types.ManagedFile {
+	var children []types.ManagedFile
+	for _, file := range GetAllFiles(idx) {
+		if IsWithinDirectory(file.OriginalPath, []string{dir}) {
+			children = append(children, file)
+		}
+	}
+	return children
+}
@@ -3,14 +3,21 @@ package index
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/digest"
+	"github.com/Merith-TK/dotman/internal/logging"
 	"github.com/Merith-TK/dotman/pkg/types"
 )
 
 // Load reads and parses the index.json file
 func Load(indexPath string) (*types.Index, error) {
+	start := time.Now()
 	data, err := os.ReadFile(indexPath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -28,11 +35,13 @@ func Load(indexPath string) (*types.Index, error) {
 		return nil, fmt.Errorf("failed to parse index file: %w", err)
 	}
 
+	logging.Done(slog.LevelDebug, "load index", start, nil, "path", indexPath, "entries", len(index.ManagedFiles))
 	return &index, nil
 }
 
 // Save writes the index to the index.json file
 func Save(index *types.Index, indexPath string) error {
+	start := time.Now()
 	data, err := json.MarshalIndent(index, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal index: %w", err)
@@ -42,6 +51,7 @@ func Save(index *types.Index, indexPath string) error {
 		return fmt.Errorf("failed to write index file: %w", err)
 	}
 
+	logging.Done(slog.LevelInfo, "save index", start, nil, "path", indexPath, "entries", len(index.ManagedFiles))
 	return nil
 }
 
@@ -79,6 +89,82 @@ func FindFile(idx *types.Index, originalPath string) (*types.ManagedFile, bool)
 	return nil, false
 }
 
+// FindByRepoPath finds a managed file by its path within the repo
+func FindByRepoPath(idx *types.Index, repoPath string) (*types.ManagedFile, bool) {
+	for _, file := range idx.ManagedFiles {
+		if file.RepoPath == repoPath {
+			return &file, true
+		}
+	}
+	return nil, false
+}
+
+// AddLayer records that an overlay layer holds a copy of a managed file
+func AddLayer(idx *types.Index, originalPath, layer string) bool {
+	for i, file := range idx.ManagedFiles {
+		if file.OriginalPath != originalPath {
+			continue
+		}
+		for _, existing := range file.Layers {
+			if existing == layer {
+				return true
+			}
+		}
+		idx.ManagedFiles[i].Layers = append(idx.ManagedFiles[i].Layers, layer)
+		return true
+	}
+	return false
+}
+
+// RemoveLayer records that an overlay layer no longer holds a copy of a
+// managed file
+func RemoveLayer(idx *types.Index, originalPath, layer string) bool {
+	for i, file := range idx.ManagedFiles {
+		if file.OriginalPath != originalPath {
+			continue
+		}
+		var kept []string
+		for _, existing := range file.Layers {
+			if existing != layer {
+				kept = append(kept, existing)
+			}
+		}
+		idx.ManagedFiles[i].Layers = kept
+		return true
+	}
+	return false
+}
+
+// MoveFile changes the original and repo paths of an entry, and of entries
+// nested below it when it is a directory. It returns the number of entries
+// changed.
+func MoveFile(idx *types.Index, oldOriginal, newOriginal, oldRepo, newRepo string) int {
+	moved := 0
+	for i, file := range idx.ManagedFiles {
+		original, ok := rebasePath(file.OriginalPath, oldOriginal, newOriginal)
+		if !ok {
+			continue
+		}
+		idx.ManagedFiles[i].OriginalPath = original
+		if repo, ok := rebasePath(file.RepoPath, oldRepo, newRepo); ok {
+			idx.ManagedFiles[i].RepoPath = repo
+		}
+		moved++
+	}
+	return moved
+}
+
+// rebasePath replaces the oldBase prefix of path with newBase
+func rebasePath(path, oldBase, newBase string) (string, bool) {
+	if path == oldBase {
+		return newBase, true
+	}
+	if strings.HasPrefix(path, oldBase+string(filepath.Separator)) {
+		return newBase + strings.TrimPrefix(path, oldBase), true
+	}
+	return "", false
+}
+
 // IsManaged checks if a path is already managed
 func IsManaged(idx *types.Index, originalPath string) bool {
 	_, found := FindFile(idx, originalPath)
@@ -94,3 +180,51 @@ func GetAllFiles(idx *types.Index) []types.ManagedFile {
 func Count(idx *types.Index) int {
 	return len(idx.ManagedFiles)
 }
+
+// UpdateDigests records the current content digest of every entry whose repo
+// content exists, in the base layer and in each overlay layer
+func UpdateDigests(idx *types.Index, repoDir string) {
+	RefreshDigests(idx, repoDir, func(types.ManagedFile) bool { return true })
+}
+
+// RefreshDigests records the current content digests of the entries for which
+// touched returns true and of the repo copies that have no digest yet. It
+// returns the repo-relative paths of the other copies whose content no longer
+// matches their digest; those keep the digest so fsck still reports them.
+func RefreshDigests(idx *types.Index, repoDir string, touched func(types.ManagedFile) bool) []string {
+	var stale []string
+	for i := range idx.ManagedFiles {
+		file := &idx.ManagedFiles[i]
+		update := touched(*file)
+
+		if sum, err := digest.Path(filepath.Join(repoDir, file.RepoPath)); err == nil {
+			switch {
+			case update || file.Digest == "":
+				file.Digest = sum
+			case file.Digest != sum:
+				stale = append(stale, file.RepoPath)
+			}
+		}
+
+		recorded := file.LayerDigests
+		file.LayerDigests = nil
+		for _, layer := range file.Layers {
+			layerPath := config.LayerPath(layer, file.RepoPath)
+			sum, err := digest.Path(filepath.Join(repoDir, layerPath))
+			if err != nil {
+				continue
+			}
+			if !update && recorded[layer] != "" {
+				if recorded[layer] != sum {
+					stale = append(stale, layerPath)
+				}
+				sum = recorded[layer]
+			}
+			if file.LayerDigests == nil {
+				file.LayerDigests = make(map[string]string)
+			}
+			file.LayerDigests[layer] = sum
+		}
+	}
+	return stale
+}
@@ -0,0 +1,295 @@
+package installscript
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/pkg/dotman"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// Entry is one deployed index entry. Paths are relative so the script works
+// for any $HOME and repo location.
+type Entry struct {
+	// Source is the repo-relative path of the content, layer included
+	Source string
+	// Target is the home-relative deploy location of a plain entry
+	Target string
+	// Glob is set for entries whose targets are discovered at install time
+	Glob *types.GlobTarget
+	// Mode is the permission of a file entry; 0 for directories
+	Mode os.FileMode
+}
+
+// Script describes a generated install script
+type Script struct {
+	// RepoDir is the default repository location relative to $HOME
+	RepoDir string
+	Profile string
+	// Copy makes copies instead of symlinks by default
+	Copy    bool
+	Entries []Entry
+	// Skipped lists messages for entries that are not deployed
+	Skipped []string
+}
+
+const header = `#!/bin/sh
+# Generated by dotman from index.json. Do not edit; run
+# 'dotman generate install-script' again after changing the index.
+#
+# Deploys the dotfiles in $DOTMAN_REPO without dotman. Existing files in the
+# way are moved to <name>.backup. Set DOTMAN_INSTALL_MODE=copy to copy files
+# instead of linking them. run_once_/run_onchange_ scripts are not run.
+# Exits 1 if an entry can't be deployed.
+set -eu
+
+DOTMAN_HOME="${DOTMAN_HOME:-$HOME}"
+`
+
+const functions = `
+export DOTMAN_REPO DOTMAN_HOME DOTMAN_PROFILE
+
+if [ ! -d "$DOTMAN_REPO" ]; then
+	echo "dotman repo not found: $DOTMAN_REPO" >&2
+	exit 1
+fi
+
+# failed is set when an entry can't be deployed; the script exits 1 at the end
+failed=0
+
+# run_hook <name> runs a hook from the repo root if it exists. Like dotman,
+# it may be named exactly (pre-deploy) or carry an extension (pre-deploy.sh).
+run_hook() {
+	for hook in "$DOTMAN_REPO/.dotman/hooks/"*; do
+		[ -f "$hook" ] || continue
+		base="$(basename "$hook")"
+		if [ "$base" = "$1" ] || [ "${base%.*}" = "$1" ]; then
+			echo "Running $1 hook..."
+			if [ -x "$hook" ]; then
+				(cd "$DOTMAN_REPO" && "$hook")
+			else
+				(cd "$DOTMAN_REPO" && sh "$hook")
+			fi
+			return
+		fi
+	done
+}
+
+# install_entry <repo path> <target> [mode] links or copies one target; the
+# mode applies to copies
+install_entry() {
+	src="$DOTMAN_REPO/$1"
+	dst="$2"
+	mode="${3:-}"
+
+	if [ ! -e "$src" ]; then
+		echo "Warning: repo file missing for $dst"
+		return 0
+	fi
+
+	if [ -L "$dst" ] && [ "$(readlink "$dst")" = "$src" ]; then
+		echo "Skipping $dst (symlink already exists)"
+		return 0
+	fi
+	if [ "$DOTMAN_INSTALL_MODE" = copy ] && [ -f "$dst" ] && [ ! -L "$dst" ] && cmp -s "$src" "$dst"; then
+		echo "Skipping $dst (already up to date)"
+		return 0
+	fi
+
+	if [ -e "$dst" ] || [ -L "$dst" ]; then
+		rm -rf "$dst.backup"
+		mv "$dst" "$dst.backup"
+		echo "Backed up $dst to $dst.backup"
+	fi
+
+	mkdir -p "$(dirname "$dst")"
+	if [ "$DOTMAN_INSTALL_MODE" = copy ]; then
+		cp -R "$src" "$dst"
+		if [ -n "$mode" ]; then
+			chmod "$mode" "$dst"
+		fi
+	else
+		ln -s "$src" "$dst"
+	fi
+	echo "Deployed $dst"
+}
+
+# A failing pre-deploy hook aborts the deployment
+run_hook pre-deploy
+`
+
+// FromPlan converts a deploy plan of the repository described by cfg into a
+// script, with paths relative to the repo and home directory
+func FromPlan(cfg *types.Config, steps []dotman.DeployStep, copyFiles bool) (*Script, error) {
+	repoDir, err := config.RelativeToHome(cfg, cfg.DotmanDir)
+	if err != nil || !config.IsInsideHome(cfg, cfg.DotmanDir) {
+		return nil, types.Errorf(types.ErrOutsideHome, "dotman directory must be inside the home directory: %s", cfg.DotmanDir)
+	}
+
+	script := &Script{
+		RepoDir: repoDir,
+		Profile: cfg.Profile,
+		Copy:    copyFiles,
+	}
+
+	for _, step := range steps {
+		if step.Skip != "" {
+			script.Skipped = append(script.Skipped, step.Skip)
+			continue
+		}
+
+		source, err := filepath.Rel(cfg.DotmanDir, step.Source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get repo path of %s: %w", step.Source, err)
+		}
+		entry := Entry{Source: source, Glob: step.File.Glob}
+
+		if step.File.Glob == nil {
+			entry.Target, err = config.RelativeToHome(cfg, step.File.OriginalPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get relative path: %w", err)
+			}
+		}
+
+		if info, err := os.Stat(step.Source); err == nil && !info.IsDir() {
+			entry.Mode = info.Mode().Perm()
+		}
+
+		script.Entries = append(script.Entries, entry)
+	}
+
+	return script, nil
+}
+
+// Write writes the install script
+func Write(w io.Writer, script *Script) error {
+	var b strings.Builder
+
+	b.WriteString(header)
+	fmt.Fprintf(&b, "DOTMAN_REPO=\"${DOTMAN_REPO:-$HOME/%s}\"\n", escapeDouble(script.RepoDir))
+	fmt.Fprintf(&b, "DOTMAN_PROFILE=\"${DOTMAN_PROFILE:-%s}\"\n", escapeDouble(script.Profile))
+	mode := "link"
+	if script.Copy {
+		mode = "copy"
+	}
+	fmt.Fprintf(&b, "DOTMAN_INSTALL_MODE=\"${DOTMAN_INSTALL_MODE:-%s}\"\n", mode)
+
+	b.WriteString(functions)
+
+	for _, message := range script.Skipped {
+		fmt.Fprintf(&b, "\n# %s\n", message)
+	}
+
+	for _, entry := range script.Entries {
+		b.WriteString("\n")
+		if entry.Glob != nil {
+			writeGlobEntry(&b, entry)
+			continue
+		}
+		fmt.Fprintf(&b, "install_entry %s \"$DOTMAN_HOME\"/%s%s\n", Quote(entry.Source), Quote(entry.Target), modeArg(entry.Mode))
+	}
+
+	b.WriteString("\nrun_hook post-deploy || echo \"Warning: post-deploy hook failed\"\n")
+	b.WriteString("\necho \"Deployment complete.\"\n")
+	b.WriteString("[ \"$failed\" = 0 ] || exit 1\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeGlobEntry emits a loop that resolves a glob entry at install time the
+// same way deploy does: the pattern up to its last wildcard component must
+// match existing paths, the remainder is created below each match
+func writeGlobEntry(b *strings.Builder, entry Entry) {
+	parts := strings.Split(filepath.ToSlash(filepath.Clean(entry.Glob.Pattern)), "/")
+	last := 0
+	for i, part := range parts {
+		if strings.ContainsAny(part, "*?[") {
+			last = i
+		}
+	}
+	prefix := strings.Join(parts[:last+1], "/")
+	rest := strings.Join(parts[last+1:], "/")
+
+	fmt.Fprintf(b, "# $HOME/%s (%s)\n", entry.Glob.Pattern, entry.Glob.Policy)
+	b.WriteString("found=0\n")
+	fmt.Fprintf(b, "for match in \"$DOTMAN_HOME\"/%s; do\n", QuoteGlob(prefix))
+
+	target := "\"$match\""
+	if rest != "" {
+		b.WriteString("\t[ -d \"$match\" ] || continue\n")
+		target = "\"$match\"/" + Quote(rest)
+	} else {
+		b.WriteString("\t[ -e \"$match\" ] || [ -L \"$match\" ] || continue\n")
+	}
+	fmt.Fprintf(b, "\tinstall_entry %s %s%s\n", Quote(entry.Source), target, modeArg(entry.Mode))
+	b.WriteString("\tfound=1\n")
+	if entry.Glob.Policy == types.MatchFirst {
+		b.WriteString("\tbreak\n")
+	}
+	b.WriteString("done\n")
+
+	if entry.Glob.Policy == types.MatchFailIfNone {
+		fmt.Fprintf(b, "if [ \"$found\" = 0 ]; then\n\techo \"Error resolving targets for $DOTMAN_HOME/%s: no targets match\" >&2\n\tfailed=1\nfi\n", escapeDouble(entry.Glob.Pattern))
+	} else {
+		fmt.Fprintf(b, "[ \"$found\" = 1 ] || echo \"Skipping $DOTMAN_HOME/%s (no matching targets)\"\n", escapeDouble(entry.Glob.Pattern))
+	}
+}
+
+// modeArg returns the optional mode argument of install_entry
+func modeArg(mode os.FileMode) string {
+	if mode == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" %04o", mode.Perm())
+}
+
+// Quote quotes a string for the shell
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// QuoteGlob quotes a glob pattern for the shell, leaving the wildcard
+// characters and bracket expressions active
+func QuoteGlob(pattern string) string {
+	var b strings.Builder
+	literal := ""
+	flush := func() {
+		if literal != "" {
+			b.WriteString(Quote(literal))
+			literal = ""
+		}
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*', '?':
+			flush()
+			b.WriteByte(c)
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				literal += "["
+				continue
+			}
+			flush()
+			b.WriteString(pattern[i : i+end+2])
+			i += end + 1
+		default:
+			literal += string(c)
+		}
+	}
+	flush()
+
+	return b.String()
+}
+
+// escapeDouble escapes a string for use inside double quotes
+func escapeDouble(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+	return replacer.Replace(s)
+}
@@ -0,0 +1,192 @@
+package installscript
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Merith-TK/dotman/pkg/dotman"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// testRepo creates a repository in a temporary home directory with an entry
+// of every kind the install script handles
+func testRepo(t *testing.T) (*types.Config, *types.Index) {
+	t.Helper()
+	home := t.TempDir()
+	cfg := &types.Config{
+		HomeDir:   home,
+		DotmanDir: filepath.Join(home, ".dotman"),
+		IndexFile: filepath.Join(home, ".dotman", "index.json"),
+		StateDir:  filepath.Join(home, ".local", "state", "dotman"),
+		ConfigDir: filepath.Join(home, ".config", "dotman"),
+		Profile:   "default",
+		Hostname:  "testhost",
+	}
+
+	files := map[string]os.FileMode{
+		".bashrc":               0644,
+		".ssh/config":           0600,
+		".config/nvim/init.lua": 0644,
+		".gitconfig":            0644,
+		".dotman/layers/hosts/testhost/.gitconfig": 0644,
+		".dotman/layers/hosts/other/.profile":      0644,
+		".mozilla/firefox/chrome/userChrome.css":   0644,
+		".app/settings.json":                       0644,
+	}
+	for path, mode := range files {
+		full := filepath.Join(cfg.DotmanDir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(path+"\n"), mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(full, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entry := func(repoPath string, fileType types.FileType) types.ManagedFile {
+		return types.ManagedFile{OriginalPath: filepath.Join(home, repoPath), RepoPath: repoPath, Type: fileType}
+	}
+	idx := &types.Index{Version: "1.0"}
+	idx.ManagedFiles = append(idx.ManagedFiles,
+		entry(".bashrc", types.FileTypeFile),
+		entry(".ssh/config", types.FileTypeFile),
+		entry(".config/nvim", types.FileTypeDirectory),
+		entry(".missing", types.FileTypeFile),
+	)
+
+	layered := entry(".gitconfig", types.FileTypeFile)
+	layered.Layers = []string{"hosts/testhost"}
+	otherHost := entry(".profile", types.FileTypeFile)
+	otherHost.Layers = []string{"hosts/other"}
+
+	firstMatch := entry(".mozilla/firefox/chrome/userChrome.css", types.FileTypeFile)
+	firstMatch.OriginalPath = filepath.Join(home, ".mozilla/firefox/*.default-release/chrome/userChrome.css")
+	firstMatch.Glob = &types.GlobTarget{Pattern: ".mozilla/firefox/*.default-release/chrome/userChrome.css", Policy: types.MatchFirst}
+
+	failIfNone := entry(".app/settings.json", types.FileTypeFile)
+	failIfNone.OriginalPath = filepath.Join(home, ".app/*/settings.json")
+	failIfNone.Glob = &types.GlobTarget{Pattern: ".app/*/settings.json", Policy: types.MatchFailIfNone}
+
+	idx.ManagedFiles = append(idx.ManagedFiles, layered, otherHost, firstMatch, failIfNone)
+	return cfg, idx
+}
+
+// generate writes the install script for the deploy plan of the repo
+func generate(t *testing.T, cfg *types.Config, idx *types.Index, copyFiles bool) []byte {
+	t.Helper()
+	script, err := FromPlan(cfg, dotman.New(cfg).PlanDeploy(idx), copyFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, script); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGolden(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		copyFiles bool
+	}{
+		{"link", false},
+		{"copy", true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cfg, idx := testRepo(t)
+			// Skip messages name absolute paths
+			got := bytes.ReplaceAll(generate(t, cfg, idx, test.copyFiles), []byte(cfg.HomeDir), []byte("/home/user"))
+
+			golden := filepath.Join("testdata", test.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("script differs from %s (run go test -update to accept it):\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestScriptDeploys(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	cfg, idx := testRepo(t)
+	scriptPath := filepath.Join(t.TempDir(), "install.sh")
+	if err := os.WriteFile(scriptPath, generate(t, cfg, idx, false), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Hooks are found with an extension, like dotman does
+	hooksDir := filepath.Join(cfg.DotmanDir, ".dotman", "hooks")
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	hook := "echo ran >> \"$DOTMAN_HOME/hook.log\"\n"
+	if err := os.WriteFile(filepath.Join(hooksDir, "pre-deploy.sh"), []byte(hook), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Link mode leaves the mode of repo copies alone
+	sshConfig := filepath.Join(cfg.DotmanDir, ".ssh", "config")
+	if err := os.Chmod(sshConfig, 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func() error {
+		cmd := exec.Command("sh", scriptPath)
+		cmd.Env = append(os.Environ(), "HOME="+cfg.HomeDir, "DOTMAN_HOME="+cfg.HomeDir)
+		output, err := cmd.CombinedOutput()
+		t.Logf("%s", output)
+		return err
+	}
+
+	// No target matches the fail-if-none entry
+	var exitErr *exec.ExitError
+	if err := run(); !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		t.Fatalf("script with an unmatched fail-if-none entry: %v, want exit status 1", err)
+	}
+
+	if link, err := os.Readlink(filepath.Join(cfg.HomeDir, ".gitconfig")); err != nil || link != filepath.Join(cfg.DotmanDir, ".dotman/layers/hosts/testhost/.gitconfig") {
+		t.Errorf(".gitconfig links to %q (%v), want the host layer copy", link, err)
+	}
+	if _, err := os.Lstat(filepath.Join(cfg.HomeDir, ".profile")); !os.IsNotExist(err) {
+		t.Errorf(".profile of another host was deployed")
+	}
+	if info, err := os.Stat(sshConfig); err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("repo copy of .ssh/config has mode %v (%v), want it unchanged", info.Mode().Perm(), err)
+	}
+	if log, _ := os.ReadFile(filepath.Join(cfg.HomeDir, "hook.log")); strings.Count(string(log), "ran") != 1 {
+		t.Errorf("pre-deploy.sh ran %d time(s), want once", strings.Count(string(log), "ran"))
+	}
+
+	if err := os.MkdirAll(filepath.Join(cfg.HomeDir, ".app", "profile"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(); err != nil {
+		t.Fatalf("script with every entry deployable: %v", err)
+	}
+	if _, err := os.Readlink(filepath.Join(cfg.HomeDir, ".app", "profile", "settings.json")); err != nil {
+		t.Errorf("fail-if-none entry not deployed: %v", err)
+	}
+}
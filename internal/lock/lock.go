@@ -0,0 +1,141 @@
+package lock
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+const (
+	LockFileName  = "dotman.lock"
+	WatchFileName = "watch.lock"
+)
+
+// Info describes the process holding the lock
+type Info struct {
+	PID     int
+	Command string
+	Since   time.Time
+}
+
+// Path returns the path of the machine-local lock file
+func Path(cfg *types.Config) string {
+	return filepath.Join(cfg.StateDir, LockFileName)
+}
+
+// WatchPath returns the path of the lock held by a running 'dotman watch'
+func WatchPath(cfg *types.Config) string {
+	return filepath.Join(cfg.StateDir, WatchFileName)
+}
+
+// Acquire takes the dotman lock for this process. A lock left behind by a
+// process that no longer exists is treated as stale and replaced.
+func Acquire(cfg *types.Config, command string) error {
+	return acquire(cfg, Path(cfg), command)
+}
+
+// AcquireWatch marks this process as the watcher of the repo; only one
+// watcher runs at a time. Unlike the dotman lock it is held until exit.
+func AcquireWatch(cfg *types.Config) error {
+	return acquire(cfg, WatchPath(cfg), "watch")
+}
+
+func acquire(cfg *types.Config, path, command string) error {
+	if err := config.EnsureStateDir(cfg); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n%s\n%s\n", os.Getpid(), command, time.Now().Format(time.RFC3339))
+			return f.Close()
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		info, err := readFile(path)
+		if err == nil && IsAlive(info.PID) {
+			return fmt.Errorf("another dotman process is running (pid %d: %s)", info.PID, info.Command)
+		}
+
+		// Stale or unreadable lock
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove stale lock: %w", err)
+		}
+	}
+
+	return fmt.Errorf("failed to acquire lock: %s", path)
+}
+
+// Release removes the lock if it is held by this process
+func Release(cfg *types.Config) error {
+	return release(Path(cfg))
+}
+
+// ReleaseWatch removes the watch lock if it is held by this process
+func ReleaseWatch(cfg *types.Config) error {
+	return release(WatchPath(cfg))
+}
+
+func release(path string) error {
+	info, err := readFile(path)
+	if err != nil || info.PID != os.Getpid() {
+		return nil
+	}
+	return os.Remove(path)
+}
+
+// Read returns information about the current lock holder
+func Read(cfg *types.Config) (*Info, error) {
+	return readFile(Path(cfg))
+}
+
+// ReadWatch returns information about the running watcher
+func ReadWatch(cfg *types.Config) (*Info, error) {
+	return readFile(WatchPath(cfg))
+}
+
+func readFile(path string) (*Info, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(string(data), "\n")
+	pid, err := strconv.Atoi(strings.TrimSpace(lines[0]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid lock file: %w", err)
+	}
+
+	info := &Info{PID: pid}
+	if len(lines) > 1 {
+		info.Command = strings.TrimSpace(lines[1])
+	}
+	if len(lines) > 2 {
+		info.Since, _ = time.Parse(time.RFC3339, strings.TrimSpace(lines[2]))
+	}
+
+	return info, nil
+}
+
+// IsAlive reports whether a process with the given pid exists
+func IsAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
@@ -0,0 +1,138 @@
+// Package logging configures the log/slog default logger used by dotman's
+// internal packages. Log records go to stderr and, optionally, to a JSON
+// log file; the normal command output on stdout is not affected.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// LevelEnv selects the stderr log level (debug, info, warn, error)
+	LevelEnv = "DOTMAN_LOG"
+	// FileEnv names a file that receives every record as JSON
+	FileEnv = "DOTMAN_LOG_FILE"
+)
+
+// Options configures Setup
+type Options struct {
+	// Level is the minimum level written to stderr
+	Level slog.Level
+	// File, if set, receives every record down to debug level as JSON
+	File string
+}
+
+// ParseLevel parses a level name as used in DOTMAN_LOG
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q; use debug, info, warn or error", name)
+	}
+	return level, nil
+}
+
+// Setup installs the default logger. The returned function closes the log
+// file.
+func Setup(opts Options) (func() error, error) {
+	handlers := []slog.Handler{
+		slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+			Level: opts.Level,
+			// Timestamps only clutter the terminal
+			ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+				if len(groups) == 0 && attr.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return attr
+			},
+		}),
+	}
+
+	closeFile := func() error { return nil }
+	if opts.File != "" {
+		if err := os.MkdirAll(filepath.Dir(opts.File), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create log directory: %w", err)
+		}
+		file, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		handlers = append(handlers, slog.NewJSONHandler(file, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		closeFile = file.Close
+	}
+
+	slog.SetDefault(slog.New(teeHandler(handlers)))
+	return closeFile, nil
+}
+
+// Done logs an operation that started at start, with its duration and error
+func Done(level slog.Level, msg string, start time.Time, err error, args ...any) {
+	args = append(args, "duration", time.Since(start))
+	if err != nil {
+		args = append(args, "error", err)
+	}
+	slog.Log(context.Background(), level, msg, args...)
+}
+
+// Command runs an external command through run (cmd.Run, cmd.Output or
+// cmd.CombinedOutput) and logs it. Output is only logged at debug level.
+func Command(cmd *exec.Cmd, run func() ([]byte, error)) ([]byte, error) {
+	start := time.Now()
+	output, err := run()
+
+	args := []any{"args", cmd.Args[1:], "dir", cmd.Dir}
+	if slog.Default().Enabled(context.Background(), slog.LevelDebug) && len(output) > 0 {
+		args = append(args, "output", strings.TrimSpace(string(output)))
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		args = append(args, "exit_code", exitErr.ExitCode())
+	}
+	Done(slog.LevelInfo, filepath.Base(cmd.Path), start, err, args...)
+	return output, err
+}
+
+// teeHandler passes every record to each handler that accepts its level
+type teeHandler []slog.Handler
+
+func (t teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t teeHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, h := range t {
+		if h.Enabled(ctx, record.Level) {
+			errs = append(errs, h.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(teeHandler, len(t))
+	for i, h := range t {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	handlers := make(teeHandler, len(t))
+	for i, h := range t {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Merith-TK/dotman/internal/logging"
+)
+
+const (
+	// UnitDir is where systemd looks for user units, relative to $HOME
+	UnitDir = ".config/systemd/user"
+
+	SyncService  = "dotman-sync.service"
+	SyncTimer    = "dotman-sync.timer"
+	WatchService = "dotman-watch.service"
+)
+
+// Units lists every unit dotman generates
+var Units = []string{SyncService, SyncTimer, WatchService}
+
+// Unit is a generated unit file
+type Unit struct {
+	Name    string
+	Content string
+}
+
+// Options describe the units to generate
+type Options struct {
+	// Executable is the dotman binary as ExecStart should run it (e.g. %h/go/bin/dotman)
+	Executable string
+	// Interval is how often the sync timer fires
+	Interval time.Duration
+	// Push also pushes after pulling in the sync service
+	Push bool
+	// Watch generates the long-running watch service instead of the timer
+	Watch bool
+	// Debounce is passed to 'dotman watch'
+	Debounce time.Duration
+}
+
+const header = "# Generated by 'dotman service install'. Run it again instead of editing.\n"
+
+// Generate returns the unit files for the options. The last one is the unit
+// to enable.
+func Generate(opts Options) ([]Unit, error) {
+	if opts.Executable == "" {
+		return nil, fmt.Errorf("dotman executable is not set")
+	}
+
+	if opts.Watch {
+		if opts.Debounce <= 0 {
+			return nil, fmt.Errorf("debounce must be positive")
+		}
+		return []Unit{{Name: WatchService, Content: watchService(opts)}}, nil
+	}
+
+	if opts.Interval < time.Minute {
+		return nil, fmt.Errorf("sync interval must be at least 1m")
+	}
+	return []Unit{
+		{Name: SyncService, Content: syncService(opts)},
+		{Name: SyncTimer, Content: syncTimer(opts)},
+	}, nil
+}
+
+func syncService(opts Options) string {
+	var b strings.Builder
+	b.WriteString(header)
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=Sync the dotman repository\n")
+	b.WriteString("Wants=network-online.target\n")
+	b.WriteString("After=network-online.target\n")
+	b.WriteString("\n[Service]\n")
+	b.WriteString("Type=oneshot\n")
+	fmt.Fprintf(&b, "ExecStart=%s sync --pull\n", quoteExec(opts.Executable))
+	if opts.Push {
+		fmt.Fprintf(&b, "ExecStart=%s sync --push\n", quoteExec(opts.Executable))
+	}
+	return b.String()
+}
+
+func syncTimer(opts Options) string {
+	var b strings.Builder
+	b.WriteString(header)
+	b.WriteString("[Unit]\n")
+	fmt.Fprintf(&b, "Description=Sync the dotman repository every %s\n", FormatInterval(opts.Interval))
+	b.WriteString("\n[Timer]\n")
+	b.WriteString("OnBootSec=5min\n")
+	fmt.Fprintf(&b, "OnUnitActiveSec=%s\n", FormatInterval(opts.Interval))
+	fmt.Fprintf(&b, "Unit=%s\n", SyncService)
+	b.WriteString("\n[Install]\n")
+	b.WriteString("WantedBy=timers.target\n")
+	return b.String()
+}
+
+func watchService(opts Options) string {
+	var b strings.Builder
+	b.WriteString(header)
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=Commit edits to dotman managed files\n")
+	b.WriteString("\n[Service]\n")
+	fmt.Fprintf(&b, "ExecStart=%s watch --debounce %s\n", quoteExec(opts.Executable), opts.Debounce)
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=10\n")
+	b.WriteString("\n[Install]\n")
+	b.WriteString("WantedBy=default.target\n")
+	return b.String()
+}
+
+// FormatInterval formats a duration as a systemd time span (1h30m, not 1h30m0s)
+func FormatInterval(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// Executable returns how ExecStart should refer to a binary: below $HOME it
+// uses the %h specifier so the unit works for any home directory
+func Executable(path, homeDir string) string {
+	rel, err := filepath.Rel(homeDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return "%h/" + filepath.ToSlash(rel)
+}
+
+// quoteExec quotes an ExecStart command path containing spaces
+func quoteExec(path string) string {
+	if !strings.ContainsAny(path, " \t\"") {
+		return path
+	}
+	return `"` + strings.ReplaceAll(path, `"`, `\"`) + `"`
+}
+
+// Systemctl runs 'systemctl --user' and returns its trimmed output
+func Systemctl(args ...string) (string, error) {
+	cmd := exec.Command("systemctl", append([]string{"--user"}, args...)...)
+	output, err := logging.Command(cmd, cmd.CombinedOutput)
+	result := strings.TrimSpace(string(output))
+	if err != nil {
+		if result == "" {
+			return "", fmt.Errorf("systemctl --user %s failed: %w", strings.Join(args, " "), err)
+		}
+		return result, fmt.Errorf("systemctl --user %s failed: %s", strings.Join(args, " "), result)
+	}
+	return result, nil
+}
+
+// UnitState returns the one-word answer of 'systemctl --user is-enabled' or
+// 'is-active' for a unit, or "unknown" when systemctl couldn't tell
+func UnitState(query, name string) string {
+	// Both exit non-zero for disabled and inactive units
+	state, _ := Systemctl(query, name)
+	if state == "" || strings.ContainsAny(state, " \n") {
+		return "unknown"
+	}
+	return state
+}
+
+// Available reports whether systemctl can be run
+func Available() bool {
+	_, err := exec.LookPath("systemctl")
+	return err == nil
+}
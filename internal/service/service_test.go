@@ -0,0 +1,161 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// unitLines returns the lines of the named unit
+func unitLines(t *testing.T, units []Unit, name string) []string {
+	t.Helper()
+	for _, unit := range units {
+		if unit.Name == name {
+			return strings.Split(unit.Content, "\n")
+		}
+	}
+	t.Fatalf("no unit %s generated", name)
+	return nil
+}
+
+// hasLine reports whether lines has line
+func hasLine(lines []string, line string) bool {
+	for _, l := range lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGenerateTimer(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		want     string
+	}{
+		{time.Minute, "OnUnitActiveSec=1m"},
+		{15 * time.Minute, "OnUnitActiveSec=15m"},
+		{time.Hour, "OnUnitActiveSec=1h"},
+		{90 * time.Minute, "OnUnitActiveSec=1h30m"},
+	}
+	for _, test := range tests {
+		units, err := Generate(Options{Executable: "/usr/bin/dotman", Interval: test.interval})
+		if err != nil {
+			t.Fatalf("Generate(interval %s): %v", test.interval, err)
+		}
+		if len(units) != 2 || units[len(units)-1].Name != SyncTimer {
+			t.Fatalf("Generate(interval %s) = %d unit(s), want the service and the timer last", test.interval, len(units))
+		}
+
+		timer := unitLines(t, units, SyncTimer)
+		for _, line := range []string{test.want, "Unit=" + SyncService, "WantedBy=timers.target"} {
+			if !hasLine(timer, line) {
+				t.Errorf("timer for %s lacks %q:\n%s", test.interval, line, strings.Join(timer, "\n"))
+			}
+		}
+	}
+}
+
+func TestGenerateRejectsShortInterval(t *testing.T) {
+	if _, err := Generate(Options{Executable: "/usr/bin/dotman", Interval: 30 * time.Second}); err == nil {
+		t.Error("Generate accepted an interval below 1m")
+	}
+	if _, err := Generate(Options{Interval: time.Hour}); err == nil {
+		t.Error("Generate accepted an empty executable")
+	}
+}
+
+func TestGenerateSyncService(t *testing.T) {
+	units, err := Generate(Options{Executable: "%h/go/bin/dotman", Interval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := unitLines(t, units, SyncService)
+	for _, line := range []string{"Type=oneshot", "ExecStart=%h/go/bin/dotman sync --pull"} {
+		if !hasLine(service, line) {
+			t.Errorf("sync service lacks %q:\n%s", line, strings.Join(service, "\n"))
+		}
+	}
+	if hasLine(service, "ExecStart=%h/go/bin/dotman sync --push") {
+		t.Error("sync service pushes without Push")
+	}
+	if hasLine(service, "[Install]") {
+		t.Error("sync service has an [Install] section; the timer starts it")
+	}
+
+	units, err = Generate(Options{Executable: "%h/go/bin/dotman", Interval: time.Hour, Push: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service = unitLines(t, units, SyncService)
+	if !hasLine(service, "ExecStart=%h/go/bin/dotman sync --push") {
+		t.Errorf("sync service with Push doesn't push:\n%s", strings.Join(service, "\n"))
+	}
+}
+
+func TestGenerateWatch(t *testing.T) {
+	units, err := Generate(Options{Executable: "/usr/bin/dotman", Watch: true, Debounce: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(units) != 1 || units[0].Name != WatchService {
+		t.Fatalf("Generate with Watch = %v, want only %s", units, WatchService)
+	}
+	watch := unitLines(t, units, WatchService)
+	for _, line := range []string{"ExecStart=/usr/bin/dotman watch --debounce 5s", "Restart=on-failure", "WantedBy=default.target"} {
+		if !hasLine(watch, line) {
+			t.Errorf("watch service lacks %q:\n%s", line, strings.Join(watch, "\n"))
+		}
+	}
+	if hasLine(watch, "Type=oneshot") {
+		t.Error("watch service is a oneshot")
+	}
+
+	if _, err := Generate(Options{Executable: "/usr/bin/dotman", Watch: true}); err == nil {
+		t.Error("Generate accepted a watch service without a debounce")
+	}
+}
+
+func TestExecStartQuoting(t *testing.T) {
+	tests := []struct {
+		executable string
+		want       string
+	}{
+		{"/usr/bin/dotman", "ExecStart=/usr/bin/dotman sync --pull"},
+		{"%h/My Tools/dotman", `ExecStart="%h/My Tools/dotman" sync --pull`},
+		{"/opt/odd\"name/dotman", `ExecStart="/opt/odd\"name/dotman" sync --pull`},
+		{"/opt/tab\there/dotman", "ExecStart=\"/opt/tab\there/dotman\" sync --pull"},
+	}
+	for _, test := range tests {
+		units, err := Generate(Options{Executable: test.executable, Interval: time.Hour})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if service := unitLines(t, units, SyncService); !hasLine(service, test.want) {
+			t.Errorf("executable %q: sync service lacks %q:\n%s", test.executable, test.want, strings.Join(service, "\n"))
+		}
+	}
+
+	units, err := Generate(Options{Executable: "%h/My Tools/dotman", Watch: true, Debounce: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if watch := unitLines(t, units, WatchService); !hasLine(watch, `ExecStart="%h/My Tools/dotman" watch --debounce 1s`) {
+		t.Errorf("watch service doesn't quote the executable:\n%s", strings.Join(watch, "\n"))
+	}
+}
+
+func TestExecutable(t *testing.T) {
+	tests := []struct {
+		path, home, want string
+	}{
+		{"/home/me/go/bin/dotman", "/home/me", "%h/go/bin/dotman"},
+		{"/usr/local/bin/dotman", "/home/me", "/usr/local/bin/dotman"},
+		{"/home/me2/dotman", "/home/me", "/home/me2/dotman"},
+	}
+	for _, test := range tests {
+		if got := Executable(test.path, test.home); got != test.want {
+			t.Errorf("Executable(%q, %q) = %q, want %q", test.path, test.home, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+// Package ui is the interactive terminal interface of 'dotman ui'. The model
+// only reaches the repository through Actions, so it can be driven with key
+// messages and a fake Actions without a terminal.
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/Merith-TK/dotman/pkg/dotman"
+)
+
+// Actions are the operations the UI runs. Changes return the progress
+// messages reported while they ran.
+type Actions interface {
+	Status() (*dotman.StatusReport, error)
+	Diff(path string) (string, error)
+	Fix(paths []string, backup bool) ([]string, error)
+	Remove(paths []string) ([]string, error)
+	MoveToLayer(paths []string, layer string) ([]string, error)
+	Commit(message string) ([]string, error)
+}
+
+type mode int
+
+const (
+	modeList mode = iota
+	modeDiff
+	modeLayer
+	modeCommit
+	modeConfirmRemove
+)
+
+// Model is the state of the UI
+type Model struct {
+	actions Actions
+	// layers are the choices for moving entries to another layer
+	layers []string
+
+	report   *dotman.StatusReport
+	cursor   int
+	offset   int
+	selected map[string]bool
+
+	mode mode
+	// pending are the paths the layer or remove prompt acts on
+	pending     []string
+	diffPath    string
+	diff        []string
+	diffOffset  int
+	layerCursor int
+	input       string
+
+	busy     bool
+	messages []string
+	err      error
+
+	width, height int
+}
+
+// statusMsg, diffMsg and doneMsg deliver the results of Actions
+type statusMsg struct {
+	report *dotman.StatusReport
+	err    error
+}
+
+type diffMsg struct {
+	path string
+	diff string
+	err  error
+}
+
+type doneMsg struct {
+	messages []string
+	err      error
+}
+
+// New returns the model of a UI running actions; layers are offered when
+// moving entries to another layer
+func New(actions Actions, layers []string) Model {
+	return Model{
+		actions:  actions,
+		layers:   layers,
+		selected: map[string]bool{},
+		busy:     true,
+	}
+}
+
+// Run shows the UI in the terminal until the user quits
+func Run(actions Actions, layers []string) error {
+	_, err := tea.NewProgram(New(actions, layers), tea.WithAltScreen()).Run()
+	return err
+}
+
+// Init loads the status
+func (m Model) Init() tea.Cmd {
+	return m.load()
+}
+
+func (m Model) load() tea.Cmd {
+	actions := m.actions
+	return func() tea.Msg {
+		report, err := actions.Status()
+		return statusMsg{report: report, err: err}
+	}
+}
+
+// run starts a change in the background; the status is reloaded when it is
+// done
+func (m Model) run(change func() ([]string, error)) (Model, tea.Cmd) {
+	m.busy = true
+	m.mode = modeList
+	m.messages = nil
+	m.err = nil
+	return m, func() tea.Msg {
+		messages, err := change()
+		return doneMsg{messages: messages, err: err}
+	}
+}
+
+// Update handles a message
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.scroll()
+		return m, nil
+
+	case statusMsg:
+		m.busy = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.report = msg.report
+		m.keepSelection()
+		m.scroll()
+		return m, nil
+
+	case diffMsg:
+		m.busy = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.mode = modeDiff
+		m.diffPath = msg.path
+		m.diff = strings.Split(strings.TrimRight(msg.diff, "\n"), "\n")
+		if strings.TrimSpace(msg.diff) == "" {
+			m.diff = []string{"No changes."}
+		}
+		m.diffOffset = 0
+		return m, nil
+
+	case doneMsg:
+		m.messages = msg.messages
+		m.err = msg.err
+		m.selected = map[string]bool{}
+		return m, m.load()
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		switch m.mode {
+		case modeDiff:
+			return m.updateDiff(msg)
+		case modeLayer:
+			return m.updateLayer(msg)
+		case modeCommit:
+			return m.updateCommit(msg)
+		case modeConfirmRemove:
+			return m.updateConfirmRemove(msg)
+		}
+		return m.updateList(msg)
+	}
+	return m, nil
+}
+
+func (m Model) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	switch key {
+	case "q":
+		return m, tea.Quit
+	case "up", "k":
+		m.cursor--
+	case "down", "j":
+		m.cursor++
+	case "pgup":
+		m.cursor -= m.listHeight()
+	case "pgdown":
+		m.cursor += m.listHeight()
+	case "home", "g":
+		m.cursor = 0
+	case "end", "G":
+		m.cursor = len(m.entries()) - 1
+	}
+	m.scroll()
+
+	if m.busy {
+		return m, nil
+	}
+
+	entries := m.entries()
+	switch key {
+	case " ":
+		if len(entries) > 0 {
+			path := entries[m.cursor].File.OriginalPath
+			if m.selected[path] {
+				delete(m.selected, path)
+			} else {
+				m.selected[path] = true
+			}
+		}
+	case "a":
+		if len(m.selected) == len(entries) {
+			m.selected = map[string]bool{}
+		} else {
+			for _, entry := range entries {
+				m.selected[entry.File.OriginalPath] = true
+			}
+		}
+	case "r":
+		m.busy = true
+		m.err = nil
+		return m, m.load()
+	case "enter", "d":
+		if len(entries) == 0 {
+			return m, nil
+		}
+		path := entries[m.cursor].File.OriginalPath
+		actions := m.actions
+		m.busy = true
+		m.err = nil
+		return m, func() tea.Msg {
+			diff, err := actions.Diff(path)
+			return diffMsg{path: path, diff: diff, err: err}
+		}
+	case "f", "F":
+		if paths := m.targets(); len(paths) > 0 {
+			backup := key == "F"
+			return m.run(func() ([]string, error) { return m.actions.Fix(paths, backup) })
+		}
+	case "x":
+		if m.pending = m.targets(); len(m.pending) > 0 {
+			m.mode = modeConfirmRemove
+		}
+	case "l":
+		if m.pending = m.targets(); len(m.pending) > 0 && len(m.layers) > 0 {
+			m.mode = modeLayer
+			m.layerCursor = 0
+		}
+	case "c":
+		m.mode = modeCommit
+		m.input = ""
+	}
+	return m, nil
+}
+
+func (m Model) updateDiff(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc", "enter":
+		m.mode = modeList
+		m.diff = nil
+	case "up", "k":
+		m.diffOffset--
+	case "down", "j":
+		m.diffOffset++
+	case "pgup":
+		m.diffOffset -= m.diffHeight()
+	case "pgdown", " ":
+		m.diffOffset += m.diffHeight()
+	case "home", "g":
+		m.diffOffset = 0
+	case "end", "G":
+		m.diffOffset = len(m.diff)
+	}
+	m.diffOffset = clamp(m.diffOffset, 0, len(m.diff)-m.diffHeight())
+	return m, nil
+}
+
+func (m Model) updateLayer(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.mode = modeList
+	case "up", "k":
+		m.layerCursor = clamp(m.layerCursor-1, 0, len(m.layers)-1)
+	case "down", "j":
+		m.layerCursor = clamp(m.layerCursor+1, 0, len(m.layers)-1)
+	case "enter":
+		paths, layer := m.pending, m.layers[m.layerCursor]
+		return m.run(func() ([]string, error) { return m.actions.MoveToLayer(paths, layer) })
+	}
+	return m, nil
+}
+
+func (m Model) updateCommit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = modeList
+	case tea.KeyEnter:
+		message := strings.TrimSpace(m.input)
+		return m.run(func() ([]string, error) { return m.actions.Commit(message) })
+	case tea.KeyBackspace:
+		if runes := []rune(m.input); len(runes) > 0 {
+			m.input = string(runes[:len(runes)-1])
+		}
+	case tea.KeySpace:
+		m.input += " "
+	case tea.KeyRunes:
+		m.input += string(msg.Runes)
+	}
+	return m, nil
+}
+
+func (m Model) updateConfirmRemove(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if key := msg.String(); key == "y" || key == "Y" {
+		paths := m.pending
+		return m.run(func() ([]string, error) { return m.actions.Remove(paths) })
+	}
+	m.mode = modeList
+	return m, nil
+}
+
+// entries returns the entries of the last status
+func (m Model) entries() []dotman.EntryStatus {
+	if m.report == nil {
+		return nil
+	}
+	return m.report.Entries
+}
+
+// targets returns the selected paths in list order, or the path under the
+// cursor when nothing is selected
+func (m Model) targets() []string {
+	entries := m.entries()
+	var paths []string
+	for _, entry := range entries {
+		if m.selected[entry.File.OriginalPath] {
+			paths = append(paths, entry.File.OriginalPath)
+		}
+	}
+	if len(paths) == 0 && len(entries) > 0 {
+		paths = append(paths, entries[m.cursor].File.OriginalPath)
+	}
+	return paths
+}
+
+// keepSelection drops selected paths that are no longer listed
+func (m *Model) keepSelection() {
+	listed := map[string]bool{}
+	for _, entry := range m.entries() {
+		listed[entry.File.OriginalPath] = true
+	}
+	for path := range m.selected {
+		if !listed[path] {
+			delete(m.selected, path)
+		}
+	}
+}
+
+// scroll keeps the cursor on an entry and in view
+func (m *Model) scroll() {
+	m.cursor = clamp(m.cursor, 0, len(m.entries())-1)
+	height := m.listHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+height {
+		m.offset = m.cursor - height + 1
+	}
+	m.offset = clamp(m.offset, 0, len(m.entries())-height)
+}
+
+// clamp limits n to [low, high]; low wins when they cross
+func clamp(n, low, high int) int {
+	if n > high {
+		n = high
+	}
+	if n < low {
+		n = low
+	}
+	return n
+}
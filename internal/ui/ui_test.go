@@ -0,0 +1,317 @@
+package ui
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/Merith-TK/dotman/pkg/dotman"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// fakeActions lists a fixed set of entries and records the changes asked of
+// it
+type fakeActions struct {
+	paths []string
+	diff  string
+
+	statusCalls int
+	calls       []string
+}
+
+func (f *fakeActions) Status() (*dotman.StatusReport, error) {
+	f.statusCalls++
+	report := &dotman.StatusReport{Initialized: true, Managed: len(f.paths)}
+	for _, path := range f.paths {
+		report.Entries = append(report.Entries, dotman.EntryStatus{File: types.ManagedFile{OriginalPath: path}})
+	}
+	return report, nil
+}
+
+func (f *fakeActions) Diff(path string) (string, error) {
+	f.calls = append(f.calls, "diff "+path)
+	return f.diff, nil
+}
+
+func (f *fakeActions) Fix(paths []string, backup bool) ([]string, error) {
+	f.calls = append(f.calls, fmt.Sprintf("fix %s backup=%t", strings.Join(paths, ","), backup))
+	return nil, nil
+}
+
+func (f *fakeActions) Remove(paths []string) ([]string, error) {
+	f.calls = append(f.calls, "remove "+strings.Join(paths, ","))
+	return nil, nil
+}
+
+func (f *fakeActions) MoveToLayer(paths []string, layer string) ([]string, error) {
+	f.calls = append(f.calls, fmt.Sprintf("layer %s to %s", strings.Join(paths, ","), layer))
+	return nil, nil
+}
+
+func (f *fakeActions) Commit(message string) ([]string, error) {
+	f.calls = append(f.calls, fmt.Sprintf("commit %q", message))
+	return nil, nil
+}
+
+// keys maps key names to the messages bubbletea delivers for them; any
+// other name is typed as runes
+var keys = map[string]tea.KeyMsg{
+	"space":     {Type: tea.KeySpace, Runes: []rune{' '}},
+	"enter":     {Type: tea.KeyEnter},
+	"esc":       {Type: tea.KeyEsc},
+	"backspace": {Type: tea.KeyBackspace},
+	"up":        {Type: tea.KeyUp},
+	"down":      {Type: tea.KeyDown},
+	"pgup":      {Type: tea.KeyPgUp},
+	"pgdown":    {Type: tea.KeyPgDown},
+	"home":      {Type: tea.KeyHome},
+	"end":       {Type: tea.KeyEnd},
+}
+
+// update runs msg through the model, then the messages of the commands it
+// returns, the way the program would
+func update(t *testing.T, m Model, msg tea.Msg) Model {
+	t.Helper()
+	next, cmd := m.Update(msg)
+	m = next.(Model)
+	for cmd != nil {
+		msg := cmd()
+		if _, quit := msg.(tea.QuitMsg); quit {
+			return m
+		}
+		next, cmd = m.Update(msg)
+		m = next.(Model)
+	}
+	return m
+}
+
+// press sends the named keys to the model
+func press(t *testing.T, m Model, names ...string) Model {
+	t.Helper()
+	for _, name := range names {
+		msg, found := keys[name]
+		if !found {
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name)}
+		}
+		m = update(t, m, msg)
+	}
+	return m
+}
+
+// start returns a model with the status of actions loaded
+func start(t *testing.T, actions *fakeActions, layers []string) Model {
+	t.Helper()
+	m := New(actions, layers)
+	next, _ := m.Update(m.Init()())
+	m = next.(Model)
+	if m.busy || len(m.entries()) != len(actions.paths) {
+		t.Fatalf("model after loading: busy %t, %d entries, want %d", m.busy, len(m.entries()), len(actions.paths))
+	}
+	return m
+}
+
+// wantCalls checks the changes run through actions
+func wantCalls(t *testing.T, actions *fakeActions, want ...string) {
+	t.Helper()
+	if !reflect.DeepEqual(actions.calls, want) {
+		t.Errorf("calls = %q, want %q", actions.calls, want)
+	}
+}
+
+func TestSelection(t *testing.T) {
+	actions := &fakeActions{paths: []string{"/h/.a", "/h/.b", "/h/.c"}}
+	m := start(t, actions, nil)
+
+	m = press(t, m, "space", "down", "down", "space", "down")
+	if m.cursor != 2 {
+		t.Errorf("cursor = %d after moving past the end, want 2", m.cursor)
+	}
+	if got := m.targets(); !reflect.DeepEqual(got, []string{"/h/.a", "/h/.c"}) {
+		t.Errorf("targets() = %q, want the selection in list order", got)
+	}
+
+	m = press(t, m, "space")
+	if got := m.targets(); !reflect.DeepEqual(got, []string{"/h/.a"}) {
+		t.Errorf("targets() = %q after unselecting /h/.c, want /h/.a", got)
+	}
+
+	m = press(t, m, "f")
+	wantCalls(t, actions, "fix /h/.a backup=false")
+	if len(m.selected) != 0 || actions.statusCalls != 2 {
+		t.Errorf("after a fix: %d selected, %d status load(s), want the selection cleared and the status reloaded", len(m.selected), actions.statusCalls)
+	}
+}
+
+func TestSelectAll(t *testing.T) {
+	actions := &fakeActions{paths: []string{"/h/.a", "/h/.b", "/h/.c"}}
+	m := start(t, actions, nil)
+
+	m = press(t, m, "a")
+	if len(m.selected) != 3 {
+		t.Fatalf("%d selected after a, want all 3", len(m.selected))
+	}
+	m = press(t, m, "a")
+	if len(m.selected) != 0 {
+		t.Fatalf("%d selected after a twice, want none", len(m.selected))
+	}
+
+	m = press(t, m, "down", "space", "a", "F")
+	wantCalls(t, actions, "fix /h/.a,/h/.b,/h/.c backup=true")
+}
+
+func TestTargetsFallBackToCursor(t *testing.T) {
+	actions := &fakeActions{paths: []string{"/h/.a", "/h/.b"}}
+	m := start(t, actions, nil)
+
+	m = press(t, m, "down")
+	if got := m.targets(); !reflect.DeepEqual(got, []string{"/h/.b"}) {
+		t.Errorf("targets() = %q with nothing selected, want the entry under the cursor", got)
+	}
+	press(t, m, "f")
+	wantCalls(t, actions, "fix /h/.b backup=false")
+
+	empty := start(t, &fakeActions{}, nil)
+	if got := empty.targets(); len(got) != 0 {
+		t.Errorf("targets() = %q without entries, want none", got)
+	}
+}
+
+func TestRemoveConfirm(t *testing.T) {
+	actions := &fakeActions{paths: []string{"/h/.a", "/h/.b"}}
+	m := start(t, actions, nil)
+
+	m = press(t, m, "down", "x")
+	if m.mode != modeConfirmRemove || !reflect.DeepEqual(m.pending, []string{"/h/.b"}) {
+		t.Fatalf("after x: mode %d, pending %q, want the prompt for /h/.b", m.mode, m.pending)
+	}
+	m = press(t, m, "y")
+	wantCalls(t, actions, "remove /h/.b")
+	if m.mode != modeList {
+		t.Errorf("mode = %d after removing, want the list", m.mode)
+	}
+}
+
+func TestRemoveCancel(t *testing.T) {
+	for _, key := range []string{"n", "esc", "enter"} {
+		actions := &fakeActions{paths: []string{"/h/.a"}}
+		m := press(t, start(t, actions, nil), "x", key)
+		wantCalls(t, actions)
+		if m.mode != modeList {
+			t.Errorf("mode = %d after x %s, want the list", m.mode, key)
+		}
+	}
+}
+
+func TestLayerChoice(t *testing.T) {
+	actions := &fakeActions{paths: []string{"/h/.a", "/h/.b"}}
+	layers := []string{"hosts/laptop", "hosts/desktop"}
+	m := start(t, actions, layers)
+
+	m = press(t, m, "space", "l", "up")
+	if m.mode != modeLayer || m.layerCursor != 0 {
+		t.Fatalf("after l up: mode %d, layer cursor %d, want the first layer", m.mode, m.layerCursor)
+	}
+	m = press(t, m, "down", "down")
+	if m.layerCursor != 1 {
+		t.Errorf("layer cursor = %d after moving past the end, want 1", m.layerCursor)
+	}
+	m = press(t, m, "esc")
+	if m.mode != modeList {
+		t.Fatalf("mode = %d after esc, want the list", m.mode)
+	}
+	wantCalls(t, actions)
+
+	press(t, m, "l", "down", "enter")
+	wantCalls(t, actions, "layer /h/.a to hosts/desktop")
+
+	// Without layers there is nothing to choose
+	noLayers := &fakeActions{paths: []string{"/h/.a"}}
+	if m := press(t, start(t, noLayers, nil), "l"); m.mode != modeList {
+		t.Errorf("mode = %d after l without layers, want the list", m.mode)
+	}
+}
+
+func TestCommitInput(t *testing.T) {
+	actions := &fakeActions{paths: []string{"/h/.a"}}
+	m := start(t, actions, nil)
+
+	// Keys of the list are typed into the message
+	m = press(t, m, "c", "q", "u", "i", "t", "space", "x", "backspace", "a", "f")
+	if m.mode != modeCommit || m.input != "quit af" {
+		t.Fatalf("commit prompt: mode %d, input %q, want %q", m.mode, m.input, "quit af")
+	}
+	press(t, m, "enter")
+	wantCalls(t, actions, `commit "quit af"`)
+
+	actions = &fakeActions{paths: []string{"/h/.a"}}
+	m = press(t, start(t, actions, nil), "c", "space", "enter")
+	wantCalls(t, actions, `commit ""`)
+
+	actions = &fakeActions{paths: []string{"/h/.a"}}
+	m = press(t, start(t, actions, nil), "c", "w", "i", "p", "esc")
+	wantCalls(t, actions)
+	if m.mode != modeList {
+		t.Errorf("mode = %d after esc, want the list", m.mode)
+	}
+	if m = press(t, m, "c"); m.input != "" {
+		t.Errorf("input = %q when the prompt opens again, want it empty", m.input)
+	}
+}
+
+func TestDiffScroll(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	actions := &fakeActions{paths: []string{"/h/.a"}, diff: strings.Join(lines, "\n") + "\n"}
+	m := start(t, actions, nil)
+	// 10 rows leave 7 for the diff
+	m = update(t, m, tea.WindowSizeMsg{Width: 80, Height: 10})
+
+	m = press(t, m, "enter")
+	wantCalls(t, actions, "diff /h/.a")
+	if m.mode != modeDiff || len(m.diff) != 20 {
+		t.Fatalf("after enter: mode %d, %d diff lines, want the diff of 20 lines", m.mode, len(m.diff))
+	}
+
+	steps := []struct {
+		key  string
+		want int
+	}{
+		{"up", 0},
+		{"down", 1},
+		{"pgdown", 8},
+		{"pgdown", 13},
+		{"end", 13},
+		{"down", 13},
+		{"pgup", 6},
+		{"home", 0},
+		{"space", 7},
+	}
+	for _, step := range steps {
+		m = press(t, m, step.key)
+		if m.diffOffset != step.want {
+			t.Errorf("diff offset = %d after %s, want %d", m.diffOffset, step.key, step.want)
+		}
+	}
+
+	m = press(t, m, "q")
+	if m.mode != modeList || m.diff != nil {
+		t.Errorf("after q: mode %d, want the list", m.mode)
+	}
+
+	// A diff that fits doesn't scroll
+	actions.diff = "+one\n-two\n"
+	m = press(t, m, "enter", "pgdown", "end")
+	if m.diffOffset != 0 {
+		t.Errorf("diff offset = %d for a diff that fits, want 0", m.diffOffset)
+	}
+
+	actions.diff = ""
+	if m = press(t, m, "q", "enter"); !reflect.DeepEqual(m.diff, []string{"No changes."}) {
+		t.Errorf("empty diff shows %q, want No changes.", m.diff)
+	}
+}
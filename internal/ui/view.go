@@ -0,0 +1,204 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Merith-TK/dotman/pkg/dotman"
+)
+
+const (
+	// headerLines are the title, the git summary and a blank line
+	headerLines = 3
+	// footerLines are a blank line, up to three messages and the help
+	footerLines  = 5
+	maxMessages  = 3
+	diffChrome   = 3
+	listHelp     = "↑/↓ move  space select  a all  enter diff  f fix  F fix with backup  x remove  l layer  c commit  r refresh  q quit"
+	layerHelp    = "↑/↓ choose  enter move  esc cancel"
+	commitHelp   = "enter commit  esc cancel"
+	diffHelp     = "↑/↓ pgup/pgdown scroll  q back"
+	noEntriesMsg = "No files are managed by dotman."
+)
+
+// listHeight is the number of entries that fit on the screen
+func (m Model) listHeight() int {
+	if m.height == 0 {
+		return max(len(m.entries()), 1)
+	}
+	return max(m.height-headerLines-footerLines, 1)
+}
+
+// diffHeight is the number of diff lines that fit on the screen
+func (m Model) diffHeight() int {
+	if m.height == 0 {
+		return max(len(m.diff), 1)
+	}
+	return max(m.height-diffChrome, 1)
+}
+
+// View renders the screen
+func (m Model) View() string {
+	var lines []string
+	if m.mode == modeDiff {
+		lines = m.viewDiff()
+	} else {
+		lines = append(m.viewHeader(), m.viewBody()...)
+		lines = append(lines, m.viewFooter()...)
+	}
+
+	for i, line := range lines {
+		lines[i] = truncate(line, m.width)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m Model) viewHeader() []string {
+	if m.report == nil {
+		return []string{"dotman ui - loading...", "", ""}
+	}
+
+	title := fmt.Sprintf("dotman ui - %d managed, %d not linked, %d selected", m.report.Managed, m.report.Broken(), len(m.selected))
+	return []string{title, describeGit(m.report.Git), ""}
+}
+
+func (m Model) viewBody() []string {
+	if m.mode == modeLayer {
+		lines := []string{fmt.Sprintf("Move %d path(s) to layer:", len(m.pending))}
+		for i, layer := range m.layers {
+			lines = append(lines, marker(i == m.layerCursor)+layer)
+		}
+		return lines
+	}
+
+	entries := m.entries()
+	if m.report != nil && len(entries) == 0 {
+		return []string{noEntriesMsg}
+	}
+
+	var lines []string
+	end := min(m.offset+m.listHeight(), len(entries))
+	for i := m.offset; i < end; i++ {
+		entry := entries[i]
+		selected := "[ ]"
+		if m.selected[entry.File.OriginalPath] {
+			selected = "[x]"
+		}
+		changed := " "
+		if entry.Changed {
+			changed = "M"
+		}
+		lines = append(lines, fmt.Sprintf("%s%s %s %s  %s", marker(i == m.cursor), selected, changed, entry.File.OriginalPath, describeEntry(entry)))
+	}
+	return lines
+}
+
+func (m Model) viewFooter() []string {
+	lines := []string{""}
+
+	messages := m.messages
+	if len(messages) > maxMessages {
+		messages = append([]string{fmt.Sprintf("(%d earlier messages)", len(messages)-maxMessages+1)}, messages[len(messages)-maxMessages+1:]...)
+	}
+	lines = append(lines, messages...)
+	if m.err != nil {
+		lines = append(lines, "Error: "+m.err.Error())
+	}
+	if m.busy {
+		lines = append(lines, "Working...")
+	}
+
+	switch m.mode {
+	case modeLayer:
+		lines = append(lines, layerHelp)
+	case modeCommit:
+		lines = append(lines, "Commit message (empty for a generated one): "+m.input+"_", commitHelp)
+	case modeConfirmRemove:
+		lines = append(lines, fmt.Sprintf("Remove %d path(s) from dotman management and move their content back? (y/N)", len(m.pending)))
+	default:
+		lines = append(lines, listHelp)
+	}
+	return lines
+}
+
+func (m Model) viewDiff() []string {
+	lines := []string{"Diff of " + m.diffPath, ""}
+	end := min(m.diffOffset+m.diffHeight(), len(m.diff))
+	lines = append(lines, m.diff[m.diffOffset:end]...)
+	return append(lines, fmt.Sprintf("%s  (%d-%d of %d)", diffHelp, m.diffOffset+1, end, len(m.diff)))
+}
+
+// describeGit summarizes the repository in one line
+func describeGit(status *dotman.GitStatus) string {
+	if status == nil {
+		return "Git repository: not initialized"
+	}
+
+	line := "Branch: " + status.Branch
+	if status.BranchErr != nil {
+		line = "Branch: unknown"
+	}
+	if status.Upstream != "" && status.HasCounts {
+		line += fmt.Sprintf(" (%d ahead, %d behind %s)", status.Ahead, status.Behind, status.Upstream)
+	}
+
+	switch {
+	case status.ChangesErr != nil:
+		line += " - changes unknown"
+	case len(status.Changes) > 0:
+		line += fmt.Sprintf(" - %d uncommitted change(s)", len(status.Changes))
+	default:
+		line += " - clean"
+	}
+	return line
+}
+
+// describeEntry summarizes the state of an entry
+func describeEntry(entry dotman.EntryStatus) string {
+	file := entry.File
+	switch {
+	case entry.OtherLayers:
+		return "- only in layers " + strings.Join(file.Layers, ", ")
+	case entry.ShadowedBy != "":
+		return "- shadowed by source " + entry.ShadowedBy
+	case entry.TargetErr != nil:
+		return "✗ " + entry.TargetErr.Error()
+	case len(entry.Targets) == 0:
+		return "- no matching targets"
+	}
+
+	kind := string(file.Type) + ", " + entry.Layer
+	if file.Glob != nil {
+		kind += ", glob"
+	} else if file.Partial != nil {
+		kind += ", partial"
+	}
+
+	switch broken := entry.Broken(); {
+	case broken == 0:
+		return fmt.Sprintf("✓ OK (%s)", kind)
+	case len(entry.Targets) == 1:
+		return fmt.Sprintf("✗ %s (%s)", entry.Targets[0].State, kind)
+	default:
+		return fmt.Sprintf("✗ %d of %d not linked (%s)", broken, len(entry.Targets), kind)
+	}
+}
+
+// marker is the cursor column of a list line
+func marker(current bool) string {
+	if current {
+		return "> "
+	}
+	return "  "
+}
+
+// truncate cuts line to width runes; a width of 0 means unknown
+func truncate(line string, width int) string {
+	if width <= 0 {
+		return line
+	}
+	if runes := []rune(line); len(runes) > width {
+		return string(runes[:width])
+	}
+	return line
+}
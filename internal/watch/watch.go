@@ -0,0 +1,148 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// FlushFunc receives the repo-relative paths changed during a burst. It
+// returns false when the changes couldn't be handled yet (for example because
+// another dotman command holds the lock); they are offered again later.
+type FlushFunc func(paths []string) bool
+
+// ErrorFunc receives the problems that don't stop watching, such as a new
+// directory that couldn't be watched
+type ErrorFunc func(err error)
+
+// Watcher reports changes below a repository, ignoring its .git directory
+type Watcher struct {
+	root    string
+	watcher *fsnotify.Watcher
+}
+
+// New watches every directory below root
+func New(root string) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watcher: %w", err)
+	}
+
+	w := &Watcher{root: root, watcher: fsw}
+	if err := w.addTree(root); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Close stops watching
+func (w *Watcher) Close() error {
+	return w.watcher.Close()
+}
+
+// addTree adds watches for a directory and everything below it; inotify
+// watches aren't recursive
+func (w *Watcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Directories can disappear while they are being walked
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if w.ignored(path) {
+			return filepath.SkipDir
+		}
+		if err := w.watcher.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// ignored reports whether a path belongs to git's own bookkeeping
+func (w *Watcher) ignored(path string) bool {
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil {
+		return true
+	}
+	return rel == ".git" || strings.HasPrefix(rel, ".git"+string(filepath.Separator))
+}
+
+// Run collects changes and calls flush once no event has arrived for delay.
+// Changes pending when ctx is cancelled are flushed once more before Run
+// returns. Problems that don't stop watching go to report.
+func (w *Watcher) Run(ctx context.Context, delay time.Duration, flush FlushFunc, report ErrorFunc) error {
+	pending := make(map[string]bool)
+	timer := time.NewTimer(delay)
+	timer.Stop()
+
+	flushPending := func() {
+		if len(pending) == 0 {
+			return
+		}
+		paths := make([]string, 0, len(pending))
+		for path := range pending {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		if flush(paths) {
+			pending = make(map[string]bool)
+			return
+		}
+		timer.Reset(delay)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			flushPending()
+			return nil
+
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return nil
+			}
+			if w.ignored(event.Name) {
+				continue
+			}
+
+			// New directories need watches of their own
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Lstat(event.Name); err == nil && info.IsDir() {
+					if err := w.addTree(event.Name); err != nil {
+						report(err)
+					}
+				}
+			}
+
+			if rel, err := filepath.Rel(w.root, event.Name); err == nil {
+				pending[rel] = true
+			}
+			timer.Reset(delay)
+
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return nil
+			}
+			report(fmt.Errorf("watch error: %w", err))
+
+		case <-timer.C:
+			flushPending()
+		}
+	}
+}
@@ -0,0 +1,715 @@
+package dotman
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/git"
+	"github.com/Merith-TK/dotman/internal/hooks"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// Add moves paths into the repo and links them back, one commit per path or
+// with opts.Batch a single commit. With opts.Layer they go to an overlay
+// layer instead of the base. The error is a *types.BatchError when some
+// paths failed; the result has the outcome of every path.
+func (m *Manager) Add(paths []string, opts types.AddOptions) (*Result, error) {
+	if opts.Merge && opts.Split {
+		return nil, fmt.Errorf("--merge and --split can't be combined")
+	}
+
+	m.batching, m.batched = opts.Batch, nil
+	result := &Result{}
+	for _, path := range paths {
+		var err error
+		if opts.Layer != "" {
+			err = m.addToLayer(path, opts)
+		} else {
+			err = m.add(path, opts)
+		}
+		result.add(path, "", err)
+	}
+
+	if m.batching {
+		m.batching = false
+		if err := m.commitBatch(opts); err != nil {
+			return result, err
+		}
+	}
+
+	if result.Succeeded() > 0 {
+		if err := hooks.Run(m.cfg, hooks.PostAdd, opts.DryRun, m.hookReport(opts.DryRun)); err != nil {
+			m.warn("", err, "%v", err)
+		}
+	}
+
+	return result, result.Err()
+}
+
+// commitBatch commits the paths added by a batched Add
+func (m *Manager) commitBatch(opts types.AddOptions) error {
+	messages := m.batched
+	m.batched = nil
+
+	switch {
+	case len(messages) == 0:
+		return nil
+	case opts.Message != "" || len(messages) == 1:
+		return m.commitAll(commitMessage(opts, messages[0]))
+	}
+	return m.commitAll(fmt.Sprintf("Add %d paths to dotman management\n\n%s", len(messages), strings.Join(messages, "\n")))
+}
+
+// commitMessage returns the custom commit message, if one was given
+func commitMessage(opts types.AddOptions, fallback string) string {
+	if opts.Message != "" {
+		return opts.Message
+	}
+	return fallback
+}
+
+// prepareRepo makes sure the dotman directory is a git repository and loads
+// the index. A dry run only loads the index.
+func (m *Manager) prepareRepo(dryRun bool) (*types.Index, error) {
+	if dryRun {
+		idx, err := index.Load(m.cfg.IndexFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load index: %w", err)
+		}
+		return idx, nil
+	}
+
+	// Ensure dotman directory exists
+	if err := config.EnsureDotmanDir(m.cfg); err != nil {
+		return nil, fmt.Errorf("failed to create dotman directory: %w", err)
+	}
+
+	// Ensure git repository is initialized
+	if err := git.EnsureRepo(m.cfg.DotmanDir); err != nil {
+		return nil, fmt.Errorf("failed to initialize git repository: %w", err)
+	}
+
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+	return idx, nil
+}
+
+func (m *Manager) add(path string, opts types.AddOptions) error {
+	// Expand the path
+	expandedPath, err := config.ExpandPath(m.cfg, path)
+	if err != nil {
+		return fmt.Errorf("failed to expand path: %w", err)
+	}
+
+	// Check if path exists
+	if !fileops.PathExists(expandedPath) {
+		return fmt.Errorf("path does not exist: %s", expandedPath)
+	}
+
+	// Check if path is inside home directory
+	if !config.IsInsideHome(m.cfg, expandedPath) {
+		return types.Errorf(types.ErrOutsideHome, "path must be inside home directory: %s", expandedPath)
+	}
+
+	idx, err := m.prepareRepo(opts.DryRun)
+	if err != nil {
+		return err
+	}
+
+	// Check if already managed
+	if index.IsManaged(idx, expandedPath) {
+		return types.Errorf(types.ErrAlreadyManaged, "path is already managed: %s", expandedPath)
+	}
+
+	// Paths inside a managed directory resolve into the repo through its link
+	if dir, found := index.ContainingDirectory(idx, expandedPath); found {
+		if dir.Partial != nil {
+			if opts.DryRun {
+				m.emit(EventPlanned, expandedPath, nil, "Would add %s to partial directory %s", expandedPath, dir.OriginalPath)
+				return nil
+			}
+			return m.addToPartialDirectory(idx, *dir, expandedPath, opts)
+		}
+		if !opts.Split {
+			return types.Errorf(types.ErrAlreadyManaged, "%s is inside managed directory %s; use --split to manage it separately", expandedPath, dir.OriginalPath)
+		}
+		if opts.DryRun {
+			m.emit(EventPlanned, expandedPath, nil, "Would split %s to manage %s separately", dir.OriginalPath, expandedPath)
+			return nil
+		}
+		return m.splitManagedDirectory(idx, *dir, expandedPath, opts)
+	}
+
+	// Calculate repo path
+	relativePath, err := config.RelativeToHome(m.cfg, expandedPath)
+	if err != nil {
+		return fmt.Errorf("failed to get relative path: %w", err)
+	}
+
+	// Prevent tracking repository metadata like .dotman and README.md
+	if config.ShouldIgnoreRepoPath(m.cfg, relativePath) {
+		return fmt.Errorf("refusing to track repository metadata: %s", relativePath)
+	}
+
+	// Children that are managed on their own would end up twice in the repo
+	if children := index.Children(idx, expandedPath); len(children) > 0 {
+		if !opts.Merge {
+			return fmt.Errorf("%s contains %d managed entries; use --merge to fold them into one directory entry", expandedPath, len(children))
+		}
+		if opts.DryRun {
+			m.emit(EventPlanned, expandedPath, nil, "Would merge %d managed entries into directory entry %s", len(children), expandedPath)
+			return nil
+		}
+		return m.mergeIntoDirectory(idx, expandedPath, relativePath, children, opts)
+	}
+
+	if opts.Partial != nil {
+		if opts.DryRun {
+			m.emit(EventPlanned, expandedPath, nil, "Would manage %s partially", expandedPath)
+			return nil
+		}
+		return m.addPartialDirectory(idx, expandedPath, relativePath, opts)
+	}
+
+	repoPath := filepath.Join(m.cfg.DotmanDir, relativePath)
+
+	// Get file type
+	fileType := fileops.GetFileType(expandedPath)
+
+	if opts.DryRun {
+		m.emit(EventPlanned, expandedPath, nil, "Would move %s to %s and link it", expandedPath, repoPath)
+		return nil
+	}
+
+	m.info(expandedPath, "Adding %s to dotman management...", expandedPath)
+
+	if opts.Backup {
+		if err := m.backup(expandedPath); err != nil {
+			return err
+		}
+	}
+
+	// Move file to repo
+	if err := fileops.MoveToRepo(expandedPath, repoPath); err != nil {
+		return fmt.Errorf("failed to move file to repo: %w", err)
+	}
+
+	// Create symlink
+	if err := fileops.CreateSymlink(expandedPath, repoPath); err != nil {
+		// Try to restore the file if symlink creation fails
+		fileops.Rename(repoPath, expandedPath)
+		return fmt.Errorf("failed to create symlink: %w", err)
+	}
+
+	// Add to index
+	index.AddFile(idx, expandedPath, relativePath, fileType)
+
+	// Convert to $HOME relative path for commit message
+	homePath := m.homePath(expandedPath, "$HOME/"+relativePath)
+	if err := m.commit(idx, commitMessage(opts, fmt.Sprintf("Add %s to dotman management", homePath))); err != nil {
+		return err
+	}
+
+	m.emit(EventLinked, expandedPath, nil, "Successfully added %s to dotman management", path)
+	return nil
+}
+
+// AddGlob adds an entry whose targets are discovered with a glob pattern,
+// using opts.Policy and opts.RepoPath
+func (m *Manager) AddGlob(pattern string, opts types.AddOptions) error {
+	policy := opts.Policy
+	if policy == "" {
+		policy = types.MatchFirst
+	}
+	if !config.ValidMatchPolicy(policy) {
+		return fmt.Errorf("unknown match policy %q (use %s, %s or %s)", policy, types.MatchFirst, types.MatchAll, types.MatchFailIfNone)
+	}
+
+	// ExpandPath doesn't interpret wildcards, so this only anchors the pattern in $HOME
+	expandedPattern, err := config.ExpandPath(m.cfg, pattern)
+	if err != nil {
+		return fmt.Errorf("failed to expand pattern: %w", err)
+	}
+
+	relativePattern, err := config.RelativeToHome(m.cfg, expandedPattern)
+	if err != nil {
+		return fmt.Errorf("failed to get relative pattern: %w", err)
+	}
+
+	repoRelPath := opts.RepoPath
+	if repoRelPath == "" {
+		repoRelPath = config.GlobRepoPath(relativePattern)
+	}
+	repoRelPath = filepath.Clean(repoRelPath)
+	if filepath.IsAbs(repoRelPath) || strings.HasPrefix(repoRelPath, "..") {
+		return fmt.Errorf("repo path must be relative to the repo: %s", repoRelPath)
+	}
+	if config.ShouldIgnoreRepoPath(m.cfg, repoRelPath) {
+		return fmt.Errorf("refusing to track repository metadata: %s", repoRelPath)
+	}
+
+	idx, err := m.prepareRepo(opts.DryRun)
+	if err != nil {
+		return err
+	}
+
+	if index.IsManaged(idx, expandedPattern) {
+		return types.Errorf(types.ErrAlreadyManaged, "pattern is already managed: %s", expandedPattern)
+	}
+	if _, found := index.FindByRepoPath(idx, repoRelPath); found {
+		return fmt.Errorf("repo path is already used by another entry: %s", repoRelPath)
+	}
+
+	glob := &types.GlobTarget{Pattern: relativePattern, Policy: policy}
+	targets, err := config.ResolveGlobTarget(m.cfg, glob)
+	if err != nil {
+		return err
+	}
+
+	repoPath := filepath.Join(m.cfg.DotmanDir, repoRelPath)
+
+	// Pick the file that seeds the repo copy, unless it is already there
+	source := ""
+	if !fileops.PathExists(repoPath) {
+		for _, target := range targets {
+			if fileops.PathExists(target) && !fileops.IsSymlink(target) {
+				source = target
+				break
+			}
+		}
+		if source == "" {
+			return fmt.Errorf("no existing file matches %s; place the file at %s first", pattern, repoPath)
+		}
+	}
+
+	m.info(expandedPattern, "Adding %s to dotman management (%s)...", expandedPattern, policy)
+
+	if opts.DryRun {
+		if source != "" {
+			m.emit(EventPlanned, source, nil, "Would move %s to %s", source, repoPath)
+		}
+		for _, target := range targets {
+			m.emit(EventPlanned, target, nil, "Would link %s", target)
+		}
+		if len(targets) == 0 {
+			m.emit(EventPlanned, "", nil, "No targets currently match the pattern")
+		}
+		return nil
+	}
+
+	fileType := fileops.GetFileType(repoPath)
+	if source != "" {
+		fileType = fileops.GetFileType(source)
+		if err := fileops.MoveToRepo(source, repoPath); err != nil {
+			return fmt.Errorf("failed to move file to repo: %w", err)
+		}
+	}
+
+	for _, target := range targets {
+		if fileops.PathExists(target) || fileops.IsSymlink(target) {
+			if target != source {
+				m.warn(target, nil, "%s exists, skipping", target)
+				continue
+			}
+		}
+		if err := fileops.CreateSymlink(target, repoPath); err != nil {
+			if target == source {
+				// Put the seed file back if it can't be linked
+				fileops.Rename(repoPath, source)
+				return fmt.Errorf("failed to create symlink: %w", err)
+			}
+			m.emit(EventFailed, target, err, "Error creating symlink for %s: %v", target, err)
+			continue
+		}
+		m.emit(EventLinked, target, nil, "Linked %s", target)
+	}
+
+	index.AddFile(idx, expandedPattern, repoRelPath, fileType)
+	idx.ManagedFiles[len(idx.ManagedFiles)-1].Glob = glob
+
+	if err := m.commit(idx, commitMessage(opts, fmt.Sprintf("Add $HOME/%s to dotman management", relativePattern))); err != nil {
+		return err
+	}
+
+	m.info(expandedPattern, "Successfully added %s to dotman management", pattern)
+	return nil
+}
+
+// addToLayer stores a file in an overlay layer. Unmanaged files are moved
+// there; managed files get a copy in the layer that shadows the other layers.
+func (m *Manager) addToLayer(path string, opts types.AddOptions) error {
+	layer := opts.Layer
+
+	expandedPath, err := config.ExpandPath(m.cfg, path)
+	if err != nil {
+		return fmt.Errorf("failed to expand path: %w", err)
+	}
+
+	relativePath, err := config.RelativeToHome(m.cfg, expandedPath)
+	if err != nil {
+		return fmt.Errorf("failed to get relative path: %w", err)
+	}
+
+	if config.ShouldIgnoreRepoPath(m.cfg, relativePath) {
+		return fmt.Errorf("refusing to track repository metadata: %s", relativePath)
+	}
+
+	idx, err := m.prepareRepo(opts.DryRun)
+	if err != nil {
+		return err
+	}
+
+	if dir, found := index.ContainingDirectory(idx, expandedPath); found {
+		return types.Errorf(types.ErrAlreadyManaged, "%s is inside managed directory %s; add the directory to the layer instead", expandedPath, dir.OriginalPath)
+	}
+
+	layerPath := filepath.Join(m.cfg.DotmanDir, config.LayerPath(layer, relativePath))
+	if fileops.PathExists(layerPath) {
+		return fmt.Errorf("layer %s already has a copy of %s", layer, expandedPath)
+	}
+
+	if opts.DryRun {
+		m.emit(EventPlanned, expandedPath, nil, "Would add %s to layer %s", expandedPath, layer)
+		return nil
+	}
+
+	m.info(expandedPath, "Adding %s to layer %s...", expandedPath, layer)
+
+	if managedFile, found := index.FindFile(idx, expandedPath); found {
+		if managedFile.Glob != nil {
+			return fmt.Errorf("glob entries can't be layered: %s", expandedPath)
+		}
+
+		// Seed the layer with the content currently deployed
+		_, source := m.EntrySource(*managedFile)
+		if err := fileops.MkdirAll(filepath.Dir(layerPath), 0755); err != nil {
+			return fmt.Errorf("failed to create layer directory: %w", err)
+		}
+		if err := fileops.CopyPath(source, layerPath); err != nil {
+			return fmt.Errorf("failed to copy %s into layer: %w", source, err)
+		}
+
+		if fileops.IsSymlink(expandedPath) {
+			if err := fileops.Remove(expandedPath); err != nil {
+				return fmt.Errorf("failed to remove old symlink: %w", err)
+			}
+		}
+		if err := fileops.CreateSymlink(expandedPath, layerPath); err != nil {
+			return fmt.Errorf("failed to create symlink: %w", err)
+		}
+
+		index.AddLayer(idx, expandedPath, layer)
+	} else {
+		if !fileops.PathExists(expandedPath) {
+			return fmt.Errorf("path does not exist: %s", expandedPath)
+		}
+		if fileops.IsSymlink(expandedPath) {
+			return fmt.Errorf("path is a symlink: %s", expandedPath)
+		}
+
+		fileType := fileops.GetFileType(expandedPath)
+
+		if err := fileops.MoveToRepo(expandedPath, layerPath); err != nil {
+			return fmt.Errorf("failed to move file to repo: %w", err)
+		}
+
+		if err := fileops.CreateSymlink(expandedPath, layerPath); err != nil {
+			// Try to restore the file if symlink creation fails
+			fileops.Rename(layerPath, expandedPath)
+			return fmt.Errorf("failed to create symlink: %w", err)
+		}
+
+		index.AddFile(idx, expandedPath, relativePath, fileType)
+		index.AddLayer(idx, expandedPath, layer)
+	}
+
+	if err := m.commit(idx, commitMessage(opts, fmt.Sprintf("Add $HOME/%s to layer %s", relativePath, layer))); err != nil {
+		return err
+	}
+
+	m.emit(EventLinked, expandedPath, nil, "Successfully added %s to layer %s", path, layer)
+	return nil
+}
+
+// splitManagedDirectory replaces the link of a managed directory with real
+// directories down to target and links everything else in them as separate
+// entries. The repo layout doesn't change, only the index and the links.
+func (m *Manager) splitManagedDirectory(idx *types.Index, dir types.ManagedFile, target string, opts types.AddOptions) error {
+	if dir.Glob != nil || len(dir.Layers) > 0 {
+		return fmt.Errorf("%s has overlay copies and can't be split", dir.OriginalPath)
+	}
+
+	repoDir := filepath.Join(m.cfg.DotmanDir, dir.RepoPath)
+	if link, err := os.Readlink(dir.OriginalPath); err != nil || link != repoDir {
+		return fmt.Errorf("%s is not linked to the repo; run 'dotman deploy' first", dir.OriginalPath)
+	}
+
+	rel, err := filepath.Rel(dir.OriginalPath, target)
+	if err != nil {
+		return fmt.Errorf("failed to get relative path: %w", err)
+	}
+	components := strings.Split(rel, string(filepath.Separator))
+
+	m.info(dir.OriginalPath, "Splitting %s to manage %s separately...", dir.OriginalPath, target)
+
+	if err := fileops.Remove(dir.OriginalPath); err != nil {
+		return fmt.Errorf("failed to remove directory symlink: %w", err)
+	}
+
+	// Put the directory link back if the split fails halfway
+	restore := func(err error) error {
+		fileops.RemoveAll(dir.OriginalPath)
+		fileops.Symlink(repoDir, dir.OriginalPath)
+		return err
+	}
+
+	index.RemoveFile(idx, dir.OriginalPath)
+
+	created := 0
+	level, levelRepo := dir.OriginalPath, dir.RepoPath
+	for i, component := range components {
+		info, err := os.Stat(filepath.Join(m.cfg.DotmanDir, levelRepo))
+		if err != nil {
+			return restore(fmt.Errorf("failed to stat repo directory: %w", err))
+		}
+		if err := os.Mkdir(level, info.Mode().Perm()); err != nil {
+			return restore(fmt.Errorf("failed to create directory: %w", err))
+		}
+
+		entries, err := os.ReadDir(filepath.Join(m.cfg.DotmanDir, levelRepo))
+		if err != nil {
+			return restore(fmt.Errorf("failed to read repo directory: %w", err))
+		}
+
+		for _, entry := range entries {
+			// The directory on the way to target is split further
+			if entry.Name() == component && i < len(components)-1 {
+				continue
+			}
+
+			childPath := filepath.Join(level, entry.Name())
+			childRepo := filepath.Join(levelRepo, entry.Name())
+			childRepoPath := filepath.Join(m.cfg.DotmanDir, childRepo)
+			if err := fileops.CreateSymlink(childPath, childRepoPath); err != nil {
+				return restore(err)
+			}
+			index.AddFile(idx, childPath, childRepo, fileops.GetFileType(childRepoPath))
+			created++
+		}
+
+		level = filepath.Join(level, component)
+		levelRepo = filepath.Join(levelRepo, component)
+	}
+
+	commitMsg := fmt.Sprintf("Split $HOME/%s to manage $HOME/%s separately", dir.RepoPath, filepath.Join(dir.RepoPath, rel))
+	if err := m.commit(idx, commitMessage(opts, commitMsg)); err != nil {
+		return err
+	}
+
+	m.emit(EventLinked, target, nil, "Successfully split %s into %d entries", dir.OriginalPath, created)
+	return nil
+}
+
+// mergeIntoDirectory turns a directory whose children are managed into one
+// directory entry. Unmanaged content is moved into the repo next to the
+// managed children and the child entries are dropped.
+func (m *Manager) mergeIntoDirectory(idx *types.Index, dirPath, relativePath string, children []types.ManagedFile, opts types.AddOptions) error {
+	if fileops.IsSymlink(dirPath) || !fileops.IsDirectory(dirPath) {
+		return fmt.Errorf("%s contains managed entries but is not a directory", dirPath)
+	}
+
+	managed := make(map[string]string)
+	for _, child := range children {
+		if child.Glob != nil || child.Partial != nil || len(child.Layers) > 0 {
+			return fmt.Errorf("%s has overlay copies and can't be merged", child.OriginalPath)
+		}
+		managed[child.OriginalPath] = filepath.Join(m.cfg.DotmanDir, child.RepoPath)
+	}
+
+	// Find what has to move into the repo before touching anything
+	var moves []string
+	dirModes := make(map[string]os.FileMode)
+	err := filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if source, ok := managed[path]; ok {
+			if link, err := os.Readlink(path); err != nil || link != source {
+				return fmt.Errorf("%s is not linked to the repo; run 'dotman status --fix' first", path)
+			}
+			return nil
+		}
+
+		if d.IsDir() && (path == dirPath || hasManagedBelow(managed, path)) {
+			if info, err := d.Info(); err == nil {
+				dirModes[path] = info.Mode().Perm()
+			}
+			return nil
+		}
+
+		rel, err := config.RelativeToHome(m.cfg, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path: %w", err)
+		}
+		if _, err := os.Lstat(filepath.Join(m.cfg.DotmanDir, rel)); err == nil {
+			return types.Errorf(types.ErrConflict, "repo path already exists: %s", rel)
+		}
+		moves = append(moves, path)
+
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	m.info(dirPath, "Merging %d managed entries into %s...", len(children), dirPath)
+
+	for _, path := range moves {
+		rel, _ := config.RelativeToHome(m.cfg, path)
+		if err := fileops.MoveToRepo(path, filepath.Join(m.cfg.DotmanDir, rel)); err != nil {
+			return fmt.Errorf("failed to move file to repo: %w", err)
+		}
+	}
+
+	// Only links and emptied directories are left in $HOME
+	if err := fileops.RemoveAll(dirPath); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", dirPath, err)
+	}
+
+	repoDir := filepath.Join(m.cfg.DotmanDir, relativePath)
+	for path, mode := range dirModes {
+		rel, _ := config.RelativeToHome(m.cfg, path)
+		fileops.MkdirAll(filepath.Join(m.cfg.DotmanDir, rel), 0755)
+		os.Chmod(filepath.Join(m.cfg.DotmanDir, rel), mode)
+	}
+
+	if err := fileops.CreateSymlink(dirPath, repoDir); err != nil {
+		return fmt.Errorf("failed to create symlink: %w", err)
+	}
+
+	for _, child := range children {
+		index.RemoveFile(idx, child.OriginalPath)
+	}
+	index.AddFile(idx, dirPath, relativePath, types.FileTypeDirectory)
+
+	commitMsg := fmt.Sprintf("Merge %d entries into $HOME/%s", len(children), relativePath)
+	if err := m.commit(idx, commitMessage(opts, commitMsg)); err != nil {
+		return err
+	}
+
+	m.emit(EventLinked, dirPath, nil, "Successfully merged %d entries and %d new paths into %s", len(children), len(moves), dirPath)
+	return nil
+}
+
+// hasManagedBelow reports whether any managed path is inside dir
+func hasManagedBelow(managed map[string]string, dir string) bool {
+	for path := range managed {
+		if index.IsWithinDirectory(path, []string{dir}) {
+			return true
+		}
+	}
+	return false
+}
+
+// addPartialDirectory starts managing a directory partially: the files
+// matching the patterns are moved into the repo and linked one by one
+func (m *Manager) addPartialDirectory(idx *types.Index, dirPath, relativePath string, opts types.AddOptions) error {
+	partial := opts.Partial
+	if err := config.ValidatePartial(partial); err != nil {
+		return err
+	}
+	if fileops.IsSymlink(dirPath) || !fileops.IsDirectory(dirPath) {
+		return fmt.Errorf("--include and --exclude need a directory: %s", dirPath)
+	}
+
+	file := types.ManagedFile{OriginalPath: dirPath, RepoPath: relativePath, Partial: partial}
+	files := PartialCandidates(file)
+
+	// Check every destination before moving anything
+	for _, path := range files {
+		rel, _ := filepath.Rel(dirPath, path)
+		if _, err := os.Lstat(filepath.Join(m.cfg.DotmanDir, relativePath, rel)); err == nil {
+			return types.Errorf(types.ErrConflict, "repo path already exists: %s", filepath.Join(relativePath, rel))
+		}
+	}
+
+	m.info(dirPath, "Adding %s to dotman management (partial)...", dirPath)
+
+	for _, path := range files {
+		rel, _ := filepath.Rel(dirPath, path)
+		repoPath := filepath.Join(m.cfg.DotmanDir, relativePath, rel)
+		if err := fileops.MoveToRepo(path, repoPath); err != nil {
+			return fmt.Errorf("failed to move file to repo: %w", err)
+		}
+		if err := fileops.CreateSymlink(path, repoPath); err != nil {
+			fileops.Rename(repoPath, path)
+			return fmt.Errorf("failed to create symlink: %w", err)
+		}
+		m.emit(EventLinked, path, nil, "Tracked %s", path)
+	}
+
+	if len(files) == 0 {
+		m.info(dirPath, "No files match the include patterns yet; matching files will show up in 'dotman status'")
+	}
+
+	index.AddFile(idx, dirPath, relativePath, types.FileTypeDirectory)
+	idx.ManagedFiles[len(idx.ManagedFiles)-1].Partial = partial
+
+	commitMsg := fmt.Sprintf("Add $HOME/%s to dotman management (%d files)", relativePath, len(files))
+	if err := m.commit(idx, commitMessage(opts, commitMsg)); err != nil {
+		return err
+	}
+
+	m.info(dirPath, "Successfully added %s with %d tracked file(s)", dirPath, len(files))
+	return nil
+}
+
+// addToPartialDirectory tracks one more file of a partial directory
+func (m *Manager) addToPartialDirectory(idx *types.Index, dir types.ManagedFile, path string, opts types.AddOptions) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("only files can be tracked in partial directory %s", dir.OriginalPath)
+	}
+
+	rel, err := filepath.Rel(dir.OriginalPath, path)
+	if err != nil {
+		return fmt.Errorf("failed to get relative path: %w", err)
+	}
+	repoRel := filepath.Join(dir.RepoPath, rel)
+	repoPath := filepath.Join(m.cfg.DotmanDir, repoRel)
+	if _, err := os.Lstat(repoPath); err == nil {
+		return types.Errorf(types.ErrConflict, "repo path already exists: %s", repoRel)
+	}
+
+	m.info(path, "Adding %s to partial directory %s...", path, dir.OriginalPath)
+
+	if err := fileops.MoveToRepo(path, repoPath); err != nil {
+		return fmt.Errorf("failed to move file to repo: %w", err)
+	}
+	if err := fileops.CreateSymlink(path, repoPath); err != nil {
+		fileops.Rename(repoPath, path)
+		return fmt.Errorf("failed to create symlink: %w", err)
+	}
+
+	if err := m.commit(idx, commitMessage(opts, fmt.Sprintf("Add $HOME/%s to dotman management", repoRel)), dir.RepoPath); err != nil {
+		return err
+	}
+
+	m.emit(EventLinked, path, nil, "Successfully added %s to dotman management", path)
+	return nil
+}
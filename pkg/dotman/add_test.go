@@ -0,0 +1,139 @@
+package dotman
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/hooks"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+func TestAdd(t *testing.T) {
+	setupGit(t)
+	m, events := newManager(t)
+	bashrc, vimrc, missing := homeFile(m, ".bashrc"), homeFile(m, ".vimrc"), homeFile(m, ".missing")
+	writeFile(t, bashrc, "bashrc\n")
+	writeFile(t, vimrc, "vimrc\n")
+
+	result, err := m.Add([]string{bashrc, missing, vimrc}, types.AddOptions{})
+	var batch *types.BatchError
+	if !errors.As(err, &batch) || batch.Succeeded != 2 || len(batch.Failed) != 1 || batch.Failed[0].Path != missing {
+		t.Fatalf("Add() error = %v, want a batch error with %s failed", err, missing)
+	}
+	if result.Succeeded() != 2 {
+		t.Errorf("Succeeded() = %d, want 2", result.Succeeded())
+	}
+
+	for _, name := range []string{".bashrc", ".vimrc"} {
+		path := homeFile(m, name)
+		wantLink(t, m, path, name)
+		if !events.has(EventLinked, path) {
+			t.Errorf("no linked event for %s", path)
+		}
+		file, found := index.FindFile(loadIndex(t, m), path)
+		if !found || file.RepoPath != name || file.Digest == "" {
+			t.Errorf("index entry of %s = %+v, want repo path %s with a digest", path, file, name)
+		}
+	}
+	if got := readFile(t, bashrc); got != "bashrc\n" {
+		t.Errorf("%s reads %q through its link, want the original content", bashrc, got)
+	}
+	if got := lastCommit(t, m); got != "Add $HOME/.vimrc to dotman management" {
+		t.Errorf("last commit = %q", got)
+	}
+
+	if _, err := m.Add([]string{bashrc}, types.AddOptions{}); !errors.Is(err, types.ErrAlreadyManaged) {
+		t.Errorf("adding %s again: error = %v, want ErrAlreadyManaged", bashrc, err)
+	}
+}
+
+func TestAddDryRun(t *testing.T) {
+	setupGit(t)
+	m, events := newManager(t)
+	bashrc := homeFile(m, ".bashrc")
+	writeFile(t, bashrc, "bashrc\n")
+
+	if _, err := m.Add([]string{bashrc}, types.AddOptions{DryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+	if fileops.IsSymlink(bashrc) || fileops.PathExists(m.cfg.DotmanDir) {
+		t.Error("a dry run changed the home directory")
+	}
+	if !events.has(EventPlanned, bashrc) {
+		t.Errorf("no planned event for %s", bashrc)
+	}
+}
+
+func TestAddBatch(t *testing.T) {
+	setupGit(t)
+	m, _ := newManager(t)
+	var paths []string
+	for _, name := range []string{".a", ".b", ".c"} {
+		writeFile(t, homeFile(m, name), name)
+		paths = append(paths, homeFile(m, name))
+	}
+
+	if _, err := m.Add(paths, types.AddOptions{Batch: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got := lastCommit(t, m); got != "Add 3 paths to dotman management" {
+		t.Errorf("last commit = %q, want one commit for the batch", got)
+	}
+	if got := runGit(t, m.cfg.DotmanDir, "rev-list", "--count", "HEAD"); got != "2" {
+		t.Errorf("%s commits, want the initial commit and the batch", got)
+	}
+}
+
+func TestAddPostAddHook(t *testing.T) {
+	setupGit(t)
+	m, _ := newManager(t)
+	marker := homeFile(m, "post-add-ran")
+	script := filepath.Join(hooks.Dir(m.cfg), hooks.PostAdd)
+	writeFile(t, script, "#!/bin/sh\ntouch \"$DOTMAN_HOME/post-add-ran\"\n")
+	if err := os.Chmod(script, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Add([]string{homeFile(m, ".missing")}, types.AddOptions{}); err == nil {
+		t.Fatal("adding a missing path succeeded")
+	}
+	if fileops.PathExists(marker) {
+		t.Error("post-add ran although no path was added")
+	}
+
+	add(t, m, ".bashrc")
+	if !fileops.PathExists(marker) {
+		t.Error("post-add didn't run after a path was added")
+	}
+}
+
+func TestCommitKeepsDigestsOfOutsideEdits(t *testing.T) {
+	setupGit(t)
+	m, events := newManager(t)
+	add(t, m, ".a")
+	before, _ := index.FindFile(loadIndex(t, m), homeFile(m, ".a"))
+
+	// An edit to the repo copy that dotman doesn't know about
+	writeFile(t, filepath.Join(m.cfg.DotmanDir, ".a"), "edited\n")
+	add(t, m, ".b")
+
+	if !events.has(EventWarning, homeFile(m, ".a")) {
+		t.Errorf("no warning about the outside edit of %s", homeFile(m, ".a"))
+	}
+	after, _ := index.FindFile(loadIndex(t, m), homeFile(m, ".a"))
+	if after.Digest != before.Digest {
+		t.Error("the digest of an entry edited outside dotman was updated by an unrelated commit")
+	}
+
+	report, err := m.Fsck()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Changed() != 1 {
+		t.Errorf("fsck reports %d changed copies, want the outside edit", report.Changed())
+	}
+}
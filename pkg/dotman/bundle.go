@@ -0,0 +1,192 @@
+package dotman
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Merith-TK/dotman/internal/bundle"
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/digest"
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/git"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// BundleInfo describes a bundle written by Export or unpacked by Bootstrap
+type BundleInfo struct {
+	Path    string
+	Entries int
+	Files   int
+	// Commit is the commit the bundle was made from; empty without git
+	Commit string
+}
+
+// Export packs the repo tree, its index and a manifest into a gzip-compressed
+// tar file at out, which must be outside the repo. Git history is not
+// included.
+func (m *Manager) Export(out string) (*BundleInfo, error) {
+	if !config.DotmanDirExists(m.cfg) {
+		return nil, types.Errorf(types.ErrRepoMissing, "dotman directory does not exist: %s", m.cfg.DotmanDir)
+	}
+
+	out, err := filepath.Abs(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve absolute path: %w", err)
+	}
+	if out == m.cfg.DotmanDir || strings.HasPrefix(out, m.cfg.DotmanDir+string(filepath.Separator)) {
+		return nil, fmt.Errorf("bundle must be written outside the dotman directory")
+	}
+
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+
+	manifest := &bundle.Manifest{
+		CreatedAt: time.Now().UTC(),
+		Hostname:  m.cfg.Hostname,
+		HomeDir:   m.cfg.HomeDir,
+		Entries:   index.Count(idx),
+	}
+
+	if git.IsGitRepo(m.cfg.DotmanDir) {
+		manifest.Commit = git.GetHead(m.cfg.DotmanDir)
+		manifest.Branch, _ = git.GetCurrentBranch(m.cfg.DotmanDir)
+		manifest.Remote, _ = git.GetRemoteURL(m.cfg.DotmanDir)
+
+		if hasChanges, err := git.HasChanges(m.cfg.DotmanDir); err == nil && hasChanges {
+			m.warn("", nil, "the repo has uncommitted changes; they are included in the bundle")
+		}
+	}
+
+	if err := bundle.Create(m.cfg.DotmanDir, out, manifest); err != nil {
+		fileops.Remove(out)
+		return nil, err
+	}
+
+	return &BundleInfo{Path: out, Entries: manifest.Entries, Files: manifest.Files, Commit: manifest.Commit}, nil
+}
+
+// Bootstrap unpacks a bundle made by Export into the dotman directory, which
+// must not exist yet, and moves its entries to this machine's home directory.
+// Deploy it afterwards.
+func (m *Manager) Bootstrap(bundlePath string, opts types.BootstrapOptions) (*BundleInfo, error) {
+	if config.DotmanDirExists(m.cfg) {
+		return nil, types.Errorf(types.ErrConflict, "dotman directory already exists: %s", m.cfg.DotmanDir)
+	}
+
+	m.info(bundlePath, "Unpacking %s to %s...", bundlePath, m.cfg.DotmanDir)
+
+	manifest, err := bundle.Extract(bundlePath, m.cfg.DotmanDir)
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		fileops.RemoveAll(m.cfg.DotmanDir)
+		return nil, fmt.Errorf("bundle has invalid index.json: %w", err)
+	}
+
+	// Index paths are absolute; move them to this machine's home directory
+	if manifest.HomeDir != "" && manifest.HomeDir != m.cfg.HomeDir {
+		if err := m.rebaseIndex(idx, manifest.HomeDir); err != nil {
+			return nil, err
+		}
+	}
+
+	// The recorded digests tell whether the bundle arrived intact
+	for _, file := range index.GetAllFiles(idx) {
+		if file.Digest == "" {
+			continue
+		}
+		current, err := digest.Path(filepath.Join(m.cfg.DotmanDir, file.RepoPath))
+		if err == nil && current != file.Digest {
+			m.warn(file.OriginalPath, nil, "content of %s differs from the recorded digest", file.RepoPath)
+		}
+	}
+
+	if err := bundle.SaveState(m.cfg, manifest); err != nil {
+		m.warn("", err, "%v", err)
+	}
+
+	if manifest.Commit != "" {
+		m.info("", "Unpacked %d entries from commit %s", manifest.Entries, ShortRevision(manifest.Commit))
+	} else {
+		m.info("", "Unpacked %d entries", manifest.Entries)
+	}
+
+	if opts.Git {
+		if err := m.initBundleRepo(manifest); err != nil {
+			m.warn("", err, "%v", err)
+		}
+	}
+
+	return &BundleInfo{Path: bundlePath, Entries: manifest.Entries, Files: manifest.Files, Commit: manifest.Commit}, nil
+}
+
+// ReconnectBundle turns a tree unpacked by Bootstrap into a git repository
+// connected to the remote the bundle was made from. It reports false when
+// the tree didn't come from a bundle.
+func (m *Manager) ReconnectBundle() (bool, error) {
+	manifest, err := bundle.LoadState(m.cfg)
+	if err != nil {
+		m.warn("", err, "%v", err)
+	}
+	if manifest == nil {
+		return false, nil
+	}
+	return true, m.initBundleRepo(manifest)
+}
+
+// initBundleRepo turns an unpacked bundle into a git repository, pointing
+// origin at the remote the bundle was exported from
+func (m *Manager) initBundleRepo(manifest *bundle.Manifest) error {
+	if _, err := git.Version(); err != nil {
+		return fmt.Errorf("git is not available; run 'dotman init' once it is installed")
+	}
+
+	if err := git.EnsureRepo(m.cfg.DotmanDir); err != nil {
+		return fmt.Errorf("failed to initialize git repository: %w", err)
+	}
+
+	if manifest.Remote != "" && !git.HasRemote(m.cfg.DotmanDir, "origin") {
+		if err := git.AddRemote(m.cfg.DotmanDir, "origin", manifest.Remote); err != nil {
+			return err
+		}
+		m.info("", "Set origin to %s", manifest.Remote)
+	}
+
+	// The new commit doesn't share history with the remote yet
+	if manifest.Remote != "" && manifest.Branch != "" {
+		m.info("", "To continue the remote history: dotman git -- fetch origin && dotman git -- reset --soft origin/%s", manifest.Branch)
+	}
+
+	m.info("", "Initialized git repository from bundle")
+	return nil
+}
+
+// rebaseIndex rewrites original paths below oldHome to the current home
+// directory and saves the index
+func (m *Manager) rebaseIndex(idx *types.Index, oldHome string) error {
+	rebased := 0
+	for i, file := range idx.ManagedFiles {
+		rel, err := filepath.Rel(oldHome, file.OriginalPath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			m.warn(file.OriginalPath, nil, "%s is outside %s, left unchanged", file.OriginalPath, oldHome)
+			continue
+		}
+		idx.ManagedFiles[i].OriginalPath = filepath.Join(m.cfg.HomeDir, rel)
+		rebased++
+	}
+
+	if err := index.Save(idx, m.cfg.IndexFile); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+
+	m.info("", "Moved %d entries from %s to %s", rebased, oldHome, m.cfg.HomeDir)
+	return nil
+}
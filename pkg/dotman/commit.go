@@ -0,0 +1,155 @@
+package dotman
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/git"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// Commit commits every uncommitted change in the repo, such as edits made
+// through the links, and returns the subject of the commit. Without a
+// message, the commit names the changed paths. A clean repo is left alone
+// and "" returned.
+func (m *Manager) Commit(message string) (string, error) {
+	if !config.DotmanDirExists(m.cfg) {
+		return "", types.Errorf(types.ErrRepoMissing, "dotman directory does not exist: %s", m.cfg.DotmanDir)
+	}
+
+	status, err := git.Status(m.cfg.DotmanDir)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(status) == "" {
+		return "", nil
+	}
+
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to load index: %w", err)
+	}
+
+	if message == "" {
+		changed := m.changedHomePaths(idx, status)
+		message = "Update dotman repository"
+		switch {
+		case len(changed) == 1:
+			message = fmt.Sprintf("Update %s", changed[0])
+		case len(changed) > 1:
+			message = fmt.Sprintf("Update %d paths\n\n%s", len(changed), strings.Join(changed, "\n"))
+		}
+	}
+
+	// The changes are committed as they are, so their digests are updated
+	changed := changedRepoPaths(strings.Split(status, "\n"))
+	var touched []string
+	for _, file := range index.GetAllFiles(idx) {
+		for _, layer := range append([]string{config.BaseLayer}, file.Layers...) {
+			if touchesPath(changed, config.LayerPath(layer, file.RepoPath)) {
+				touched = append(touched, file.RepoPath)
+				break
+			}
+		}
+	}
+
+	if err := m.commit(idx, message, touched...); err != nil {
+		return "", err
+	}
+	return strings.SplitN(message, "\n", 2)[0], nil
+}
+
+// statusPath returns the path of a 'git status --porcelain' line; for a
+// rename, the new path
+func statusPath(line string) string {
+	line = strings.TrimSpace(line)
+	i := strings.IndexByte(line, ' ')
+	if i < 0 {
+		return ""
+	}
+	path := strings.TrimSpace(line[i+1:])
+	if j := strings.Index(path, " -> "); j >= 0 {
+		path = path[j+4:]
+	}
+	return filepath.Clean(strings.Trim(path, `"`))
+}
+
+// changedRepoPaths returns the repo paths of 'git status --porcelain' lines
+func changedRepoPaths(lines []string) []string {
+	var paths []string
+	for _, line := range lines {
+		if path := statusPath(line); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// touchesPath reports whether any of the changed repo paths is repoPath,
+// is below it or is an untracked directory holding it
+func touchesPath(changed []string, repoPath string) bool {
+	sep := string(filepath.Separator)
+	for _, path := range changed {
+		if path == repoPath || strings.HasPrefix(path, repoPath+sep) || strings.HasPrefix(repoPath, path+sep) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsPath reports whether paths holds path
+func containsPath(paths []string, path string) bool {
+	for _, p := range paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// changedHomePaths maps the paths in git status output to the $HOME paths
+// of the entries they belong to
+func (m *Manager) changedHomePaths(idx *types.Index, status string) []string {
+	seen := make(map[string]bool)
+	var paths []string
+
+	for _, path := range changedRepoPaths(strings.Split(status, "\n")) {
+		// index.json, .gitignore and repo metadata don't live in $HOME
+		if path == config.IndexFileName || path == ".gitignore" || !config.IsOverlayPath(path) && config.ShouldIgnoreRepoPath(m.cfg, path) {
+			continue
+		}
+
+		homePath := "$HOME/" + homeRelativeRepoPath(idx, path)
+		if !seen[homePath] {
+			seen[homePath] = true
+			paths = append(paths, homePath)
+		}
+	}
+
+	return paths
+}
+
+// homeRelativeRepoPath returns where a repo path appears below $HOME: the
+// repo mirrors $HOME except for overlay layers and glob entries
+func homeRelativeRepoPath(idx *types.Index, repoRelPath string) string {
+	rel := repoRelPath
+	if config.IsOverlayPath(rel) {
+		// Strip .dotman/layers/hosts/<hostname> or .dotman/layers/os/<goos>
+		if parts := strings.SplitN(rel, string(filepath.Separator), 5); len(parts) == 5 {
+			rel = parts[4]
+		}
+	}
+
+	for _, file := range index.GetAllFiles(idx) {
+		if file.Glob == nil {
+			continue
+		}
+		if rel == file.RepoPath || strings.HasPrefix(rel, file.RepoPath+string(filepath.Separator)) {
+			return filepath.Join(file.Glob.Pattern, strings.TrimPrefix(rel, file.RepoPath))
+		}
+	}
+	return rel
+}
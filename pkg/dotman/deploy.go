@@ -0,0 +1,185 @@
+package dotman
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/hooks"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// DeployStep is what deploy does with one index entry on this machine
+type DeployStep struct {
+	File  types.ManagedFile
+	Layer string
+	// Source is the absolute repo path the targets link to
+	Source    string
+	Targets   []string
+	TargetErr error
+	// Skip is the reason the entry is not deployed, if any
+	Skip string
+}
+
+// DeployResult lists what Deploy did with each target
+type DeployResult struct {
+	// Deployed are the targets that were (or in a dry run would be) linked
+	Deployed []string
+	// Skipped are the targets and entries that were left alone
+	Skipped []string
+	// Failed are the targets that couldn't be linked, including existing
+	// files in the way
+	Failed []types.Operation
+}
+
+// PlanDeploy decides for every index entry which layer supplies it and where
+// it is deployed. Deploy and the generated install script share this plan.
+func (m *Manager) PlanDeploy(idx *types.Index) []DeployStep {
+	var steps []DeployStep
+
+	for _, file := range m.LinkedEntries(idx) {
+		step := DeployStep{File: file}
+		step.Layer, step.Source = m.EntrySource(file)
+
+		switch winner, shadowedEntry := m.ShadowedBy(file.OriginalPath); {
+		case config.ShouldIgnoreRepoPath(m.cfg, file.RepoPath):
+			// Skip repository metadata
+			step.Skip = fmt.Sprintf("Skipping repository metadata: %s", file.RepoPath)
+		case !m.AppliesHere(file):
+			step.Skip = fmt.Sprintf("Skipping %s (only in layers %s)", file.OriginalPath, strings.Join(file.Layers, ", "))
+		case shadowedEntry:
+			step.Skip = fmt.Sprintf("Skipping %s (shadowed by source %s)", file.OriginalPath, winner)
+		case !fileops.PathExists(step.Source):
+			// Check if repo file exists
+			step.Skip = fmt.Sprintf("Warning: repo file missing for %s", file.OriginalPath)
+		default:
+			step.Targets, step.TargetErr = m.EntryTargets(file)
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps
+}
+
+// Deploy links every managed entry that applies to this machine into $HOME.
+// Existing files are reported as conflicts, or with opts.Backup saved to the
+// backup store and replaced. The error is a *types.BatchError when some
+// targets couldn't be linked.
+func (m *Manager) Deploy(opts types.DeployOptions) (*DeployResult, error) {
+	if !config.DotmanDirExists(m.cfg) {
+		return nil, types.Errorf(types.ErrRepoMissing, "dotman directory does not exist: %s", m.cfg.DotmanDir)
+	}
+
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+
+	result := &DeployResult{}
+	if index.Count(idx) == 0 {
+		m.info("", "No files to deploy.")
+		return result, nil
+	}
+
+	// A failing pre-deploy hook aborts the deployment
+	if err := hooks.Run(m.cfg, hooks.PreDeploy, opts.DryRun, m.hookReport(opts.DryRun)); err != nil {
+		return nil, err
+	}
+
+	m.info("", "Deploying %d file(s)...", index.Count(idx))
+
+	for _, step := range m.PlanDeploy(idx) {
+		if step.Skip != "" {
+			m.emit(EventSkipped, step.File.OriginalPath, nil, "%s", step.Skip)
+			result.Skipped = append(result.Skipped, step.File.OriginalPath)
+			continue
+		}
+		if step.TargetErr != nil {
+			m.emit(EventFailed, step.File.OriginalPath, step.TargetErr, "Error resolving targets for %s: %v", step.File.OriginalPath, step.TargetErr)
+			result.Failed = append(result.Failed, types.Operation{Path: step.File.OriginalPath, Error: step.TargetErr, Message: "failed to resolve targets"})
+			continue
+		}
+		if step.File.Glob != nil && len(step.Targets) == 0 {
+			m.emit(EventSkipped, step.File.OriginalPath, nil, "Skipping %s (no matching targets)", step.File.OriginalPath)
+			result.Skipped = append(result.Skipped, step.File.OriginalPath)
+			continue
+		}
+
+		for _, target := range step.Targets {
+			m.deployTarget(result, target, step.Source, opts)
+		}
+	}
+
+	if err := hooks.RunScripts(m.cfg, opts.DryRun, m.hookReport(opts.DryRun)); err != nil {
+		m.warn("", err, "%v", err)
+	}
+
+	if err := hooks.Run(m.cfg, hooks.PostDeploy, opts.DryRun, m.hookReport(opts.DryRun)); err != nil {
+		m.warn("", err, "%v", err)
+	}
+
+	m.info("", "Deployment complete.")
+	if len(result.Failed) > 0 {
+		return result, &types.BatchError{Succeeded: len(result.Deployed) + len(result.Skipped), Failed: result.Failed}
+	}
+	return result, nil
+}
+
+// deployTarget links a single target location to its repo path
+func (m *Manager) deployTarget(result *DeployResult, target, repoPath string, opts types.DeployOptions) {
+	// Check if original location already exists
+	if fileops.PathExists(target) {
+		if fileops.IsSymlink(target) {
+			m.emit(EventSkipped, target, nil, "Skipping %s (symlink already exists)", target)
+			result.Skipped = append(result.Skipped, target)
+			return
+		}
+		if !opts.Backup {
+			err := types.Errorf(types.ErrConflict, "%s exists and is not a symlink", target)
+			m.warn(target, err, "%s exists and is not a symlink, skipping", target)
+			result.Failed = append(result.Failed, types.Operation{Path: target, Error: err, Message: "target exists"})
+			return
+		}
+		if opts.DryRun {
+			m.emit(EventPlanned, target, nil, "Would back up %s and deploy it", target)
+			result.Deployed = append(result.Deployed, target)
+			return
+		}
+		if err := m.replaceWithBackup(target); err != nil {
+			m.emit(EventFailed, target, err, "Error backing up %s: %v", target, err)
+			result.Failed = append(result.Failed, types.Operation{Path: target, Error: err, Message: "failed to back up"})
+			return
+		}
+	}
+
+	if opts.DryRun {
+		m.emit(EventPlanned, target, nil, "Would deploy %s", target)
+		result.Deployed = append(result.Deployed, target)
+		return
+	}
+
+	// Create symlink (and any missing parent directories)
+	if err := fileops.CreateSymlink(target, repoPath); err != nil {
+		m.emit(EventFailed, target, err, "Error creating symlink for %s: %v", target, err)
+		result.Failed = append(result.Failed, types.Operation{Path: target, Error: err, Message: "failed to create symlink"})
+		return
+	}
+
+	m.emit(EventLinked, target, nil, "Deployed %s", target)
+	result.Deployed = append(result.Deployed, target)
+}
+
+// replaceWithBackup saves target to the backup store and removes it, making
+// room for a link
+func (m *Manager) replaceWithBackup(target string) error {
+	if err := m.backup(target); err != nil {
+		return err
+	}
+	if err := fileops.RemoveAll(target); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", target, err)
+	}
+	return nil
+}
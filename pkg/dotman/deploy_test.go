@@ -0,0 +1,71 @@
+package dotman
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+func TestDeploy(t *testing.T) {
+	setupGit(t)
+	m, events := newManager(t)
+	add(t, m, ".bashrc", ".vimrc", ".zshrc")
+	bashrc, vimrc, zshrc := homeFile(m, ".bashrc"), homeFile(m, ".vimrc"), homeFile(m, ".zshrc")
+
+	// A fresh machine: one target is missing, one is in the way
+	for _, path := range []string{bashrc, vimrc} {
+		if err := os.Remove(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, vimrc, "local\n")
+	events.events = nil
+
+	result, err := m.Deploy(types.DeployOptions{})
+	var batch *types.BatchError
+	if !errors.As(err, &batch) || len(batch.Failed) != 1 || batch.Failed[0].Path != vimrc {
+		t.Fatalf("Deploy() error = %v, want a batch error for %s", err, vimrc)
+	}
+	if len(result.Deployed) != 1 || result.Deployed[0] != bashrc {
+		t.Errorf("Deployed = %q, want %s", result.Deployed, bashrc)
+	}
+	wantLink(t, m, bashrc, ".bashrc")
+	if got := readFile(t, vimrc); got != "local\n" {
+		t.Errorf("the file in the way was changed to %q", got)
+	}
+	if !events.has(EventLinked, bashrc) || !events.has(EventWarning, vimrc) || !events.has(EventSkipped, zshrc) {
+		t.Errorf("events = %+v, want %s linked, %s in the way and %s skipped", events.events, bashrc, vimrc, zshrc)
+	}
+
+	// With Backup the file in the way is saved and replaced
+	if _, err := m.Deploy(types.DeployOptions{Backup: true}); err != nil {
+		t.Fatal(err)
+	}
+	wantLink(t, m, vimrc, ".vimrc")
+}
+
+func TestDeployDryRun(t *testing.T) {
+	setupGit(t)
+	m, events := newManager(t)
+	add(t, m, ".bashrc")
+	bashrc := homeFile(m, ".bashrc")
+	if err := os.Remove(bashrc); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := m.Deploy(types.DeployOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Deployed) != 1 {
+		t.Errorf("Deployed = %q, want the planned link", result.Deployed)
+	}
+	if _, err := os.Lstat(bashrc); !os.IsNotExist(err) {
+		t.Error("a dry run created the link")
+	}
+	if !events.has(EventPlanned, bashrc) {
+		t.Errorf("no planned event for %s", bashrc)
+	}
+}
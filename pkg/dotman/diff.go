@@ -0,0 +1,92 @@
+package dotman
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/git"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// Diff returns the uncommitted changes to the repo content of a managed
+// path. For each target that is not a link, the difference between the
+// repo copy and the content in $HOME follows.
+func (m *Manager) Diff(path string) (string, error) {
+	if !config.DotmanDirExists(m.cfg) {
+		return "", types.Errorf(types.ErrRepoMissing, "dotman directory does not exist: %s", m.cfg.DotmanDir)
+	}
+
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to load index: %w", err)
+	}
+
+	repoPath, file, err := m.ResolveManagedPath(idx, path)
+	if err != nil {
+		return "", err
+	}
+
+	diff, err := git.Diff(m.cfg.DotmanDir, "HEAD", "--", repoPath)
+	if err != nil {
+		return "", err
+	}
+
+	links, err := m.EntryLinks(*file)
+	if err != nil {
+		return diff, nil
+	}
+	for _, link := range links {
+		if _, err := os.Lstat(link.Target); err != nil || fileops.IsSymlink(link.Target) || !fileops.PathExists(link.Source) {
+			continue
+		}
+		inHome, err := git.Diff(m.cfg.DotmanDir, "--no-index", "--", link.Source, link.Target)
+		if err != nil {
+			return "", err
+		}
+		if inHome != "" {
+			diff += fmt.Sprintf("%s is not a link; it differs from the repo copy:\n%s", link.Target, inHome)
+		}
+	}
+	return diff, nil
+}
+
+// DiffArgs returns the git diff arguments that compare the repo content of a
+// managed path, or of the whole repo when path is "", with revision since
+func (m *Manager) DiffArgs(path, since string) ([]string, error) {
+	idx, err := m.RepoIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := git.VerifyRevision(m.cfg.DotmanDir, since); err != nil {
+		return nil, err
+	}
+
+	args := []string{"diff", since, "--"}
+	if path != "" {
+		repoPath, _, err := m.ResolveManagedPath(idx, path)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, repoPath)
+	}
+	return args, nil
+}
+
+// LogArgs returns the git log arguments that show the history of a managed
+// path, or of a file inside a managed directory
+func (m *Manager) LogArgs(path string) ([]string, error) {
+	idx, err := m.RepoIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	repoPath, _, err := m.ResolveManagedPath(idx, path)
+	if err != nil {
+		return nil, err
+	}
+	return []string{"log", "--follow", "--", repoPath}, nil
+}
@@ -0,0 +1,169 @@
+package dotman
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Merith-TK/dotman/internal/catalog"
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// Candidate is configuration found by Discover
+type Candidate struct {
+	Path string
+	// App is the catalog application the path belongs to
+	App  string
+	Type types.FileType
+	// Size is the total size of the files, Files their number
+	Size  int64
+	Files int
+	// ModTime is the latest modification of a file
+	ModTime time.Time
+	// Skip is the reason the path shouldn't be added, if any
+	Skip string
+}
+
+// cacheDirs are directory names that hold caches or state rather than
+// configuration
+var cacheDirs = map[string]bool{
+	"cache": true, "Cache": true, ".cache": true, "caches": true, "Caches": true,
+	"CachedData": true, "GPUCache": true, "Code Cache": true,
+	"node_modules": true, "__pycache__": true, "logs": true, "tmp": true,
+}
+
+// Discover looks for the configuration of the applications in the built-in
+// catalog in $HOME and $XDG_CONFIG_HOME. Managed paths are left out. Paths
+// holding caches, binary files or a git repository, and paths larger than
+// opts.MaxSize, are returned with a Skip reason.
+func (m *Manager) Discover(opts types.DiscoverOptions) ([]Candidate, error) {
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+	managedDirs := index.ManagedDirectories(idx)
+	configHome := config.ConfigHome(m.cfg.HomeDir)
+
+	seen := make(map[string]bool)
+	var candidates []Candidate
+	for _, app := range catalog.Apps {
+		var paths []string
+		for _, path := range app.Home {
+			paths = append(paths, filepath.Join(m.cfg.HomeDir, path))
+		}
+		for _, path := range app.Config {
+			paths = append(paths, filepath.Join(configHome, path))
+		}
+
+		for _, path := range paths {
+			if seen[path] || index.IsManaged(idx, path) || index.IsWithinDirectory(path, managedDirs) {
+				continue
+			}
+			seen[path] = true
+
+			info, err := os.Lstat(path)
+			if err != nil {
+				continue
+			}
+
+			candidate := Candidate{Path: path, App: app.Name, Type: types.FileTypeFile, ModTime: info.ModTime()}
+			if info.IsDir() {
+				candidate.Type = types.FileTypeDirectory
+			}
+
+			switch {
+			case info.Mode()&os.ModeSymlink != 0:
+				candidate.Skip = "symlink"
+			case !config.IsInsideHome(m.cfg, path):
+				candidate.Skip = "outside home directory"
+			default:
+				candidate.Skip = inspect(&candidate)
+				if candidate.Skip == "" && opts.MaxSize > 0 && candidate.Size > opts.MaxSize {
+					candidate.Skip = "larger than the size limit"
+				}
+			}
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	return candidates, nil
+}
+
+// inspect adds up the files of a candidate and returns why it shouldn't be
+// added, if it shouldn't
+func inspect(candidate *Candidate) string {
+	reason := ""
+	err := filepath.WalkDir(candidate.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(candidate.Path, path)
+
+		if d.IsDir() {
+			switch {
+			case path == candidate.Path:
+			case d.Name() == ".git":
+				reason = "holds a git repository"
+				return filepath.SkipAll
+			case cacheDirs[d.Name()]:
+				reason = "holds cache directory " + rel
+				return filepath.SkipAll
+			}
+			return nil
+		}
+
+		// Links inside a directory are moved along as they are
+		if d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			reason = "holds special file " + rel
+			return filepath.SkipAll
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		candidate.Files++
+		candidate.Size += info.Size()
+		if info.ModTime().After(candidate.ModTime) {
+			candidate.ModTime = info.ModTime()
+		}
+
+		if isBinary(path) {
+			reason = "binary file"
+			if path != candidate.Path {
+				reason = "holds binary file " + rel
+			}
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Sprintf("unreadable: %v", err)
+	}
+	return reason
+}
+
+// isBinary reports whether a file looks binary: a NUL byte in its first 8 KiB
+func isBinary(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	head := make([]byte, 8192)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return false
+	}
+	return bytes.IndexByte(head[:n], 0) >= 0
+}
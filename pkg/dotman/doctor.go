@@ -0,0 +1,436 @@
+package dotman
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/git"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/internal/lock"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// Severity of a doctor check
+type Severity int
+
+const (
+	SeverityOK Severity = iota
+	SeverityWarning
+	SeverityFailure
+)
+
+// DoctorCheck is the result of a single health check
+type DoctorCheck struct {
+	Name     string
+	Severity Severity
+	Summary  string
+	Details  []string
+	// Fix is a suggested command to fix the problem
+	Fix string
+	// Repair fixes the problem without risk of losing data; nil if there is
+	// no safe repair
+	Repair func() error
+}
+
+// Doctor runs health checks against the dotman lock, the index, the repo,
+// the deployed links and git. Nothing is changed until a Repair is called.
+func (m *Manager) Doctor() []DoctorCheck {
+	// The lock is checked first so a caller holding it for repairs isn't reported
+	checks := []DoctorCheck{m.checkLock()}
+
+	if !config.DotmanDirExists(m.cfg) {
+		return append(checks, DoctorCheck{
+			Name:     "Repository",
+			Severity: SeverityFailure,
+			Summary:  fmt.Sprintf("%s does not exist", m.cfg.DotmanDir),
+			Fix:      "dotman init (or dotman clone <url>)",
+		})
+	}
+
+	idxCheck, idx := m.checkIndex()
+	checks = append(checks, idxCheck)
+
+	if idx != nil {
+		checks = append(checks, m.checkDuplicates(idx))
+		checks = append(checks, m.checkOverlaps(idx))
+		checks = append(checks, m.checkRepoPaths(idx))
+		checks = append(checks, m.checkLinks(idx))
+	}
+
+	checks = append(checks, m.checkGit()...)
+
+	if idx != nil && git.IsGitRepo(m.cfg.DotmanDir) {
+		checks = append(checks, m.checkGitignore(idx))
+	}
+
+	return checks
+}
+
+// checkLock reports whether another dotman process holds the lock
+func (m *Manager) checkLock() DoctorCheck {
+	check := DoctorCheck{Name: "Lock", Summary: "not held"}
+
+	info, err := lock.Read(m.cfg)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return check
+		}
+		// The next command that takes the lock replaces it
+		check.Severity = SeverityWarning
+		check.Summary = fmt.Sprintf("unreadable lock file %s: %v", lock.Path(m.cfg), err)
+		check.Fix = "rm " + lock.Path(m.cfg)
+		return check
+	}
+
+	if lock.IsAlive(info.PID) {
+		check.Severity = SeverityWarning
+		check.Summary = fmt.Sprintf("held by pid %d (%s) since %s", info.PID, info.Command, info.Since.Format("2006-01-02 15:04:05"))
+		return check
+	}
+
+	// The next command that takes the lock replaces it
+	check.Severity = SeverityWarning
+	check.Summary = fmt.Sprintf("stale lock left by pid %d (%s)", info.PID, info.Command)
+	check.Fix = "rm " + lock.Path(m.cfg)
+	return check
+}
+
+// checkIndex verifies the index can be parsed and has a known version
+func (m *Manager) checkIndex() (DoctorCheck, *types.Index) {
+	check := DoctorCheck{Name: "Index"}
+
+	if !config.IndexFileExists(m.cfg) {
+		check.Severity = SeverityFailure
+		check.Summary = fmt.Sprintf("%s does not exist", m.cfg.IndexFile)
+		check.Fix = "dotman init"
+		return check, nil
+	}
+
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		check.Severity = SeverityFailure
+		check.Summary = err.Error()
+		check.Fix = fmt.Sprintf("git -C %s checkout -- %s", m.cfg.DotmanDir, config.IndexFileName)
+		return check, nil
+	}
+
+	check.Summary = fmt.Sprintf("version %s, %d entries", idx.Version, index.Count(idx))
+	if idx.Version != config.DefaultVersion {
+		check.Severity = SeverityWarning
+		check.Summary = fmt.Sprintf("unknown version %q (expected %s), %d entries", idx.Version, config.DefaultVersion, index.Count(idx))
+	}
+
+	return check, idx
+}
+
+// checkDuplicates looks for entries sharing an original path or repo path
+func (m *Manager) checkDuplicates(idx *types.Index) DoctorCheck {
+	check := DoctorCheck{Name: "Duplicate entries", Summary: "none"}
+
+	seenOriginal := make(map[string]types.ManagedFile)
+	seenRepo := make(map[string]types.ManagedFile)
+	exact := 0
+
+	for _, file := range index.GetAllFiles(idx) {
+		if prev, found := seenOriginal[file.OriginalPath]; found {
+			if prev.RepoPath == file.RepoPath {
+				exact++
+			}
+			check.Details = append(check.Details, fmt.Sprintf("%s is listed more than once", file.OriginalPath))
+		} else if prev, found := seenRepo[file.RepoPath]; found {
+			check.Details = append(check.Details, fmt.Sprintf("%s and %s share repo path %s", prev.OriginalPath, file.OriginalPath, file.RepoPath))
+		}
+		seenOriginal[file.OriginalPath] = file
+		seenRepo[file.RepoPath] = file
+	}
+
+	if len(check.Details) == 0 {
+		return check
+	}
+
+	check.Severity = SeverityFailure
+	check.Summary = fmt.Sprintf("%d duplicate entries", len(check.Details))
+	check.Fix = fmt.Sprintf("edit %s and remove the extra entries", m.cfg.IndexFile)
+	if exact > 0 && exact == len(check.Details) {
+		check.Fix = "dotman doctor --fix-safe"
+		check.Repair = m.dedupeIndex
+	}
+	return check
+}
+
+// dedupeIndex removes entries that exactly repeat an earlier entry
+func (m *Manager) dedupeIndex() error {
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return fmt.Errorf("failed to load index: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var kept []types.ManagedFile
+	for _, file := range index.GetAllFiles(idx) {
+		key := file.OriginalPath + "\x00" + file.RepoPath
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, file)
+	}
+	removed := len(idx.ManagedFiles) - len(kept)
+	idx.ManagedFiles = kept
+
+	return m.commit(idx, fmt.Sprintf("Doctor: remove %d duplicate index entries", removed))
+}
+
+// checkOverlaps looks for entries inside managed directories
+func (m *Manager) checkOverlaps(idx *types.Index) DoctorCheck {
+	check := DoctorCheck{Name: "Overlapping entries", Summary: "none"}
+
+	managedDirs := index.ManagedDirectories(idx)
+	redundantFiles := 0
+	for _, file := range index.GetAllFiles(idx) {
+		if !index.IsWithinDirectory(file.OriginalPath, managedDirs) {
+			continue
+		}
+		check.Details = append(check.Details, fmt.Sprintf("%s (%s) is inside a managed directory", file.OriginalPath, file.Type))
+		if file.Type == types.FileTypeFile {
+			redundantFiles++
+		}
+	}
+
+	if len(check.Details) == 0 {
+		return check
+	}
+
+	check.Severity = SeverityWarning
+	check.Summary = fmt.Sprintf("%d entries covered by managed directories", len(check.Details))
+	if redundantFiles == len(check.Details) {
+		check.Fix = "dotman status --cleanup"
+		check.Repair = func() error {
+			_, err := m.Cleanup(types.FixOptions{})
+			return err
+		}
+	} else {
+		check.Fix = "dotman status --cleanup, then remove nested directory entries from the index"
+	}
+	return check
+}
+
+// checkRepoPaths compares the repo tree with the index in both directions
+func (m *Manager) checkRepoPaths(idx *types.Index) DoctorCheck {
+	check := DoctorCheck{Name: "Repo contents", Summary: "index and repo agree"}
+
+	missing := 0
+	for _, file := range index.GetAllFiles(idx) {
+		if !m.AppliesHere(file) {
+			continue
+		}
+		if _, source := m.EntrySource(file); !fileops.PathExists(source) {
+			check.Details = append(check.Details, fmt.Sprintf("%s: repo path %s is missing", file.OriginalPath, file.RepoPath))
+			missing++
+		}
+	}
+
+	unmanaged, err := m.UnmanagedFiles(idx)
+	if err != nil {
+		check.Severity = SeverityWarning
+		check.Summary = fmt.Sprintf("failed to scan repo: %v", err)
+		return check
+	}
+	for _, relPath := range unmanaged {
+		check.Details = append(check.Details, fmt.Sprintf("%s is in the repo but not in the index", relPath))
+	}
+
+	switch {
+	case missing > 0:
+		check.Severity = SeverityFailure
+		check.Summary = fmt.Sprintf("%d entries without repo content, %d unindexed file(s)", missing, len(unmanaged))
+		check.Fix = fmt.Sprintf("restore the content with git -C %s log/checkout, or drop the entries from the index", m.cfg.DotmanDir)
+	case len(unmanaged) > 0:
+		check.Severity = SeverityWarning
+		check.Summary = fmt.Sprintf("%d unindexed file(s) in the repo", len(unmanaged))
+		check.Fix = "dotman sync"
+	}
+	return check
+}
+
+// checkLinks verifies every deployed target is a symlink to its repo path
+func (m *Manager) checkLinks(idx *types.Index) DoctorCheck {
+	check := DoctorCheck{Name: "Symlinks", Summary: "all targets linked correctly"}
+
+	managedDirs := index.LinkedDirectories(idx)
+	fixable := 0
+	for _, file := range m.LinkedEntries(idx) {
+		if file.Type == types.FileTypeFile && index.IsWithinDirectory(file.OriginalPath, managedDirs) {
+			continue
+		}
+
+		_, repoPath := m.EntrySource(file)
+		if !m.AppliesHere(file) || !fileops.PathExists(repoPath) {
+			// Reported by checkRepoPaths
+			continue
+		}
+
+		targets, err := m.EntryTargets(file)
+		if err != nil {
+			check.Details = append(check.Details, fmt.Sprintf("%s: %v", file.OriginalPath, err))
+			continue
+		}
+
+		for _, target := range targets {
+			switch {
+			case !fileops.IsSymlink(target) && !fileops.PathExists(target):
+				check.Details = append(check.Details, fmt.Sprintf("%s - Missing", target))
+				fixable++
+			case !fileops.IsSymlink(target):
+				check.Details = append(check.Details, fmt.Sprintf("%s - Not a symlink", target))
+			case !fileops.PathExists(target):
+				check.Details = append(check.Details, fmt.Sprintf("%s - Dangling symlink", target))
+				fixable++
+			default:
+				if link, err := os.Readlink(target); err == nil && resolveLink(target, link) != repoPath {
+					check.Details = append(check.Details, fmt.Sprintf("%s - Points to %s instead of %s", target, link, repoPath))
+				}
+			}
+		}
+	}
+
+	if len(check.Details) == 0 {
+		return check
+	}
+
+	check.Severity = SeverityFailure
+	check.Summary = fmt.Sprintf("%d problem(s)", len(check.Details))
+	if fixable == len(check.Details) {
+		check.Fix = "dotman status --fix"
+		check.Repair = func() error {
+			_, err := m.Fix(types.FixOptions{})
+			return err
+		}
+	} else {
+		check.Fix = "dotman status --fix for missing links; move conflicting files aside and re-run it for the rest"
+	}
+	return check
+}
+
+// resolveLink turns a possibly relative symlink target into an absolute path
+func resolveLink(linkPath, target string) string {
+	if filepath.IsAbs(target) {
+		return filepath.Clean(target)
+	}
+	return filepath.Join(filepath.Dir(linkPath), target)
+}
+
+// checkGit reports on the git binary, the repository, its remote and upstream
+func (m *Manager) checkGit() []DoctorCheck {
+	version, err := git.Version()
+	if err != nil {
+		return []DoctorCheck{{
+			Name:     "Git",
+			Severity: SeverityFailure,
+			Summary:  err.Error(),
+			Fix:      "install git and make sure it is in PATH",
+		}}
+	}
+	checks := []DoctorCheck{{Name: "Git", Summary: "version " + version}}
+
+	if !git.IsGitRepo(m.cfg.DotmanDir) {
+		return append(checks, DoctorCheck{
+			Name:     "Git repository",
+			Severity: SeverityFailure,
+			Summary:  fmt.Sprintf("%s is not a git repository", m.cfg.DotmanDir),
+			Fix:      "dotman init",
+		})
+	}
+
+	remote := DoctorCheck{Name: "Remote"}
+	remoteURL, err := git.GetRemoteURL(m.cfg.DotmanDir)
+	if err != nil {
+		remote.Severity = SeverityWarning
+		remote.Summary = "not configured"
+		remote.Fix = "dotman remote set <url>"
+		return append(checks, remote)
+	}
+	remote.Summary = remoteURL
+	checks = append(checks, remote)
+
+	upstream := DoctorCheck{Name: "Upstream"}
+	upstreamBranch, err := git.GetUpstream(m.cfg.DotmanDir)
+	if err != nil {
+		branch, _ := git.GetCurrentBranch(m.cfg.DotmanDir)
+		upstream.Severity = SeverityWarning
+		upstream.Summary = "no upstream branch configured"
+		upstream.Fix = fmt.Sprintf("git -C %s push --set-upstream origin %s", m.cfg.DotmanDir, branch)
+		return append(checks, upstream)
+	}
+
+	ahead, behind, err := git.AheadBehind(m.cfg.DotmanDir)
+	if err != nil {
+		upstream.Severity = SeverityWarning
+		upstream.Summary = fmt.Sprintf("%s (%v)", upstreamBranch, err)
+		return append(checks, upstream)
+	}
+
+	upstream.Summary = fmt.Sprintf("%s, %d ahead, %d behind", upstreamBranch, ahead, behind)
+	switch {
+	case ahead > 0 && behind > 0:
+		upstream.Severity = SeverityWarning
+		upstream.Fix = "dotman sync --pull, then dotman sync --push"
+	case behind > 0:
+		upstream.Severity = SeverityWarning
+		upstream.Fix = "dotman sync --pull"
+	case ahead > 0:
+		upstream.Severity = SeverityWarning
+		upstream.Fix = "dotman sync --push"
+	}
+	return append(checks, upstream)
+}
+
+// checkGitignore reports managed paths hidden by a tracked .gitignore
+func (m *Manager) checkGitignore(idx *types.Index) DoctorCheck {
+	check := DoctorCheck{Name: ".gitignore", Summary: "no managed files are ignored"}
+
+	if !git.IsTracked(m.cfg.DotmanDir, ".gitignore") {
+		check.Summary = "not tracked"
+		return check
+	}
+
+	var paths, dirs []string
+	for _, file := range index.GetAllFiles(idx) {
+		paths = append(paths, file.RepoPath)
+		if file.Type == types.FileTypeDirectory {
+			dirs = append(dirs, file.RepoPath)
+		}
+	}
+
+	ignored, err := git.CheckIgnored(m.cfg.DotmanDir, paths...)
+	if err != nil {
+		check.Severity = SeverityWarning
+		check.Summary = err.Error()
+		return check
+	}
+	for _, path := range ignored {
+		check.Details = append(check.Details, fmt.Sprintf("%s is ignored", path))
+	}
+
+	if len(dirs) > 0 {
+		hidden, err := git.IgnoredFiles(m.cfg.DotmanDir, dirs...)
+		if err == nil {
+			for _, path := range hidden {
+				check.Details = append(check.Details, fmt.Sprintf("%s is ignored and won't be committed", path))
+			}
+		}
+	}
+
+	if len(check.Details) == 0 {
+		return check
+	}
+
+	check.Severity = SeverityWarning
+	check.Summary = fmt.Sprintf("%d managed path(s) hidden by ignore rules", len(check.Details))
+	check.Fix = fmt.Sprintf("edit %s (check with: git -C %s check-ignore -v <path>)",
+		filepath.Join(m.cfg.DotmanDir, ".gitignore"), m.cfg.DotmanDir)
+	return check
+}
@@ -0,0 +1,143 @@
+// Package dotman manages a dotfiles repository: it moves files into the
+// repo, links them back into $HOME and keeps the index and git history up to
+// date. The dotman command is a thin layer over this package.
+//
+// A Manager works on one repository. Progress is reported as Events to an
+// EventHandler, and every operation returns a structured result:
+//
+//	m := dotman.New(cfg, dotman.WithEvents(dotman.EventFunc(func(e dotman.Event) {
+//		log.Println(e.Message)
+//	})))
+//	result, err := m.Add([]string{"~/.bashrc"}, types.AddOptions{})
+//
+// A Manager doesn't take the dotman lock; callers that run alongside the
+// dotman command should hold it (see the lock package used by the CLI).
+package dotman
+
+import (
+	"fmt"
+
+	"github.com/Merith-TK/dotman/internal/backup"
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// Manager runs dotman operations on the repository described by its config
+type Manager struct {
+	cfg      *types.Config
+	events   EventHandler
+	shadowed map[string]string
+
+	// batched holds the commit messages of a batched Add until it commits
+	batched  []string
+	batching bool
+}
+
+// Option configures a Manager
+type Option func(*Manager)
+
+// WithEvents delivers progress events to handler
+func WithEvents(handler EventHandler) Option {
+	return func(m *Manager) {
+		m.events = handler
+	}
+}
+
+// WithShadowed marks targets that a higher priority source deploys. The map
+// goes from target path to the name of the winning source; those entries are
+// skipped by Deploy, Fix and Status.
+func WithShadowed(shadowed map[string]string) Option {
+	return func(m *Manager) {
+		m.shadowed = shadowed
+	}
+}
+
+// New returns a Manager for the repository described by cfg
+func New(cfg *types.Config, opts ...Option) *Manager {
+	m := &Manager{cfg: cfg}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Open returns a Manager for the default repository in ~/.dotman
+func Open(opts ...Option) (*Manager, error) {
+	cfg, err := config.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize config: %w", err)
+	}
+	return New(cfg, opts...), nil
+}
+
+// Config returns the configuration the Manager works with
+func (m *Manager) Config() *types.Config {
+	return m.cfg
+}
+
+// backup saves path to the machine's backup store before it is replaced or
+// deleted
+func (m *Manager) backup(path string) error {
+	location, err := backup.New(m.cfg).Save(path)
+	if err != nil {
+		return err
+	}
+	m.info(path, "Backed up %s to %s", path, location)
+	return nil
+}
+
+// ShadowedBy returns the source that deploys path instead of this one
+func (m *Manager) ShadowedBy(path string) (string, bool) {
+	winner, found := m.shadowed[path]
+	return winner, found
+}
+
+// Result lists what an operation did with each path it was given
+type Result struct {
+	Operations []types.Operation
+}
+
+// Succeeded returns the number of paths that were handled
+func (r *Result) Succeeded() int {
+	count := 0
+	for _, op := range r.Operations {
+		if op.Success {
+			count++
+		}
+	}
+	return count
+}
+
+// Failed returns the operations that failed
+func (r *Result) Failed() []types.Operation {
+	var failed []types.Operation
+	for _, op := range r.Operations {
+		if !op.Success {
+			failed = append(failed, op)
+		}
+	}
+	return failed
+}
+
+// Err returns nil when every path was handled, the error of the only path
+// when that one failed, and a *types.BatchError otherwise
+func (r *Result) Err() error {
+	failed := r.Failed()
+	switch {
+	case len(failed) == 0:
+		return nil
+	case len(r.Operations) == 1:
+		return failed[0].Error
+	}
+	return &types.BatchError{Succeeded: r.Succeeded(), Failed: failed}
+}
+
+// add records the outcome for one path
+func (r *Result) add(path, message string, err error) {
+	r.Operations = append(r.Operations, types.Operation{
+		Success: err == nil,
+		Path:    path,
+		Error:   err,
+		Message: message,
+	})
+}
@@ -0,0 +1,156 @@
+package dotman
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// recorder collects the events of a Manager
+type recorder struct {
+	events []Event
+}
+
+func (r *recorder) HandleEvent(e Event) {
+	r.events = append(r.events, e)
+}
+
+// has reports whether an event of kind about path was reported
+func (r *recorder) has(kind EventKind, path string) bool {
+	for _, e := range r.events {
+		if e.Kind == kind && e.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// count returns the number of events of kind
+func (r *recorder) count(kind EventKind) int {
+	n := 0
+	for _, e := range r.events {
+		if e.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// setupGit keeps git away from the user's configuration and gives it an
+// identity to commit with
+func setupGit(t *testing.T) {
+	t.Helper()
+	gitconfig := filepath.Join(t.TempDir(), "gitconfig")
+	content := "[user]\n\tname = dotman\n\temail = dotman@example.com\n[init]\n\tdefaultBranch = main\n"
+	if err := os.WriteFile(gitconfig, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", gitconfig)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+}
+
+// newManager returns a Manager for the repo in a new temporary home, along
+// with the recorder of its events
+func newManager(t *testing.T) (*Manager, *recorder) {
+	t.Helper()
+	home := t.TempDir()
+	dotmanDir := filepath.Join(home, config.DotmanDirName)
+	cfg := &types.Config{
+		DotmanDir: dotmanDir,
+		HomeDir:   home,
+		IndexFile: filepath.Join(dotmanDir, config.IndexFileName),
+		StateDir:  filepath.Join(home, ".local", "state", config.StateDirName),
+		ConfigDir: filepath.Join(home, ".config", config.StateDirName),
+		Profile:   config.DefaultProfile,
+		Hostname:  "testhost",
+	}
+
+	events := &recorder{}
+	return New(cfg, WithEvents(events)), events
+}
+
+// homeFile returns the path of name in the home of m
+func homeFile(m *Manager, name string) string {
+	return filepath.Join(m.cfg.HomeDir, name)
+}
+
+// writeFile creates path with content, and the directories above it
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readFile returns the content of path
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+// add manages the named files of the home of m, which are created first
+func add(t *testing.T, m *Manager, names ...string) {
+	t.Helper()
+	var paths []string
+	for _, name := range names {
+		writeFile(t, homeFile(m, name), name+"\n")
+		paths = append(paths, homeFile(m, name))
+	}
+	if _, err := m.Add(paths, types.AddOptions{}); err != nil {
+		t.Fatalf("Add(%q): %v", names, err)
+	}
+}
+
+// wantLink checks that path is a link to the repo copy of name
+func wantLink(t *testing.T, m *Manager, path, name string) {
+	t.Helper()
+	link, err := os.Readlink(path)
+	if err != nil {
+		t.Errorf("%s is not a link: %v", path, err)
+		return
+	}
+	if want := filepath.Join(m.cfg.DotmanDir, name); link != want {
+		t.Errorf("%s links to %s, want %s", path, link, want)
+	}
+}
+
+// loadIndex returns the index of m
+func loadIndex(t *testing.T, m *Manager) *types.Index {
+	t.Helper()
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return idx
+}
+
+// runGit runs git in dir and returns its trimmed output
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// lastCommit returns the subject of the newest commit of the repo of m
+func lastCommit(t *testing.T, m *Manager) string {
+	t.Helper()
+	return runGit(t, m.cfg.DotmanDir, "log", "-1", "--format=%s")
+}
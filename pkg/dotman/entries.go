@@ -0,0 +1,233 @@
+package dotman
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/git"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// EntrySource returns the layer that supplies an entry on this machine and
+// the absolute repo path its targets link to
+func (m *Manager) EntrySource(file types.ManagedFile) (string, string) {
+	layer, layerPath := config.ResolveLayer(m.cfg, file.RepoPath)
+	return layer, filepath.Join(m.cfg.DotmanDir, layerPath)
+}
+
+// EntryTargets returns the locations an entry is deployed to: its original
+// path, or every resolved match for a glob entry
+func (m *Manager) EntryTargets(file types.ManagedFile) ([]string, error) {
+	if file.Glob == nil {
+		return []string{file.OriginalPath}, nil
+	}
+	return config.ResolveGlobTarget(m.cfg, file.Glob)
+}
+
+// TargetLink is a location in $HOME and the repo path linked there
+type TargetLink struct {
+	Target string
+	Source string
+}
+
+// EntryLinks returns every link an entry deploys, with partial directories
+// expanded into their tracked files
+func (m *Manager) EntryLinks(file types.ManagedFile) ([]TargetLink, error) {
+	entries := []types.ManagedFile{file}
+	if file.Partial != nil {
+		entries = m.PartialChildren(file)
+	}
+
+	var links []TargetLink
+	for _, entry := range entries {
+		_, source := m.EntrySource(entry)
+		targets, err := m.EntryTargets(entry)
+		if err != nil {
+			return nil, err
+		}
+		for _, target := range targets {
+			links = append(links, TargetLink{Target: target, Source: source})
+		}
+	}
+	return links, nil
+}
+
+// AppliesHere reports whether an entry has content for this machine. Entries
+// whose only copies are in other machines' overlay layers don't.
+func (m *Manager) AppliesHere(file types.ManagedFile) bool {
+	if len(file.Layers) == 0 {
+		return true
+	}
+	_, source := m.EntrySource(file)
+	return fileops.PathExists(source)
+}
+
+// LinkedEntries returns the index entries as they are linked into $HOME:
+// partial directories are replaced by their tracked files
+func (m *Manager) LinkedEntries(idx *types.Index) []types.ManagedFile {
+	var entries []types.ManagedFile
+	for _, file := range index.GetAllFiles(idx) {
+		if file.Partial != nil {
+			entries = append(entries, m.PartialChildren(file)...)
+			continue
+		}
+		entries = append(entries, file)
+	}
+	return entries
+}
+
+// PartialChildren returns the files of a partial directory that are in the
+// repo, in any of its layers, as file entries of their own
+func (m *Manager) PartialChildren(file types.ManagedFile) []types.ManagedFile {
+	seen := make(map[string]bool)
+	for _, layer := range append([]string{config.BaseLayer}, file.Layers...) {
+		root := filepath.Join(m.cfg.DotmanDir, config.LayerPath(layer, file.RepoPath))
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if rel, err := filepath.Rel(root, path); err == nil {
+				seen[rel] = true
+			}
+			return nil
+		})
+	}
+
+	rels := make([]string, 0, len(seen))
+	for rel := range seen {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+
+	children := make([]types.ManagedFile, 0, len(rels))
+	for _, rel := range rels {
+		children = append(children, types.ManagedFile{
+			OriginalPath: filepath.Join(file.OriginalPath, rel),
+			RepoPath:     filepath.Join(file.RepoPath, rel),
+			Type:         types.FileTypeFile,
+			AddedDate:    file.AddedDate,
+			Layers:       file.Layers,
+		})
+	}
+	return children
+}
+
+// PartialCandidates returns the files in a partial directory in $HOME that
+// match its include patterns but are not tracked yet
+func PartialCandidates(file types.ManagedFile) []string {
+	if fileops.IsSymlink(file.OriginalPath) {
+		return nil
+	}
+
+	var candidates []string
+	filepath.WalkDir(file.OriginalPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == file.OriginalPath {
+			return nil
+		}
+		rel, err := filepath.Rel(file.OriginalPath, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if config.PartialExcluded(file.Partial, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Symlinks are tracked files or belong to something else
+		if d.Type().IsRegular() && config.PartialIncluded(file.Partial, rel) {
+			candidates = append(candidates, path)
+		}
+		return nil
+	})
+	return candidates
+}
+
+// ResolveManagedPath maps a path in $HOME to its path within the repo, using
+// the entry that manages it or the managed directory that contains it
+func (m *Manager) ResolveManagedPath(idx *types.Index, path string) (string, *types.ManagedFile, error) {
+	expandedPath, err := config.ExpandPath(m.cfg, path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to expand path: %w", err)
+	}
+
+	for _, file := range index.GetAllFiles(idx) {
+		if file.OriginalPath == expandedPath {
+			_, layerPath := config.ResolveLayer(m.cfg, file.RepoPath)
+			return layerPath, &file, nil
+		}
+	}
+
+	for _, file := range index.GetAllFiles(idx) {
+		targets, err := m.EntryTargets(file)
+		if err != nil {
+			continue
+		}
+		for _, target := range targets {
+			_, layerPath := config.ResolveLayer(m.cfg, file.RepoPath)
+			if target == expandedPath {
+				return layerPath, &file, nil
+			}
+			if file.Type == types.FileTypeDirectory && index.IsWithinDirectory(expandedPath, []string{target}) {
+				rest, err := filepath.Rel(target, expandedPath)
+				if err != nil {
+					continue
+				}
+				return filepath.Join(layerPath, rest), &file, nil
+			}
+		}
+	}
+
+	return "", nil, types.Errorf(types.ErrNotManaged, "path is not managed by dotman: %s", expandedPath)
+}
+
+// homePath returns an absolute path in $HOME as $HOME/<rel> for commit
+// messages, or fallback if it is outside $HOME
+func (m *Manager) homePath(path, fallback string) string {
+	if rel, err := config.RelativeToHome(m.cfg, path); err == nil {
+		return "$HOME/" + rel
+	}
+	return fallback
+}
+
+// commit updates the content digests of the entries with the given repo paths
+// and of new repo copies, saves the index and commits everything in the repo
+// with message. Other entries changed outside dotman keep their digest, so
+// fsck still reports them, and get a warning. During a batched Add, the
+// commit is held back.
+func (m *Manager) commit(idx *types.Index, message string, repoPaths ...string) error {
+	touched := func(file types.ManagedFile) bool { return containsPath(repoPaths, file.RepoPath) }
+	for _, path := range index.RefreshDigests(idx, m.cfg.DotmanDir, touched) {
+		homePath := filepath.Join(m.cfg.HomeDir, homeRelativeRepoPath(idx, path))
+		m.warn(homePath, nil, "%s was changed outside dotman; its digest is kept so 'dotman fsck' reports it", homePath)
+	}
+
+	if err := index.Save(idx, m.cfg.IndexFile); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+
+	if m.batching {
+		m.batched = append(m.batched, message)
+		return nil
+	}
+	return m.commitAll(message)
+}
+
+// commitAll commits everything in the repo with message
+func (m *Manager) commitAll(message string) error {
+	if err := git.Add(m.cfg.DotmanDir); err != nil {
+		return fmt.Errorf("failed to stage changes: %w", err)
+	}
+
+	if err := git.Commit(m.cfg.DotmanDir, message); err != nil {
+		return fmt.Errorf("failed to commit changes: %w", err)
+	}
+	return nil
+}
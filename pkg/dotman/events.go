@@ -0,0 +1,89 @@
+package dotman
+
+import (
+	"fmt"
+
+	"github.com/Merith-TK/dotman/internal/hooks"
+)
+
+// EventKind classifies a progress event
+type EventKind int
+
+const (
+	// EventInfo is general progress, such as the start of an operation
+	EventInfo EventKind = iota
+	// EventLinked means a symlink to the repo was created at Path
+	EventLinked
+	// EventSkipped means Path was left alone; Message says why
+	EventSkipped
+	// EventPlanned is what a dry run would do
+	EventPlanned
+	// EventWarning is a problem that doesn't stop the operation
+	EventWarning
+	// EventFailed means Path couldn't be handled; the operation goes on with
+	// the rest
+	EventFailed
+	// EventProblem is a broken link that needs manual attention
+	EventProblem
+	// EventFixed is a link that was (or in a dry run would be) repaired
+	EventFixed
+)
+
+// Event reports progress of a Manager operation
+type Event struct {
+	Kind EventKind
+	// Path is the path in $HOME or the repo the event is about, if any
+	Path string
+	// Message is a human-readable description of the event
+	Message string
+	// Err is the error behind a warning or problem, if any
+	Err error
+}
+
+// EventHandler receives the events of a Manager
+type EventHandler interface {
+	HandleEvent(Event)
+}
+
+// EventFunc adapts a function to an EventHandler
+type EventFunc func(Event)
+
+// HandleEvent calls f(e)
+func (f EventFunc) HandleEvent(e Event) {
+	f(e)
+}
+
+// emit sends an event to the handler, if there is one
+func (m *Manager) emit(kind EventKind, path string, err error, format string, args ...interface{}) {
+	if m.events == nil {
+		return
+	}
+	m.events.HandleEvent(Event{
+		Kind:    kind,
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+		Err:     err,
+	})
+}
+
+// info reports general progress
+func (m *Manager) info(path, format string, args ...interface{}) {
+	m.emit(EventInfo, path, nil, format, args...)
+}
+
+// warn reports a problem that doesn't stop the operation
+func (m *Manager) warn(path string, err error, format string, args ...interface{}) {
+	m.emit(EventWarning, path, err, format, args...)
+}
+
+// hookReport reports the progress of hooks and scripts as events; in a dry
+// run, what would run is reported as planned
+func (m *Manager) hookReport(dryRun bool) hooks.Report {
+	kind := EventInfo
+	if dryRun {
+		kind = EventPlanned
+	}
+	return func(message string) {
+		m.emit(kind, "", nil, "%s", message)
+	}
+}
@@ -0,0 +1,157 @@
+package dotman
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/digest"
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/git"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// ContentState is the result of checking a repo copy against its recorded
+// digest
+type ContentState string
+
+const (
+	ContentOK         ContentState = "OK"
+	ContentMissing    ContentState = "Repository content missing"
+	ContentHashFailed ContentState = "Failed to hash"
+	ContentUnrecorded ContentState = "No digest recorded"
+	// ContentCheckedOut matches the recorded digest but not HEAD, e.g. after
+	// a checkout
+	ContentCheckedOut  ContentState = "Differs from HEAD but matches the recorded digest"
+	ContentUncommitted ContentState = "Changed outside dotman (uncommitted)"
+	ContentCommitted   ContentState = "Changed outside dotman (committed without dotman)"
+)
+
+// ContentCheck is the state of one repo copy of an entry
+type ContentCheck struct {
+	File  types.ManagedFile
+	Layer string
+	State ContentState
+	// Err is set when State is ContentHashFailed
+	Err error
+}
+
+// FsckReport lists the state of every repo copy
+type FsckReport struct {
+	// Managed is the number of index entries
+	Managed int
+	Checks  []ContentCheck
+}
+
+// Changed returns the number of copies changed outside dotman or missing
+func (r *FsckReport) Changed() int {
+	changed := 0
+	for _, check := range r.Checks {
+		if check.State != ContentOK && check.State != ContentUnrecorded {
+			changed++
+		}
+	}
+	return changed
+}
+
+// Unrecorded returns the number of copies without a recorded digest
+func (r *FsckReport) Unrecorded() int {
+	unrecorded := 0
+	for _, check := range r.Checks {
+		if check.State == ContentUnrecorded {
+			unrecorded++
+		}
+	}
+	return unrecorded
+}
+
+// Fsck verifies every repo copy, including overlay copies, against the
+// digest recorded at the last dotman commit and against the git HEAD
+func (m *Manager) Fsck() (*FsckReport, error) {
+	if !config.DotmanDirExists(m.cfg) {
+		return nil, types.Errorf(types.ErrRepoMissing, "dotman directory does not exist: %s", m.cfg.DotmanDir)
+	}
+
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+
+	report := &FsckReport{Managed: index.Count(idx)}
+	isRepo := git.IsGitRepo(m.cfg.DotmanDir)
+
+	for _, file := range index.GetAllFiles(idx) {
+		// The base copy is optional for entries with overlay copies
+		for _, layer := range append([]string{config.BaseLayer}, file.Layers...) {
+			repoRelPath := config.LayerPath(layer, file.RepoPath)
+			repoPath := filepath.Join(m.cfg.DotmanDir, repoRelPath)
+			recorded := file.Digest
+			if layer != config.BaseLayer {
+				recorded = file.LayerDigests[layer]
+			}
+
+			check := ContentCheck{File: file, Layer: layer}
+			if !fileops.PathExists(repoPath) {
+				if layer == config.BaseLayer && len(file.Layers) > 0 {
+					continue
+				}
+				check.State = ContentMissing
+				report.Checks = append(report.Checks, check)
+				continue
+			}
+
+			sum, err := digest.Path(repoPath)
+			if err != nil {
+				check.State, check.Err = ContentHashFailed, err
+				report.Checks = append(report.Checks, check)
+				continue
+			}
+
+			// Uncommitted changes show the edit hasn't been through git yet either
+			dirty := false
+			if isRepo {
+				dirty, _ = git.PathHasChanges(m.cfg.DotmanDir, repoRelPath)
+			}
+
+			switch {
+			case recorded == "":
+				check.State = ContentUnrecorded
+			case recorded == sum && dirty:
+				check.State = ContentCheckedOut
+			case recorded == sum:
+				check.State = ContentOK
+			case dirty:
+				check.State = ContentUncommitted
+			default:
+				check.State = ContentCommitted
+			}
+			report.Checks = append(report.Checks, check)
+		}
+	}
+
+	return report, nil
+}
+
+// RecordDigests records the digests of the current content and commits it.
+// It reports whether there was a git repository to commit to.
+func (m *Manager) RecordDigests() (bool, error) {
+	if !config.DotmanDirExists(m.cfg) {
+		return false, types.Errorf(types.ErrRepoMissing, "dotman directory does not exist: %s", m.cfg.DotmanDir)
+	}
+
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load index: %w", err)
+	}
+
+	index.UpdateDigests(idx, m.cfg.DotmanDir)
+	if !git.IsGitRepo(m.cfg.DotmanDir) {
+		if err := index.Save(idx, m.cfg.IndexFile); err != nil {
+			return false, fmt.Errorf("failed to save index: %w", err)
+		}
+		return false, nil
+	}
+
+	return true, m.commit(idx, "Fsck: update content digests")
+}
@@ -0,0 +1,164 @@
+package dotman
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Merith-TK/dotman/internal/backup"
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/hooks"
+	"github.com/Merith-TK/dotman/internal/importer"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// ImportPlan lists what an import would do with each target
+type ImportPlan = importer.Plan
+
+// ImportItem is one target of an ImportPlan
+type ImportItem = importer.Item
+
+// PlanStowImport plans importing the packages of a GNU Stow directory (all
+// of them when none are named) whose links point from target
+func (m *Manager) PlanStowImport(stowDir, target string, packages []string, dotfiles bool, opts types.ImportOptions) (*ImportPlan, error) {
+	plan, err := importer.Stow(stowDir, target, packages, dotfiles)
+	if err != nil {
+		return nil, err
+	}
+	return m.checkImport(plan, opts)
+}
+
+// PlanBareImport plans importing the files tracked by a bare repository
+// whose work tree is the home directory
+func (m *Manager) PlanBareImport(gitDir string, opts types.ImportOptions) (*ImportPlan, error) {
+	plan, err := importer.Bare(gitDir, m.cfg.HomeDir)
+	if err != nil {
+		return nil, err
+	}
+	return m.checkImport(plan, opts)
+}
+
+// PlanChezmoiImport plans importing a chezmoi source directory
+func (m *Manager) PlanChezmoiImport(sourceDir string, opts types.ImportOptions) (*ImportPlan, error) {
+	plan, err := importer.Chezmoi(sourceDir, m.cfg.HomeDir)
+	if err != nil {
+		return nil, err
+	}
+	return m.checkImport(plan, opts)
+}
+
+// checkImport decides for every item of plan whether it can be imported
+func (m *Manager) checkImport(plan *ImportPlan, opts types.ImportOptions) (*ImportPlan, error) {
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+	importer.Check(m.cfg, idx, plan, opts.Force)
+	return plan, nil
+}
+
+// Import places the content of every item of plan that isn't skipped in the
+// repo and links its target, in a single commit. The error is a
+// *types.BatchError when some items failed; the result has the outcome of
+// every item.
+func (m *Manager) Import(plan *ImportPlan) (*Result, error) {
+	idx, err := m.prepareRepo(false)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	imported := 0
+	for _, item := range plan.Items {
+		if item.Skip != "" {
+			continue
+		}
+		if err := m.importItem(item); err != nil {
+			result.add(item.Target, "", err)
+			continue
+		}
+
+		relativePath, _ := config.RelativeToHome(m.cfg, item.Target)
+		index.AddFile(idx, item.Target, relativePath, item.Type)
+		m.emit(EventLinked, item.Target, nil, "Imported %s", item.Target)
+		result.add(item.Target, "", nil)
+		imported++
+	}
+
+	if imported > 0 {
+		if err := m.commit(idx, fmt.Sprintf("Import %d file(s) from %s", imported, plan.Tool)); err != nil {
+			return result, err
+		}
+
+		if err := hooks.Run(m.cfg, hooks.PostAdd, false, m.hookReport(false)); err != nil {
+			m.warn("", err, "%v", err)
+		}
+	}
+
+	return result, result.Err()
+}
+
+// importItem places one item's content in the repo and links its target
+func (m *Manager) importItem(item ImportItem) error {
+	relativePath, err := config.RelativeToHome(m.cfg, item.Target)
+	if err != nil {
+		return fmt.Errorf("failed to get relative path: %w", err)
+	}
+	repoPath := filepath.Join(m.cfg.DotmanDir, relativePath)
+
+	if item.Move {
+		if err := fileops.MoveToRepo(item.Source, repoPath); err != nil {
+			return fmt.Errorf("failed to move file to repo: %w", err)
+		}
+	} else {
+		if err := fileops.MkdirAll(filepath.Dir(repoPath), 0755); err != nil {
+			return fmt.Errorf("failed to create destination directory: %w", err)
+		}
+		if err := fileops.CopyPath(item.Source, repoPath); err != nil {
+			fileops.RemoveAll(repoPath)
+			return fmt.Errorf("failed to copy %s to repo: %w", item.Source, err)
+		}
+	}
+
+	if item.Mode != 0 {
+		if err := os.Chmod(repoPath, item.Mode); err != nil {
+			return fmt.Errorf("failed to set permissions: %w", err)
+		}
+	}
+
+	if !item.Move && (fileops.PathExists(item.Target) || fileops.IsSymlink(item.Target)) {
+		if item.Replace {
+			location, err := backup.New(m.cfg).Save(item.Target)
+			if err != nil {
+				fileops.RemoveAll(repoPath)
+				return err
+			}
+			m.info(item.Target, "Backed up %s to %s", item.Target, location)
+		}
+		if err := fileops.RemoveAll(item.Target); err != nil {
+			fileops.RemoveAll(repoPath)
+			return fmt.Errorf("failed to remove %s: %w", item.Target, err)
+		}
+	}
+
+	if err := fileops.CreateSymlink(item.Target, repoPath); err != nil {
+		// Put the file back if it can't be linked
+		if item.Move {
+			fileops.Rename(repoPath, item.Target)
+		} else {
+			fileops.RemoveAll(repoPath)
+		}
+		return fmt.Errorf("failed to create symlink: %w", err)
+	}
+
+	// Applied last, a read-only directory can't receive the link
+	if item.DirMode != 0 {
+		if err := os.Chmod(filepath.Dir(item.Target), item.DirMode); err != nil {
+			return fmt.Errorf("failed to set directory permissions: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package dotman
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/git"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// MoveToLayer moves the copy of each entry that this machine uses into
+// layer (base or an overlay such as hosts/<hostname>) and relinks it, all in
+// one commit. Copies in other layers stay where they are. The error is a
+// *types.BatchError when some paths failed; the result has the outcome of
+// every path.
+func (m *Manager) MoveToLayer(paths []string, layer string, opts types.MoveOptions) (*Result, error) {
+	if !config.DotmanDirExists(m.cfg) {
+		return nil, types.Errorf(types.ErrRepoMissing, "dotman directory does not exist: %s", m.cfg.DotmanDir)
+	}
+
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+
+	result := &Result{}
+	var moved, repoPaths []string
+
+	for _, path := range paths {
+		from, file, err := m.moveToLayer(idx, path, layer, opts)
+		if err != nil {
+			result.add(path, "", err)
+			continue
+		}
+
+		homePath := m.homePath(file.OriginalPath, "$HOME/"+file.RepoPath)
+		moved = append(moved, fmt.Sprintf("%s (from %s)", homePath, from))
+		repoPaths = append(repoPaths, file.RepoPath)
+		result.add(file.OriginalPath, homePath, nil)
+	}
+
+	if len(moved) > 0 && !opts.DryRun {
+		commitMsg := fmt.Sprintf("Move %s to layer %s", moved[0], layer)
+		if len(moved) > 1 {
+			commitMsg = fmt.Sprintf("Move %d paths to layer %s\n\n%s", len(moved), layer, strings.Join(moved, "\n"))
+		}
+		if err := m.commit(idx, commitMsg, repoPaths...); err != nil {
+			return result, err
+		}
+	}
+
+	return result, result.Err()
+}
+
+// moveToLayer moves one entry and returns the layer it came from; only idx
+// and the filesystem are changed
+func (m *Manager) moveToLayer(idx *types.Index, path, layer string, opts types.MoveOptions) (string, *types.ManagedFile, error) {
+	expandedPath, err := config.ExpandPath(m.cfg, path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to expand path: %w", err)
+	}
+
+	file, found := index.FindFile(idx, expandedPath)
+	if !found {
+		return "", nil, types.Errorf(types.ErrNotManaged, "path is not managed by dotman: %s", expandedPath)
+	}
+	if file.Glob != nil || file.Partial != nil {
+		return "", nil, fmt.Errorf("glob entries and partial directories can't change layers: %s", expandedPath)
+	}
+
+	from, source := m.EntrySource(*file)
+	if !fileops.PathExists(source) {
+		return "", nil, fmt.Errorf("%s has no copy for this machine", expandedPath)
+	}
+	if from == layer {
+		return "", nil, fmt.Errorf("%s is already in layer %s", expandedPath, layer)
+	}
+
+	to := config.LayerPath(layer, file.RepoPath)
+	if _, err := os.Lstat(filepath.Join(m.cfg.DotmanDir, to)); err == nil {
+		return "", nil, types.Errorf(types.ErrConflict, "layer %s already has a copy of %s", layer, expandedPath)
+	}
+
+	// Only a deployed (or missing) link can be replaced safely
+	if _, err := os.Lstat(expandedPath); err == nil && !fileops.IsSymlink(expandedPath) {
+		return "", nil, types.Errorf(types.ErrConflict, "%s exists and is not a symlink; resolve it before moving", expandedPath)
+	}
+
+	if opts.DryRun {
+		m.emit(EventPlanned, expandedPath, nil, "Would move %s from layer %s to %s", expandedPath, from, layer)
+		return from, file, nil
+	}
+
+	m.info(expandedPath, "Moving %s from layer %s to %s...", expandedPath, from, layer)
+
+	fromPath := config.LayerPath(from, file.RepoPath)
+	dest := filepath.Join(m.cfg.DotmanDir, to)
+	if err := fileops.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", nil, fmt.Errorf("failed to create layer directory: %w", err)
+	}
+	if git.IsTracked(m.cfg.DotmanDir, fromPath) {
+		err = git.Move(m.cfg.DotmanDir, fromPath, to)
+	} else {
+		err = fileops.Rename(source, dest)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	if from != config.BaseLayer {
+		index.RemoveLayer(idx, expandedPath, from)
+	}
+	if layer != config.BaseLayer {
+		index.AddLayer(idx, expandedPath, layer)
+	}
+
+	if fileops.IsSymlink(expandedPath) {
+		if err := fileops.Remove(expandedPath); err != nil {
+			return "", nil, fmt.Errorf("failed to remove old symlink: %w", err)
+		}
+	}
+	movedFile, _ := index.FindFile(idx, expandedPath)
+	_, repoPath := m.EntrySource(*movedFile)
+	if err := fileops.CreateSymlink(expandedPath, repoPath); err != nil {
+		return "", nil, fmt.Errorf("failed to create symlink: %w", err)
+	}
+
+	m.emit(EventLinked, expandedPath, nil, "Moved %s to layer %s", expandedPath, layer)
+	return from, movedFile, nil
+}
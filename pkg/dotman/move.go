@@ -0,0 +1,195 @@
+package dotman
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/git"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// Move moves a managed path to a new location in the home directory: the
+// repo copies are moved with git mv, the index is updated and the link is
+// recreated at the new location, all in one commit. When a step fails, the
+// steps before it are undone.
+func (m *Manager) Move(oldPath, newPath string, opts types.MoveOptions) error {
+	if !config.DotmanDirExists(m.cfg) {
+		return types.Errorf(types.ErrRepoMissing, "dotman directory does not exist: %s", m.cfg.DotmanDir)
+	}
+
+	oldPath, err := config.ExpandPath(m.cfg, oldPath)
+	if err != nil {
+		return fmt.Errorf("failed to expand path: %w", err)
+	}
+	newPath, err = config.ExpandPath(m.cfg, newPath)
+	if err != nil {
+		return fmt.Errorf("failed to expand path: %w", err)
+	}
+
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return fmt.Errorf("failed to load index: %w", err)
+	}
+
+	managedFile, found := index.FindFile(idx, oldPath)
+	if !found {
+		return types.Errorf(types.ErrNotManaged, "path is not managed by dotman: %s", oldPath)
+	}
+	if managedFile.Glob != nil {
+		return fmt.Errorf("glob entries can't be moved; remove and add the new pattern instead")
+	}
+	if managedFile.Partial != nil {
+		return fmt.Errorf("partial directories can't be moved; remove and add the directory again instead")
+	}
+
+	// The new location must be free and belong to no other entry
+	if !config.IsInsideHome(m.cfg, newPath) {
+		return types.Errorf(types.ErrOutsideHome, "path must be inside home directory: %s", newPath)
+	}
+	if _, err := os.Lstat(newPath); err == nil {
+		return types.Errorf(types.ErrConflict, "destination already exists: %s", newPath)
+	}
+	if newPath == oldPath || strings.HasPrefix(newPath, oldPath+string(filepath.Separator)) {
+		return fmt.Errorf("cannot move %s into itself", oldPath)
+	}
+	if index.IsManaged(idx, newPath) || index.IsWithinDirectory(newPath, index.ManagedDirectories(idx)) {
+		return types.Errorf(types.ErrAlreadyManaged, "destination is already managed: %s", newPath)
+	}
+
+	oldRepoPath := managedFile.RepoPath
+	newRepoPath, err := config.RelativeToHome(m.cfg, newPath)
+	if err != nil {
+		return fmt.Errorf("failed to get relative path: %w", err)
+	}
+	if config.ShouldIgnoreRepoPath(m.cfg, newRepoPath) {
+		return fmt.Errorf("refusing to track repository metadata: %s", newRepoPath)
+	}
+
+	// Only a deployed (or missing) link can be replaced safely
+	if _, err := os.Lstat(oldPath); err == nil && !fileops.IsSymlink(oldPath) {
+		return types.Errorf(types.ErrConflict, "%s exists and is not a symlink; resolve it before moving", oldPath)
+	}
+
+	// Every layer copy moves along with the entry
+	var moves []repoMove
+	for _, layer := range append([]string{config.BaseLayer}, managedFile.Layers...) {
+		from := config.LayerPath(layer, oldRepoPath)
+		to := config.LayerPath(layer, newRepoPath)
+		if _, err := os.Lstat(filepath.Join(m.cfg.DotmanDir, from)); err != nil {
+			continue
+		}
+		if fileops.PathExists(filepath.Join(m.cfg.DotmanDir, to)) {
+			return types.Errorf(types.ErrConflict, "repo path already exists: %s", to)
+		}
+		moves = append(moves, repoMove{from: from, to: to})
+	}
+
+	if opts.DryRun {
+		for _, move := range moves {
+			m.emit(EventPlanned, oldPath, nil, "Would move repo path %s to %s", move.from, move.to)
+		}
+		m.emit(EventPlanned, newPath, nil, "Would link %s", newPath)
+		if opts.KeepLink {
+			m.emit(EventPlanned, oldPath, nil, "Would link %s to %s", oldPath, newPath)
+		}
+		return nil
+	}
+
+	m.info(oldPath, "Moving %s to %s...", oldPath, newPath)
+
+	// undo holds the steps taken so far, to be undone in reverse order
+	var undo []func() error
+	rollback := func(err error) error {
+		for i := len(undo) - 1; i >= 0; i-- {
+			if undoErr := undo[i](); undoErr != nil {
+				m.warn(oldPath, undoErr, "failed to undo the move of %s: %v", oldPath, undoErr)
+			}
+		}
+		return err
+	}
+
+	for _, move := range moves {
+		if err := m.moveRepoPath(move); err != nil {
+			return rollback(err)
+		}
+		reverse := repoMove{from: move.to, to: move.from}
+		undo = append(undo, func() error {
+			if err := m.moveRepoPath(reverse); err != nil {
+				return err
+			}
+			removeEmptyParents(filepath.Join(m.cfg.DotmanDir, reverse.from), m.cfg.DotmanDir)
+			return nil
+		})
+	}
+
+	if oldTarget, err := os.Readlink(oldPath); err == nil {
+		if err := fileops.Remove(oldPath); err != nil {
+			return rollback(fmt.Errorf("failed to remove old symlink: %w", err))
+		}
+		undo = append(undo, func() error { return fileops.Symlink(oldTarget, oldPath) })
+	}
+
+	moved := index.MoveFile(idx, oldPath, newPath, oldRepoPath, newRepoPath)
+	undo = append(undo, func() error {
+		index.MoveFile(idx, newPath, oldPath, newRepoPath, oldRepoPath)
+		return nil
+	})
+
+	movedFile, _ := index.FindFile(idx, newPath)
+	_, repoPath := m.EntrySource(*movedFile)
+	if err := fileops.CreateSymlink(newPath, repoPath); err != nil {
+		return rollback(fmt.Errorf("failed to create symlink: %w", err))
+	}
+	undo = append(undo, func() error { return fileops.Remove(newPath) })
+
+	if opts.KeepLink {
+		if err := fileops.Symlink(newPath, oldPath); err != nil {
+			m.warn(oldPath, err, "failed to create compatibility link: %v", err)
+		} else {
+			undo = append(undo, func() error { return fileops.Remove(oldPath) })
+		}
+	}
+
+	oldRel, _ := config.RelativeToHome(m.cfg, oldPath)
+	if err := m.commit(idx, fmt.Sprintf("Move $HOME/%s to $HOME/%s", oldRel, newRepoPath)); err != nil {
+		// The index may already be saved with the new location
+		undo = append([]func() error{func() error { return index.Save(idx, m.cfg.IndexFile) }}, undo...)
+		return rollback(err)
+	}
+
+	if moved > 1 {
+		m.info(newPath, "Updated %d index entries", moved)
+	}
+	m.emit(EventLinked, newPath, nil, "Successfully moved %s to %s", oldPath, newPath)
+	return nil
+}
+
+// repoMove is a rename of a repo-relative path
+type repoMove struct{ from, to string }
+
+// moveRepoPath renames a repo path, with git mv when it is tracked
+func (m *Manager) moveRepoPath(move repoMove) error {
+	to := filepath.Join(m.cfg.DotmanDir, move.to)
+	if err := fileops.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return fmt.Errorf("failed to create destination directory: %w", err)
+	}
+	if git.IsTracked(m.cfg.DotmanDir, move.from) {
+		return git.Move(m.cfg.DotmanDir, move.from, move.to)
+	}
+	return fileops.Rename(filepath.Join(m.cfg.DotmanDir, move.from), to)
+}
+
+// removeEmptyParents removes the directories holding path while they are
+// empty, up to but not including stop
+func removeEmptyParents(path, stop string) {
+	for dir := filepath.Dir(path); dir != stop && strings.HasPrefix(dir, stop+string(filepath.Separator)); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package dotman
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/git"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// Remove takes paths out of dotman management in a single commit. What
+// happens to the content depends on opts.Mode. The error is a
+// *types.BatchError when some paths failed; the result has the outcome of
+// every path.
+func (m *Manager) Remove(paths []string, opts types.RemoveOptions) (*Result, error) {
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+
+	result := &Result{}
+	var removed []string
+
+	for _, path := range paths {
+		managedFile, err := m.remove(idx, path, opts)
+		if err != nil {
+			result.add(path, "", err)
+			continue
+		}
+
+		// Convert to $HOME relative path for commit message
+		homePath := m.homePath(managedFile.OriginalPath, "$HOME/"+managedFile.RepoPath)
+		removed = append(removed, homePath)
+		result.add(managedFile.OriginalPath, homePath, nil)
+	}
+
+	if len(removed) > 0 && !opts.DryRun {
+		verb := "Remove"
+		if opts.Mode == types.RemoveDelete {
+			verb = "Delete"
+		}
+
+		commitMsg := fmt.Sprintf("%s %s from dotman management", verb, removed[0])
+		if len(removed) > 1 {
+			commitMsg = fmt.Sprintf("%s %d paths from dotman management\n\n%s", verb, len(removed), strings.Join(removed, "\n"))
+		}
+		if err := m.commit(idx, commitMsg); err != nil {
+			return result, err
+		}
+	}
+
+	return result, result.Err()
+}
+
+// remove takes one path out of management; only idx and the filesystem are
+// changed
+func (m *Manager) remove(idx *types.Index, path string, opts types.RemoveOptions) (*types.ManagedFile, error) {
+	// Expand the path
+	expandedPath, err := config.ExpandPath(m.cfg, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand path: %w", err)
+	}
+
+	// Check if managed
+	managedFile, found := index.FindFile(idx, expandedPath)
+	if !found {
+		return nil, types.Errorf(types.ErrNotManaged, "path is not managed by dotman: %s", expandedPath)
+	}
+	file := *managedFile
+
+	_, repoPath := m.EntrySource(file)
+
+	// The default mode moves the repo copy back, which needs the link in place
+	if opts.Mode == types.RemoveRestore && file.Glob == nil && file.Partial == nil && !fileops.IsSymlink(expandedPath) {
+		if fileops.PathExists(expandedPath) {
+			return nil, types.Errorf(types.ErrConflict, "%s is not a symlink; use --index-only to drop the entry and keep the file", expandedPath)
+		}
+		return nil, fmt.Errorf("%s does not exist; use --index-only or --delete to drop the entry", expandedPath)
+	}
+
+	if opts.DryRun {
+		switch opts.Mode {
+		case types.RemoveKeepInRepo:
+			m.emit(EventPlanned, expandedPath, nil, "Would restore a copy of %s and archive %s", expandedPath, file.RepoPath)
+		case types.RemoveDelete:
+			m.emit(EventPlanned, expandedPath, nil, "Would delete %s and %s", expandedPath, file.RepoPath)
+		case types.RemoveIndexOnly:
+			m.emit(EventPlanned, expandedPath, nil, "Would drop %s from the index and archive %s", expandedPath, file.RepoPath)
+		default:
+			m.emit(EventPlanned, expandedPath, nil, "Would restore %s from %s", expandedPath, file.RepoPath)
+		}
+		return &file, nil
+	}
+
+	m.info(expandedPath, "Removing %s from dotman management...", expandedPath)
+
+	switch opts.Mode {
+	case types.RemoveKeepInRepo:
+		err = m.restoreCopies(file)
+	case types.RemoveDelete:
+		err = m.removeLinks(file)
+	case types.RemoveIndexOnly:
+		// $HOME is left as it is
+	default:
+		if file.Glob != nil || file.Partial != nil {
+			err = m.restoreGlobTargets(file, repoPath)
+		} else if err = fileops.RemoveSymlink(expandedPath, repoPath); err != nil {
+			// Remove symlink and restore original
+			err = fmt.Errorf("failed to remove symlink and restore file: %w", err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Copies in other layers belong to the same entry; git history keeps them
+	for _, layer := range append([]string{config.BaseLayer}, file.Layers...) {
+		layerRel := config.LayerPath(layer, file.RepoPath)
+		layerPath := filepath.Join(m.cfg.DotmanDir, layerRel)
+		if _, err := os.Lstat(layerPath); err != nil {
+			continue
+		}
+
+		if opts.Mode == types.RemoveKeepInRepo || opts.Mode == types.RemoveIndexOnly {
+			if err := m.archiveRepoPath(layerRel); err != nil {
+				m.warn(layerRel, err, "failed to archive %s: %v", layerRel, err)
+			}
+			continue
+		}
+		// Uncommitted edits would be lost with the repo copy
+		if err := m.backup(layerPath); err != nil {
+			m.warn(layerRel, err, "%s copy left in place: %v", layer, err)
+			continue
+		}
+		if err := fileops.RemoveAll(layerPath); err != nil {
+			m.warn(layerRel, err, "failed to remove %s copy: %v", layer, err)
+		}
+	}
+
+	// Remove from index
+	index.RemoveFile(idx, expandedPath)
+
+	return &file, nil
+}
+
+// restoreGlobTargets replaces every linked target of a glob entry or partial
+// directory with a copy of the repo content, then removes the repo copy
+func (m *Manager) restoreGlobTargets(file types.ManagedFile, repoPath string) error {
+	if err := m.restoreCopies(file); err != nil {
+		return err
+	}
+
+	if err := fileops.RemoveAll(repoPath); err != nil {
+		return fmt.Errorf("failed to remove repo copy: %w", err)
+	}
+	return nil
+}
+
+// restoreCopies replaces every linked target of an entry with a copy of the
+// repo content
+func (m *Manager) restoreCopies(file types.ManagedFile) error {
+	links, err := m.EntryLinks(file)
+	if err != nil {
+		return fmt.Errorf("failed to resolve targets: %w", err)
+	}
+
+	restored := 0
+	for _, link := range links {
+		if target, err := os.Readlink(link.Target); err != nil || target != link.Source {
+			continue
+		}
+		if err := fileops.Remove(link.Target); err != nil {
+			return fmt.Errorf("failed to remove symlink: %w", err)
+		}
+		if err := fileops.CopyPath(link.Source, link.Target); err != nil {
+			return fmt.Errorf("failed to restore %s from repo: %w", link.Target, err)
+		}
+		restored++
+	}
+
+	// Never drop the only copy of the content
+	if restored == 0 && len(links) > 0 {
+		return fmt.Errorf("no deployed targets to restore into; run 'dotman deploy' first")
+	}
+	return nil
+}
+
+// removeLinks deletes the symlinks of an entry that point into the repo
+func (m *Manager) removeLinks(file types.ManagedFile) error {
+	links, err := m.EntryLinks(file)
+	if err != nil {
+		return fmt.Errorf("failed to resolve targets: %w", err)
+	}
+
+	for _, link := range links {
+		if target, err := os.Readlink(link.Target); err != nil || target != link.Source {
+			if fileops.PathExists(link.Target) {
+				m.warn(link.Target, nil, "%s is not linked to the repo, left in place", link.Target)
+			}
+			continue
+		}
+		if err := fileops.Remove(link.Target); err != nil {
+			return fmt.Errorf("failed to remove symlink: %w", err)
+		}
+	}
+	return nil
+}
+
+// archiveRepoPath moves repo content out of the tracked tree into the
+// archive directory, keeping its history connected
+func (m *Manager) archiveRepoPath(repoRelPath string) error {
+	archiveRel := filepath.Join(config.DotmanDirName, config.ArchiveDirName, repoRelPath)
+	if _, err := os.Lstat(filepath.Join(m.cfg.DotmanDir, archiveRel)); err == nil {
+		archiveRel += "." + time.Now().Format("20060102-150405")
+	}
+
+	archivePath := filepath.Join(m.cfg.DotmanDir, archiveRel)
+	if err := fileops.MkdirAll(filepath.Dir(archivePath), 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	if git.IsTracked(m.cfg.DotmanDir, repoRelPath) {
+		if err := git.Move(m.cfg.DotmanDir, repoRelPath, archiveRel); err != nil {
+			return err
+		}
+	} else if err := fileops.Rename(filepath.Join(m.cfg.DotmanDir, repoRelPath), archivePath); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", repoRelPath, err)
+	}
+
+	m.info(repoRelPath, "Archived %s to %s", repoRelPath, archiveRel)
+	return nil
+}
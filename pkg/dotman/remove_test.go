@@ -0,0 +1,62 @@
+package dotman
+
+import (
+	"testing"
+
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+func TestRemove(t *testing.T) {
+	setupGit(t)
+	m, events := newManager(t)
+	add(t, m, ".bashrc", ".vimrc")
+	bashrc := homeFile(m, ".bashrc")
+
+	result, err := m.Remove([]string{bashrc}, types.RemoveOptions{Mode: types.RemoveRestore})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Succeeded() != 1 {
+		t.Errorf("Succeeded() = %d, want 1", result.Succeeded())
+	}
+	if fileops.IsSymlink(bashrc) || readFile(t, bashrc) != ".bashrc\n" {
+		t.Errorf("%s is not restored as a regular file", bashrc)
+	}
+	if fileops.PathExists(homeFile(m, ".dotman/.bashrc")) {
+		t.Error("the repo copy is still there")
+	}
+	idx := loadIndex(t, m)
+	if index.IsManaged(idx, bashrc) || !index.IsManaged(idx, homeFile(m, ".vimrc")) {
+		t.Error("the index doesn't hold just the other entry")
+	}
+	if !events.has(EventInfo, bashrc) {
+		t.Errorf("no progress event for %s", bashrc)
+	}
+	if got := lastCommit(t, m); got != "Remove $HOME/.bashrc from dotman management" {
+		t.Errorf("last commit = %q", got)
+	}
+
+	if _, err := m.Remove([]string{bashrc}, types.RemoveOptions{}); err == nil {
+		t.Errorf("removing %s again succeeded", bashrc)
+	}
+}
+
+func TestRemoveDryRun(t *testing.T) {
+	setupGit(t)
+	m, events := newManager(t)
+	add(t, m, ".bashrc")
+	bashrc := homeFile(m, ".bashrc")
+
+	if _, err := m.Remove([]string{bashrc}, types.RemoveOptions{Mode: types.RemoveDelete, DryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+	wantLink(t, m, bashrc, ".bashrc")
+	if !index.IsManaged(loadIndex(t, m), bashrc) {
+		t.Error("a dry run changed the index")
+	}
+	if !events.has(EventPlanned, bashrc) {
+		t.Errorf("no planned event for %s", bashrc)
+	}
+}
@@ -0,0 +1,78 @@
+package dotman
+
+import (
+	"fmt"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/git"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// Restore sets a managed path, or a file inside a managed directory, back to
+// its content at rev and commits the result
+func (m *Manager) Restore(path, rev string) error {
+	idx, err := m.RepoIndex()
+	if err != nil {
+		return err
+	}
+
+	repoPath, file, err := m.ResolveManagedPath(idx, path)
+	if err != nil {
+		return err
+	}
+
+	commit, err := git.VerifyRevision(m.cfg.DotmanDir, rev)
+	if err != nil {
+		return err
+	}
+
+	if !git.ExistsAt(m.cfg.DotmanDir, commit, repoPath) {
+		return fmt.Errorf("%s does not exist at %s", repoPath, rev)
+	}
+
+	m.info(path, "Restoring %s from %s...", path, rev)
+
+	if err := git.RestorePath(m.cfg.DotmanDir, commit, repoPath); err != nil {
+		return err
+	}
+
+	// Convert to $HOME relative path for commit message
+	homePath := "$HOME/" + repoPath
+	if expandedPath, err := config.ExpandPath(m.cfg, path); err == nil {
+		homePath = m.homePath(expandedPath, homePath)
+	}
+
+	if err := m.commit(idx, fmt.Sprintf("Restore %s from %s", homePath, ShortRevision(commit)), file.RepoPath); err != nil {
+		return err
+	}
+
+	m.info(path, "Successfully restored %s from %s", path, rev)
+	return nil
+}
+
+// RepoIndex checks the dotman git repository exists and loads its index
+func (m *Manager) RepoIndex() (*types.Index, error) {
+	if !config.DotmanDirExists(m.cfg) {
+		return nil, types.Errorf(types.ErrRepoMissing, "dotman directory does not exist: %s", m.cfg.DotmanDir)
+	}
+
+	if !git.IsGitRepo(m.cfg.DotmanDir) {
+		return nil, types.Errorf(types.ErrRepoMissing, "dotman directory is not a git repository")
+	}
+
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+
+	return idx, nil
+}
+
+// ShortRevision abbreviates a full commit hash for messages
+func ShortRevision(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
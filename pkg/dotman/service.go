@@ -0,0 +1,240 @@
+package dotman
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/internal/service"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// ServiceInstall describes the systemd user units written by InstallService
+type ServiceInstall struct {
+	// Paths are the unit files in $HOME
+	Paths []string
+	// Unit is the unit to enable
+	Unit string
+	// Enabled is set when Unit was enabled and started
+	Enabled bool
+}
+
+// UnitStatus is the state of one installed unit
+type UnitStatus struct {
+	Name    string
+	Path    string
+	Managed bool
+	// Enabled and Active are the answers of systemctl is-enabled and
+	// is-active; empty when systemctl is not available
+	Enabled, Active string
+}
+
+// ServiceReport lists the installed dotman units
+type ServiceReport struct {
+	// Available is false when systemctl can't be run
+	Available bool
+	Units     []UnitStatus
+}
+
+// unitPath returns the location of a unit file in $HOME
+func (m *Manager) unitPath(name string) string {
+	return filepath.Join(m.cfg.HomeDir, service.UnitDir, name)
+}
+
+// InstallService writes systemd user units that run sync on a timer, or
+// watch with opts.Watch, as managed files and commits them. With
+// opts.Enable, the units are enabled and started.
+func (m *Manager) InstallService(opts types.ServiceOptions) (*ServiceInstall, error) {
+	if !config.DotmanDirExists(m.cfg) {
+		return nil, types.Errorf(types.ErrRepoMissing, "dotman directory does not exist: %s", m.cfg.DotmanDir)
+	}
+
+	units, err := service.Generate(service.Options{
+		Executable: service.Executable(opts.Executable, m.cfg.HomeDir),
+		Interval:   opts.Interval,
+		Push:       opts.Push,
+		Watch:      opts.Watch,
+		Debounce:   opts.Debounce,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+
+	install := &ServiceInstall{Unit: units[len(units)-1].Name}
+	var written, repoPaths []string
+	for _, unit := range units {
+		path := m.unitPath(unit.Name)
+		if err := m.writeManagedFile(idx, path, []byte(unit.Content)); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", unit.Name, err)
+		}
+		written = append(written, "$HOME/"+filepath.Join(service.UnitDir, unit.Name))
+		if dir, found := index.ContainingDirectory(idx, path); found {
+			repoPaths = append(repoPaths, dir.RepoPath)
+		} else if file, found := index.FindFile(idx, path); found {
+			repoPaths = append(repoPaths, file.RepoPath)
+		}
+		install.Paths = append(install.Paths, path)
+		m.info(path, "Wrote %s", path)
+	}
+
+	// Reinstalling on a machine where the units are up to date commits nothing
+	if err := m.commit(idx, fmt.Sprintf("Add systemd units %s", strings.Join(written, ", ")), repoPaths...); err != nil {
+		return nil, err
+	}
+
+	if !opts.Enable {
+		return install, nil
+	}
+
+	if !service.Available() {
+		m.warn("", nil, "systemctl is not available; the units were written but not enabled")
+		return install, nil
+	}
+
+	if _, err := service.Systemctl("daemon-reload"); err != nil {
+		return install, err
+	}
+	if _, err := service.Systemctl("enable", "--now", install.Unit); err != nil {
+		return install, err
+	}
+
+	install.Enabled = true
+	return install, nil
+}
+
+// writeManagedFile writes generated content to a path in $HOME and makes
+// sure dotman manages it. Content below a managed directory already lands
+// in the repo through the directory link.
+func (m *Manager) writeManagedFile(idx *types.Index, path string, content []byte) error {
+	if dir, found := index.ContainingDirectory(idx, path); found && dir.Partial == nil {
+		if err := fileops.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		return fileops.WriteFile(path, content, 0644)
+	}
+
+	relativePath, err := config.RelativeToHome(m.cfg, path)
+	if err != nil {
+		return fmt.Errorf("failed to get relative path: %w", err)
+	}
+	repoPath := filepath.Join(m.cfg.DotmanDir, relativePath)
+
+	// A file that is not ours is left alone
+	if fileops.PathExists(path) || fileops.IsSymlink(path) {
+		if link, err := os.Readlink(path); err != nil || link != repoPath {
+			return types.Errorf(types.ErrConflict, "%s exists and is not managed by dotman", path)
+		}
+	}
+
+	if err := fileops.MkdirAll(filepath.Dir(repoPath), 0755); err != nil {
+		return fmt.Errorf("failed to create repo directory: %w", err)
+	}
+	if err := fileops.WriteFile(repoPath, content, 0644); err != nil {
+		return err
+	}
+
+	if !fileops.IsSymlink(path) {
+		if err := fileops.CreateSymlink(path, repoPath); err != nil {
+			return fmt.Errorf("failed to create symlink: %w", err)
+		}
+	}
+
+	// Files of a partial directory are tracked through the repo
+	if !index.IsManaged(idx, path) && !index.IsWithinDirectory(path, index.ManagedDirectories(idx)) {
+		index.AddFile(idx, path, relativePath, types.FileTypeFile)
+	}
+	return nil
+}
+
+// ServiceStatus lists the installed dotman units and, when systemctl is
+// available, whether they are enabled and active
+func (m *Manager) ServiceStatus() (*ServiceReport, error) {
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+
+	report := &ServiceReport{Available: service.Available()}
+	for _, name := range service.Units {
+		path := m.unitPath(name)
+		if !fileops.PathExists(path) {
+			continue
+		}
+
+		unit := UnitStatus{Name: name, Path: path}
+		if _, _, err := m.ResolveManagedPath(idx, path); err == nil {
+			unit.Managed = true
+		}
+		if report.Available {
+			unit.Enabled = service.UnitState("is-enabled", name)
+			unit.Active = service.UnitState("is-active", name)
+		}
+		report.Units = append(report.Units, unit)
+	}
+	return report, nil
+}
+
+// UninstallService disables the dotman units, removes them from $HOME and
+// the repo and commits. It returns the removed unit files.
+func (m *Manager) UninstallService() ([]string, error) {
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+
+	var removed, paths []string
+	for _, name := range service.Units {
+		path := m.unitPath(name)
+		if !fileops.PathExists(path) && !fileops.IsSymlink(path) {
+			continue
+		}
+
+		repoRel, _, err := m.ResolveManagedPath(idx, path)
+		if err != nil {
+			m.emit(EventSkipped, path, nil, "Skipping %s (not managed by dotman)", path)
+			continue
+		}
+
+		// The sync service is started by the timer and can't be enabled itself
+		if service.Available() && name != service.SyncService {
+			if _, err := service.Systemctl("disable", "--now", name); err != nil {
+				m.warn(path, err, "%v", err)
+			}
+		}
+
+		if fileops.IsSymlink(path) {
+			if err := fileops.Remove(path); err != nil {
+				return paths, fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+		}
+		if err := fileops.Remove(filepath.Join(m.cfg.DotmanDir, repoRel)); err != nil && !os.IsNotExist(err) {
+			return paths, fmt.Errorf("failed to remove repo copy of %s: %w", name, err)
+		}
+		index.RemoveFile(idx, path)
+
+		removed = append(removed, "$HOME/"+filepath.Join(service.UnitDir, name))
+		paths = append(paths, path)
+		m.info(path, "Removed %s", path)
+	}
+
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	if service.Available() {
+		if _, err := service.Systemctl("daemon-reload"); err != nil {
+			m.warn("", err, "%v", err)
+		}
+	}
+
+	return paths, m.commit(idx, fmt.Sprintf("Remove systemd units %s", strings.Join(removed, ", ")))
+}
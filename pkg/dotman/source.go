@@ -0,0 +1,144 @@
+package dotman
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/git"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// Sources returns the configured sources, highest priority first. The
+// Manager's repository is the default source.
+func (m *Manager) Sources() ([]types.Source, error) {
+	return config.LoadSources(m.cfg)
+}
+
+// Collisions returns, per source, the targets that a higher-priority source
+// also manages (directly or through a managed directory) and which source
+// wins each of them. The Manager's config is the base the sources are
+// resolved against.
+func (m *Manager) Collisions(all []types.Source) map[string]map[string]string {
+	type claim struct {
+		path   string
+		isDir  bool
+		source string
+	}
+
+	var claims []claim
+	collisions := make(map[string]map[string]string)
+
+	for _, source := range all {
+		idx, err := index.Load(config.ForSource(m.cfg, source).IndexFile)
+		if err != nil {
+			continue
+		}
+
+		for _, file := range index.GetAllFiles(idx) {
+			isDir := file.Type == types.FileTypeDirectory
+			winner := ""
+			for _, c := range claims {
+				if c.source == source.Name {
+					continue
+				}
+				if c.path == file.OriginalPath ||
+					(c.isDir && index.IsWithinDirectory(file.OriginalPath, []string{c.path})) ||
+					(isDir && index.IsWithinDirectory(c.path, []string{file.OriginalPath})) {
+					winner = c.source
+					break
+				}
+			}
+
+			if winner == "" {
+				claims = append(claims, claim{path: file.OriginalPath, isDir: isDir, source: source.Name})
+				continue
+			}
+			if collisions[source.Name] == nil {
+				collisions[source.Name] = make(map[string]string)
+			}
+			collisions[source.Name][file.OriginalPath] = winner
+		}
+	}
+
+	return collisions
+}
+
+// AddSource adds a source to the list. An existing directory is registered
+// as is; anything else is cloned into the sources directory.
+func (m *Manager) AddSource(name, location string, priority int) (*types.Source, error) {
+	sources, err := m.Sources()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, found := config.FindSource(sources, name); found {
+		return nil, fmt.Errorf("source %s already exists", name)
+	}
+	if strings.ContainsAny(name, `/\`) || name == "" {
+		return nil, fmt.Errorf("invalid source name: %s", name)
+	}
+
+	dir := location
+	if fileops.IsDirectory(location) {
+		absDir, err := filepath.Abs(location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve absolute path: %w", err)
+		}
+		dir = absDir
+	} else {
+		dir = filepath.Join(config.SourcesDir(m.cfg), name)
+		if fileops.PathExists(dir) {
+			return nil, fmt.Errorf("directory already exists: %s", dir)
+		}
+		if err := fileops.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create sources directory: %w", err)
+		}
+
+		m.info(dir, "Cloning source %s from %s...", name, location)
+		if err := git.Clone(location, dir); err != nil {
+			return nil, err
+		}
+	}
+
+	if !fileops.PathExists(filepath.Join(dir, config.IndexFileName)) {
+		m.warn(dir, nil, "%s has no %s yet", dir, config.IndexFileName)
+	}
+
+	source := types.Source{Name: name, Dir: dir, Priority: priority}
+	if err := config.SaveSources(m.cfg, append(sources, source)); err != nil {
+		return nil, err
+	}
+	return &source, nil
+}
+
+// RemoveSource removes a source from the list. Its repository and deployed
+// links are left in place.
+func (m *Manager) RemoveSource(name string) error {
+	if name == config.DefaultSource {
+		return fmt.Errorf("the default source can't be removed")
+	}
+
+	sources, err := m.Sources()
+	if err != nil {
+		return err
+	}
+
+	var kept []types.Source
+	found := false
+	for _, source := range sources {
+		if source.Name == name {
+			found = true
+			continue
+		}
+		kept = append(kept, source)
+	}
+	if !found {
+		return fmt.Errorf("unknown source: %s", name)
+	}
+
+	return config.SaveSources(m.cfg, kept)
+}
@@ -0,0 +1,422 @@
+package dotman
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/git"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// LinkState is the state of a target in $HOME
+type LinkState string
+
+const (
+	LinkOK         LinkState = "OK"
+	LinkMissing    LinkState = "Missing"
+	LinkNotSymlink LinkState = "Not a symlink"
+)
+
+// TargetStatus is the state of one deployed location of an entry
+type TargetStatus struct {
+	Path  string
+	State LinkState
+}
+
+// EntryStatus describes one index entry on this machine
+type EntryStatus struct {
+	File types.ManagedFile
+	// Layer supplies the entry on this machine
+	Layer string
+	// OtherLayers is set when the entry only has copies for other machines
+	OtherLayers bool
+	// ShadowedBy names the source that deploys the entry instead, if any
+	ShadowedBy string
+	// Targets are the deployed locations: the original path, every match of
+	// a glob entry or every tracked file of a partial directory
+	Targets []TargetStatus
+	// TargetErr is set when the targets of a glob entry can't be resolved
+	TargetErr error
+	// Candidates are untracked files of a partial directory that match its
+	// include patterns
+	Candidates []string
+	// Changed is set when the repo content of the entry has uncommitted
+	// changes
+	Changed bool
+}
+
+// Broken returns the number of targets that are not linked
+func (e EntryStatus) Broken() int {
+	if e.OtherLayers || e.ShadowedBy != "" {
+		return 0
+	}
+	if e.TargetErr != nil {
+		return 1
+	}
+	broken := 0
+	for _, target := range e.Targets {
+		if target.State != LinkOK {
+			broken++
+		}
+	}
+	return broken
+}
+
+// GitStatus describes the repository
+type GitStatus struct {
+	Branch    string
+	BranchErr error
+	// Remote is the origin URL; empty when not configured
+	Remote string
+	// Upstream is the tracking branch; empty when not configured
+	Upstream      string
+	Ahead, Behind int
+	// HasCounts is set when Ahead and Behind could be determined
+	HasCounts bool
+	Commits   string
+	// Changes are the 'git status --porcelain' lines of uncommitted changes
+	Changes []string
+	// ChangesErr is set when the working tree couldn't be checked
+	ChangesErr error
+}
+
+// StatusReport describes every managed entry and the repository
+type StatusReport struct {
+	// Initialized is false when the dotman directory doesn't exist
+	Initialized bool
+	// Managed is the number of index entries
+	Managed int
+	Entries []EntryStatus
+	// Git is nil when the dotman directory is not a git repository
+	Git *GitStatus
+}
+
+// Broken returns the number of targets that are not linked
+func (r *StatusReport) Broken() int {
+	broken := 0
+	for _, entry := range r.Entries {
+		broken += entry.Broken()
+	}
+	return broken
+}
+
+// Status checks the links of every managed entry and the state of the repo.
+// Files covered by a managed directory are left out.
+func (m *Manager) Status() (*StatusReport, error) {
+	report := &StatusReport{Initialized: config.DotmanDirExists(m.cfg)}
+	if !report.Initialized {
+		return report, nil
+	}
+
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+
+	report.Managed = index.Count(idx)
+
+	// Get all managed directories first
+	managedDirs := index.ManagedDirectories(idx)
+
+	for _, file := range index.GetAllFiles(idx) {
+		// Skip individual files that are within managed directories
+		if file.Type == types.FileTypeFile && index.IsWithinDirectory(file.OriginalPath, managedDirs) {
+			continue
+		}
+
+		entry := EntryStatus{File: file}
+		entry.Layer, _ = m.EntrySource(file)
+
+		switch winner, found := m.ShadowedBy(file.OriginalPath); {
+		case !m.AppliesHere(file):
+			entry.OtherLayers = true
+		case found:
+			entry.ShadowedBy = winner
+		case file.Partial != nil:
+			for _, child := range m.PartialChildren(file) {
+				entry.Targets = append(entry.Targets, TargetStatus{Path: child.OriginalPath, State: linkState(child.OriginalPath)})
+			}
+			entry.Candidates = PartialCandidates(file)
+		default:
+			targets, err := m.EntryTargets(file)
+			if err != nil {
+				entry.TargetErr = err
+				break
+			}
+			for _, target := range targets {
+				entry.Targets = append(entry.Targets, TargetStatus{Path: target, State: linkState(target)})
+			}
+		}
+
+		report.Entries = append(report.Entries, entry)
+	}
+
+	if git.IsGitRepo(m.cfg.DotmanDir) {
+		report.Git = m.gitStatus()
+
+		changed := changedRepoPaths(report.Git.Changes)
+		for i, entry := range report.Entries {
+			_, layerPath := config.ResolveLayer(m.cfg, entry.File.RepoPath)
+			report.Entries[i].Changed = touchesPath(changed, layerPath)
+		}
+	}
+
+	return report, nil
+}
+
+// linkState checks whether a target is a symlink
+func linkState(target string) LinkState {
+	if !fileops.PathExists(target) {
+		return LinkMissing
+	}
+	if !fileops.IsSymlink(target) {
+		return LinkNotSymlink
+	}
+	return LinkOK
+}
+
+// gitStatus collects what status shows about the repository
+func (m *Manager) gitStatus() *GitStatus {
+	status := &GitStatus{}
+	status.Branch, status.BranchErr = git.GetCurrentBranch(m.cfg.DotmanDir)
+
+	if remoteURL, err := git.GetRemoteURL(m.cfg.DotmanDir); err == nil {
+		status.Remote = remoteURL
+	}
+
+	if upstream, err := git.GetUpstream(m.cfg.DotmanDir); err == nil {
+		status.Upstream = upstream
+		if ahead, behind, err := git.AheadBehind(m.cfg.DotmanDir); err == nil {
+			status.Ahead, status.Behind, status.HasCounts = ahead, behind, true
+		}
+	}
+
+	if commitCount, err := git.GetCommitCount(m.cfg.DotmanDir); err == nil {
+		status.Commits = commitCount
+	}
+
+	hasChanges, err := git.HasChanges(m.cfg.DotmanDir)
+	if err != nil {
+		status.ChangesErr = err
+		return status
+	}
+	if hasChanges {
+		if gitStatus, err := git.Status(m.cfg.DotmanDir); err == nil {
+			for _, line := range strings.Split(strings.TrimSpace(gitStatus), "\n") {
+				if len(line) >= 3 {
+					status.Changes = append(status.Changes, line)
+				}
+			}
+		}
+	}
+	return status
+}
+
+// FixResult lists what Fix did
+type FixResult struct {
+	// Fixed are the targets that were (or in a dry run would be) relinked
+	Fixed []string
+	// Problems are the targets that need manual attention
+	Problems []types.Operation
+}
+
+// Fix repairs broken or missing symlinks of managed entries, or only of
+// opts.Paths. Targets that hold something else are reported, or with
+// opts.Backup saved to the backup store and replaced. The error is a
+// *types.BatchError when some targets need manual attention.
+func (m *Manager) Fix(opts types.FixOptions) (*FixResult, error) {
+	if !config.DotmanDirExists(m.cfg) {
+		return nil, types.Errorf(types.ErrRepoMissing, "dotman directory does not exist: %s", m.cfg.DotmanDir)
+	}
+
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+
+	result := &FixResult{}
+	if index.Count(idx) == 0 {
+		m.info("", "No files are managed by dotman.")
+		return result, nil
+	}
+
+	for _, file := range m.LinkedEntries(idx) {
+		if _, found := m.ShadowedBy(file.OriginalPath); found || !m.AppliesHere(file) {
+			continue
+		}
+		// The files of a partial directory are fixed when it is requested
+		if len(opts.Paths) > 0 && !containsPath(opts.Paths, file.OriginalPath) && !index.IsWithinDirectory(file.OriginalPath, opts.Paths) {
+			continue
+		}
+		_, repoPath := m.EntrySource(file)
+
+		// Check if repo file exists
+		if !fileops.PathExists(repoPath) {
+			m.problem(result, file.OriginalPath, fmt.Errorf("repository file missing"), "Repository file missing: %s", file.OriginalPath)
+			continue
+		}
+
+		targets, err := m.EntryTargets(file)
+		if err != nil {
+			m.problem(result, file.OriginalPath, err, "%s - %v", file.OriginalPath, err)
+			continue
+		}
+
+		for _, target := range targets {
+			m.fixTarget(result, target, repoPath, opts)
+		}
+	}
+
+	if len(result.Fixed) > 0 {
+		if opts.DryRun {
+			m.info("", "Would fix %d file(s)", len(result.Fixed))
+		} else {
+			m.info("", "Fixed %d file(s)", len(result.Fixed))
+		}
+	}
+
+	if len(result.Problems) > 0 {
+		return result, &types.BatchError{Succeeded: len(result.Fixed), Failed: result.Problems}
+	}
+	return result, nil
+}
+
+// problem records and reports a target that needs manual attention
+func (m *Manager) problem(result *FixResult, path string, err error, format string, args ...interface{}) {
+	m.emit(EventProblem, path, err, format, args...)
+	result.Problems = append(result.Problems, types.Operation{Path: path, Error: err, Message: fmt.Sprintf(format, args...)})
+}
+
+// fixTarget repairs the symlink at a single target location if possible
+func (m *Manager) fixTarget(result *FixResult, target, repoPath string, opts types.FixOptions) {
+	// Check original location status
+	if fileops.PathExists(target) {
+		if fileops.IsSymlink(target) {
+			// Check if symlink points to correct location
+			if linkTarget, err := os.Readlink(target); err == nil {
+				if linkTarget == repoPath {
+					return // Already correct
+				}
+				if !opts.Backup {
+					m.problem(result, target, types.Errorf(types.ErrConflict, "symlink points to %s", linkTarget), "%s - Symlink points to wrong location: %s", target, linkTarget)
+					return
+				}
+			}
+		} else if !opts.Backup {
+			m.problem(result, target, types.Errorf(types.ErrConflict, "not a symlink"), "%s - Exists but is not a symlink (use --backup to replace it)", target)
+			return
+		}
+
+		// Whatever is in the way is kept in the backup store
+		if opts.DryRun {
+			m.emit(EventFixed, target, nil, "%s - In the way (would back up and fix)", target)
+			result.Fixed = append(result.Fixed, target)
+			return
+		}
+		if err := m.replaceWithBackup(target); err != nil {
+			m.problem(result, target, err, "%s - Failed to back up: %v", target, err)
+			return
+		}
+	}
+
+	// File is missing or broken symlink - can be fixed
+	if opts.DryRun {
+		m.emit(EventFixed, target, nil, "%s - Missing symlink (would fix)", target)
+		result.Fixed = append(result.Fixed, target)
+		return
+	}
+
+	// Remove broken symlink if it exists
+	if fileops.IsSymlink(target) {
+		fileops.Remove(target)
+	}
+
+	// Create new symlink
+	if err := fileops.CreateSymlink(target, repoPath); err != nil {
+		m.problem(result, target, err, "%s - Missing symlink - Failed to fix: %v", target, err)
+		return
+	}
+	m.emit(EventFixed, target, nil, "%s - Missing symlink - Fixed!", target)
+	result.Fixed = append(result.Fixed, target)
+}
+
+// Cleanup removes file entries that are covered by managed directories and
+// returns them. Nothing is removed in a dry run.
+func (m *Manager) Cleanup(opts types.FixOptions) ([]types.ManagedFile, error) {
+	if !config.DotmanDirExists(m.cfg) {
+		return nil, types.Errorf(types.ErrRepoMissing, "dotman directory does not exist: %s", m.cfg.DotmanDir)
+	}
+
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+
+	if index.Count(idx) == 0 {
+		m.info("", "No files are managed by dotman.")
+		return nil, nil
+	}
+
+	// Get all managed directories
+	managedDirs := index.ManagedDirectories(idx)
+	if len(managedDirs) == 0 {
+		m.info("", "No managed directories found - nothing to clean up.")
+		return nil, nil
+	}
+
+	// Find redundant file entries
+	var redundantFiles []types.ManagedFile
+	for _, file := range index.GetAllFiles(idx) {
+		if file.Type == types.FileTypeFile && index.IsWithinDirectory(file.OriginalPath, managedDirs) {
+			redundantFiles = append(redundantFiles, file)
+		}
+	}
+
+	if len(redundantFiles) == 0 {
+		m.info("", "No redundant file entries found.")
+		return nil, nil
+	}
+
+	m.info("", "Found %d redundant file entries covered by managed directories:", len(redundantFiles))
+	for _, file := range redundantFiles {
+		m.info(file.OriginalPath, "  %s (covered by parent directory)", file.OriginalPath)
+	}
+
+	if opts.DryRun {
+		m.emit(EventPlanned, "", nil, "\nDry-run mode: would remove these entries from the index")
+		return redundantFiles, nil
+	}
+
+	// Remove redundant entries from the index
+	var removed []types.ManagedFile
+	for _, file := range redundantFiles {
+		if index.RemoveFile(idx, file.OriginalPath) {
+			removed = append(removed, file)
+			m.info(file.OriginalPath, "Removed %s from index", file.OriginalPath)
+		}
+	}
+
+	if len(removed) == 0 {
+		m.info("", "No entries were removed from the index.")
+		return nil, nil
+	}
+
+	// Create commit message with directory count
+	var commitMsg string
+	if len(managedDirs) == 1 {
+		commitMsg = fmt.Sprintf("Cleanup: remove %d redundant entries covered by 1 directory", len(removed))
+	} else {
+		commitMsg = fmt.Sprintf("Cleanup: remove %d redundant entries covered by %d directories", len(removed), len(managedDirs))
+	}
+
+	if err := m.commit(idx, commitMsg); err != nil {
+		return nil, err
+	}
+
+	m.info("", "Successfully cleaned up %d redundant file entries", len(removed))
+	return removed, nil
+}
@@ -0,0 +1,127 @@
+package dotman
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// entryStatus returns the status of the entry for path
+func entryStatus(t *testing.T, report *StatusReport, path string) EntryStatus {
+	t.Helper()
+	for _, entry := range report.Entries {
+		if entry.File.OriginalPath == path {
+			return entry
+		}
+	}
+	t.Fatalf("no status for %s", path)
+	return EntryStatus{}
+}
+
+func TestStatus(t *testing.T) {
+	setupGit(t)
+	m, _ := newManager(t)
+
+	report, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Initialized {
+		t.Error("status of a home without a repo is initialized")
+	}
+
+	add(t, m, ".bashrc", ".vimrc", ".zshrc")
+	bashrc, vimrc, zshrc := homeFile(m, ".bashrc"), homeFile(m, ".vimrc"), homeFile(m, ".zshrc")
+	for _, path := range []string{vimrc, zshrc} {
+		if err := os.Remove(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, zshrc, "local\n")
+	writeFile(t, filepath.Join(m.cfg.DotmanDir, ".bashrc"), "edited\n")
+
+	report, err = m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Initialized || report.Managed != 3 || report.Broken() != 2 {
+		t.Errorf("report: initialized %t, %d managed, %d broken, want 3 managed and 2 broken", report.Initialized, report.Managed, report.Broken())
+	}
+
+	tests := []struct {
+		path    string
+		state   LinkState
+		changed bool
+	}{
+		{bashrc, LinkOK, true},
+		{vimrc, LinkMissing, false},
+		{zshrc, LinkNotSymlink, false},
+	}
+	for _, test := range tests {
+		entry := entryStatus(t, report, test.path)
+		if len(entry.Targets) != 1 || entry.Targets[0].State != test.state || entry.Changed != test.changed {
+			t.Errorf("status of %s = %+v (changed %t), want %s (changed %t)", test.path, entry.Targets, entry.Changed, test.state, test.changed)
+		}
+	}
+
+	if report.Git == nil || report.Git.Branch != "main" || len(report.Git.Changes) != 1 {
+		t.Errorf("git status = %+v, want branch main with one change", report.Git)
+	}
+}
+
+func TestFix(t *testing.T) {
+	setupGit(t)
+	m, events := newManager(t)
+	add(t, m, ".bashrc", ".vimrc", ".zshrc")
+	bashrc, vimrc, zshrc := homeFile(m, ".bashrc"), homeFile(m, ".vimrc"), homeFile(m, ".zshrc")
+	for _, path := range []string{bashrc, vimrc, zshrc} {
+		if err := os.Remove(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, zshrc, "local\n")
+
+	// Only the requested path is fixed
+	result, err := m.Fix(types.FixOptions{Paths: []string{bashrc}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Fixed) != 1 || result.Fixed[0] != bashrc || !events.has(EventFixed, bashrc) {
+		t.Errorf("Fixed = %q, want %s", result.Fixed, bashrc)
+	}
+	wantLink(t, m, bashrc, ".bashrc")
+	if _, err := os.Lstat(vimrc); !os.IsNotExist(err) {
+		t.Errorf("%s was fixed without being requested", vimrc)
+	}
+
+	events.events = nil
+	result, err = m.Fix(types.FixOptions{})
+	var batch *types.BatchError
+	if !errors.As(err, &batch) || !batch.Partial() || !errors.Is(err, types.ErrConflict) {
+		t.Errorf("Fix() error = %v, want a partial batch error for the conflict", err)
+	}
+	if len(result.Fixed) != 1 || result.Fixed[0] != vimrc {
+		t.Errorf("Fixed = %q, want %s", result.Fixed, vimrc)
+	}
+	if len(result.Problems) != 1 || result.Problems[0].Path != zshrc || !events.has(EventProblem, zshrc) {
+		t.Errorf("Problems = %+v, want the file in the way at %s", result.Problems, zshrc)
+	}
+	if got := readFile(t, zshrc); got != "local\n" {
+		t.Errorf("the file in the way was changed to %q", got)
+	}
+
+	result, err = m.Fix(types.FixOptions{Backup: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Fixed) != 1 || len(result.Problems) != 0 {
+		t.Errorf("with Backup: %d fixed, %d problems, want the file in the way replaced", len(result.Fixed), len(result.Problems))
+	}
+	wantLink(t, m, zshrc, ".zshrc")
+	if events.count(EventFixed) != 2 {
+		t.Errorf("%d fixed events, want 2", events.count(EventFixed))
+	}
+}
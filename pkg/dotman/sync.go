@@ -0,0 +1,395 @@
+package dotman
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Merith-TK/dotman/internal/config"
+	"github.com/Merith-TK/dotman/internal/fileops"
+	"github.com/Merith-TK/dotman/internal/git"
+	"github.com/Merith-TK/dotman/internal/hooks"
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+// SyncResult describes what Sync did
+type SyncResult struct {
+	// Remote and Branch are what was pulled from or pushed to
+	Remote string
+	Branch string
+	// Mirrors are the push mirrors that were pushed to
+	Mirrors []string
+	// Unmanaged are the repo files missing from the index (discover)
+	Unmanaged []string
+	// Added are the repo files that were added to the index (discover)
+	Added []string
+	// Deployed are the targets linked after a pull
+	Deployed []string
+}
+
+// Sync pulls from or pushes to the remote, or adds repo files that are
+// missing from the index. A dry run of discover lists them in Unmanaged
+// without adding them.
+func (m *Manager) Sync(opts types.SyncOptions) (*SyncResult, error) {
+	switch opts.Mode {
+	case types.SyncPull:
+		return m.syncPull(opts)
+	case types.SyncPush:
+		return m.syncPush(opts)
+	default:
+		return m.syncDiscover(opts)
+	}
+}
+
+func (m *Manager) syncPull(opts types.SyncOptions) (*SyncResult, error) {
+	if !config.DotmanDirExists(m.cfg) {
+		return nil, types.Errorf(types.ErrRepoMissing, "dotman directory does not exist: %s", m.cfg.DotmanDir)
+	}
+
+	if !git.IsGitRepo(m.cfg.DotmanDir) {
+		return nil, types.Errorf(types.ErrRepoMissing, "dotman directory is not a git repository")
+	}
+
+	remote, branch, err := m.SyncTarget(opts.Remote, opts.Branch)
+	if err != nil {
+		return nil, err
+	}
+	result := &SyncResult{Remote: remote, Branch: branch}
+
+	m.info("", "Pulling changes from %s/%s...", remote, branch)
+
+	if opts.DryRun {
+		m.emit(EventPlanned, "", nil, "Dry-run mode: would pull changes from remote")
+		return result, nil
+	}
+
+	// Remember what was deployed before the pull so removed entries can be unlinked
+	oldIdx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+
+	if err := git.Pull(m.cfg.DotmanDir, remote, branch); err != nil {
+		return nil, fmt.Errorf("failed to pull from remote: %w", err)
+	}
+
+	m.info("", "Successfully pulled changes from remote")
+
+	deployed, err := m.Reconcile(oldIdx)
+	result.Deployed = deployed
+	return result, err
+}
+
+// Reconcile brings $HOME in line with the repo after HEAD moved (pull,
+// checkout, reset, ...): links of entries that disappeared from the index
+// are removed if they now dangle, entries that are not deployed yet are
+// linked, and the post-pull hook runs. oldIdx is the index before HEAD
+// moved. It returns the targets that were linked.
+func (m *Manager) Reconcile(oldIdx *types.Index) ([]string, error) {
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index after update: %w", err)
+	}
+
+	for _, old := range index.GetAllFiles(oldIdx) {
+		if old.Glob != nil || index.IsManaged(idx, old.OriginalPath) {
+			continue
+		}
+		link, err := os.Readlink(old.OriginalPath)
+		if err != nil || !strings.HasPrefix(link, m.cfg.DotmanDir+string(filepath.Separator)) {
+			continue
+		}
+		if fileops.PathExists(old.OriginalPath) {
+			m.warn(old.OriginalPath, nil, "%s is no longer in the index but its repo content still exists", old.OriginalPath)
+			continue
+		}
+		if err := fileops.Remove(old.OriginalPath); err != nil {
+			m.warn(old.OriginalPath, err, "failed to remove stale link %s: %v", old.OriginalPath, err)
+			continue
+		}
+		m.info(old.OriginalPath, "Removed stale link %s", old.OriginalPath)
+	}
+
+	result := &DeployResult{}
+	for _, file := range m.LinkedEntries(idx) {
+		_, repoPath := m.EntrySource(file)
+		if config.ShouldIgnoreRepoPath(m.cfg, file.RepoPath) || !m.AppliesHere(file) {
+			continue
+		}
+		if _, found := m.ShadowedBy(file.OriginalPath); found {
+			continue
+		}
+		if !fileops.PathExists(repoPath) {
+			m.warn(file.OriginalPath, nil, "repo file missing for %s", file.OriginalPath)
+			continue
+		}
+
+		targets, err := m.EntryTargets(file)
+		if err != nil {
+			m.emit(EventFailed, file.OriginalPath, err, "Error resolving targets for %s: %v", file.OriginalPath, err)
+			continue
+		}
+		for _, target := range targets {
+			// Only new entries need work; deployTarget reports everything else
+			if fileops.PathExists(target) || fileops.IsSymlink(target) {
+				continue
+			}
+			m.deployTarget(result, target, repoPath, types.DeployOptions{})
+		}
+	}
+
+	if err := hooks.Run(m.cfg, hooks.PostPull, false, m.hookReport(false)); err != nil {
+		m.warn("", err, "%v", err)
+	}
+
+	return result.Deployed, nil
+}
+
+func (m *Manager) syncPush(opts types.SyncOptions) (*SyncResult, error) {
+	if !config.DotmanDirExists(m.cfg) {
+		return nil, types.Errorf(types.ErrRepoMissing, "dotman directory does not exist: %s", m.cfg.DotmanDir)
+	}
+
+	if !git.IsGitRepo(m.cfg.DotmanDir) {
+		return nil, types.Errorf(types.ErrRepoMissing, "dotman directory is not a git repository")
+	}
+
+	// Check if there are any changes to push
+	hasChanges, err := git.HasChanges(m.cfg.DotmanDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for changes: %w", err)
+	}
+
+	if hasChanges {
+		m.warn("", nil, "You have uncommitted changes. Commit them first or they won't be pushed.")
+		m.info("", "Tip: 'dotman watch' commits edits to managed files automatically.")
+	}
+
+	remote, branch, err := m.SyncTarget(opts.Remote, opts.Branch)
+	if err != nil {
+		return nil, err
+	}
+	result := &SyncResult{Remote: remote, Branch: branch}
+
+	var mirrors []string
+	for _, mirror := range m.MirrorRemotes() {
+		if mirror != remote {
+			mirrors = append(mirrors, mirror)
+		}
+	}
+
+	m.info("", "Pushing changes to %s/%s...", remote, branch)
+
+	if opts.DryRun {
+		m.emit(EventPlanned, "", nil, "Dry-run mode: would push changes to remote")
+		for _, mirror := range mirrors {
+			m.emit(EventPlanned, "", nil, "Dry-run mode: would push changes to mirror %s", mirror)
+		}
+		return result, nil
+	}
+
+	if err := git.Push(m.cfg.DotmanDir, remote, branch); err != nil {
+		return nil, fmt.Errorf("failed to push to remote: %w", err)
+	}
+
+	m.info("", "Successfully pushed changes to remote")
+
+	// A failing mirror doesn't undo the main push, so only report it
+	failed := 0
+	for _, mirror := range mirrors {
+		if err := git.PushMirror(m.cfg.DotmanDir, mirror, branch); err != nil {
+			m.warn("", err, "%v", err)
+			failed++
+			continue
+		}
+		m.info("", "Pushed to mirror %s", mirror)
+		result.Mirrors = append(result.Mirrors, mirror)
+	}
+
+	if failed > 0 {
+		return result, fmt.Errorf("failed to push to %d of %d mirror(s)", failed, len(mirrors))
+	}
+
+	return result, nil
+}
+
+// syncDiscover adds the repo files that are not in the index
+func (m *Manager) syncDiscover(opts types.SyncOptions) (*SyncResult, error) {
+	if !config.DotmanDirExists(m.cfg) {
+		return nil, types.Errorf(types.ErrRepoMissing, "dotman directory does not exist: %s", m.cfg.DotmanDir)
+	}
+
+	// Load current index
+	idx, err := index.Load(m.cfg.IndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+
+	// Find unmanaged files in the repo
+	unmanaged, err := m.UnmanagedFiles(idx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan repo: %w", err)
+	}
+
+	result := &SyncResult{Unmanaged: unmanaged}
+	if len(unmanaged) == 0 || opts.DryRun {
+		return result, nil
+	}
+
+	// Add unmanaged files to the index
+	var addedPaths []string
+	for _, repoPath := range unmanaged {
+		// Calculate the original path (where the symlink should be)
+		originalPath := filepath.Join(m.cfg.HomeDir, repoPath)
+		fileType := fileops.GetFileType(filepath.Join(m.cfg.DotmanDir, repoPath))
+		index.AddFile(idx, originalPath, repoPath, fileType)
+
+		result.Added = append(result.Added, repoPath)
+		addedPaths = append(addedPaths, "$HOME/"+repoPath)
+		m.info(originalPath, "Added %s to index", repoPath)
+	}
+
+	// Create commit message with actual paths
+	var commitMsg string
+	if len(addedPaths) == 1 {
+		commitMsg = fmt.Sprintf("Sync: add %s to index", addedPaths[0])
+	} else if len(addedPaths) <= 3 {
+		commitMsg = fmt.Sprintf("Sync: add %s to index", strings.Join(addedPaths, ", "))
+	} else {
+		commitMsg = fmt.Sprintf("Sync: add %d files to index (%s, ...)", len(addedPaths), strings.Join(addedPaths[:2], ", "))
+	}
+
+	if err := m.commit(idx, commitMsg); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// repoGitFiles are files at the repo root that configure the repo itself
+var repoGitFiles = map[string]bool{".gitignore": true, ".gitattributes": true, ".gitmodules": true}
+
+// UnmanagedFiles scans the repo and returns the files that are neither in
+// the index nor covered by a managed directory, relative to the repo
+func (m *Manager) UnmanagedFiles(idx *types.Index) ([]string, error) {
+	var unmanaged []string
+	repoDir := m.cfg.DotmanDir
+
+	// Get all managed directories first
+	managedDirs := index.ManagedDirectories(idx)
+
+	err := filepath.Walk(repoDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip .git directories (and the .git file of a submodule)
+		if info.Name() == ".git" {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Skip directories - we only track files
+		if info.IsDir() {
+			return nil
+		}
+
+		// Get relative path from repo root
+		relPath, err := filepath.Rel(repoDir, path)
+		if err != nil {
+			return err
+		}
+
+		// Skip the index and the repo's own git files, like the .gitignore
+		// created by init
+		if indexRel, err := filepath.Rel(repoDir, m.cfg.IndexFile); err == nil && relPath == indexRel {
+			return nil
+		}
+		if repoGitFiles[relPath] {
+			return nil
+		}
+
+		// Skip repository metadata files like .dotman and README.md
+		if config.ShouldIgnoreRepoPath(m.cfg, relPath) {
+			return nil
+		}
+
+		// Entries with a glob target live at a repo path that doesn't mirror $HOME
+		if _, found := index.FindByRepoPath(idx, relPath); found {
+			return nil
+		}
+
+		// Check if this file is already managed in the index
+		originalPath := filepath.Join(m.cfg.HomeDir, relPath)
+		if !index.IsManaged(idx, originalPath) {
+			// Also check if this file is covered by a managed directory
+			if !index.IsWithinDirectory(originalPath, managedDirs) {
+				unmanaged = append(unmanaged, relPath)
+			}
+		}
+
+		return nil
+	})
+
+	return unmanaged, err
+}
+
+// SyncTarget returns the remote and remote branch sync should use. Explicit
+// arguments win, then the choice made with 'remote use', then the upstream
+// of the current branch, then origin and the current branch name.
+func (m *Manager) SyncTarget(remote, branch string) (string, string, error) {
+	current, err := git.GetCurrentBranch(m.cfg.DotmanDir)
+	if err != nil {
+		return "", "", err
+	}
+
+	upstreamRemote, upstreamBranch, upstreamErr := git.GetBranchUpstream(m.cfg.DotmanDir, current)
+
+	if remote == "" {
+		if configured, err := git.GetConfig(m.cfg.DotmanDir, config.SyncRemoteKey); err == nil {
+			remote = configured
+		} else if upstreamErr == nil {
+			remote = upstreamRemote
+		} else {
+			remote = "origin"
+		}
+	}
+
+	if branch == "" {
+		if configured, err := git.GetConfig(m.cfg.DotmanDir, config.SyncBranchKey); err == nil {
+			branch = configured
+		} else if upstreamErr == nil && upstreamRemote == remote {
+			branch = upstreamBranch
+		} else {
+			branch = current
+		}
+	}
+
+	return remote, branch, nil
+}
+
+// IsMirror reports whether a remote is marked as a push mirror
+func (m *Manager) IsMirror(name string) bool {
+	value, err := git.GetConfig(m.cfg.DotmanDir, "remote."+name+"."+config.MirrorKey)
+	return err == nil && value == "true"
+}
+
+// MirrorRemotes returns all remotes marked as push mirrors
+func (m *Manager) MirrorRemotes() []string {
+	remotes, err := git.ListRemotes(m.cfg.DotmanDir)
+	if err != nil {
+		return nil
+	}
+
+	var mirrors []string
+	for _, remote := range remotes {
+		if m.IsMirror(remote.Name) {
+			mirrors = append(mirrors, remote.Name)
+		}
+	}
+	return mirrors
+}
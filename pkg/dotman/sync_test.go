@@ -0,0 +1,83 @@
+package dotman
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Merith-TK/dotman/internal/index"
+	"github.com/Merith-TK/dotman/pkg/types"
+)
+
+func TestSyncDiscover(t *testing.T) {
+	setupGit(t)
+	m, events := newManager(t)
+	add(t, m, ".bashrc")
+	writeFile(t, filepath.Join(m.cfg.DotmanDir, ".config", "app", "config.toml"), "x = 1\n")
+	unmanaged := filepath.Join(".config", "app", "config.toml")
+
+	result, err := m.Sync(types.SyncOptions{Mode: types.SyncDiscover, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Unmanaged) != 1 || result.Unmanaged[0] != unmanaged || len(result.Added) != 0 {
+		t.Fatalf("dry run: unmanaged %q, added %q, want %s listed only", result.Unmanaged, result.Added, unmanaged)
+	}
+
+	result, err = m.Sync(types.SyncOptions{Mode: types.SyncDiscover})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Added) != 1 || result.Added[0] != unmanaged {
+		t.Errorf("Added = %q, want %s", result.Added, unmanaged)
+	}
+	target := homeFile(m, unmanaged)
+	if !index.IsManaged(loadIndex(t, m), target) || !events.has(EventInfo, target) {
+		t.Errorf("%s is not in the index", target)
+	}
+	if got := lastCommit(t, m); got != "Sync: add $HOME/"+unmanaged+" to index" {
+		t.Errorf("last commit = %q", got)
+	}
+}
+
+func TestSyncPushPull(t *testing.T) {
+	setupGit(t)
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	runGit(t, filepath.Dir(remote), "init", "--bare", remote)
+
+	m, events := newManager(t)
+	add(t, m, ".bashrc")
+	runGit(t, m.cfg.DotmanDir, "remote", "add", "origin", remote)
+	result, err := m.Sync(types.SyncOptions{Mode: types.SyncPush})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Remote != "origin" || result.Branch != "main" {
+		t.Errorf("pushed to %s/%s, want origin/main", result.Remote, result.Branch)
+	}
+
+	add(t, m, ".vimrc")
+	if _, err := m.Sync(types.SyncOptions{Mode: types.SyncPush}); err != nil {
+		t.Fatal(err)
+	}
+	if got := runGit(t, remote, "log", "-1", "--format=%s"); got != "Add $HOME/.vimrc to dotman management" {
+		t.Errorf("remote is at %q, want the pushed commit", got)
+	}
+
+	// A machine that doesn't have the last commit yet
+	vimrc := homeFile(m, ".vimrc")
+	runGit(t, m.cfg.DotmanDir, "reset", "--hard", "HEAD~1")
+	if err := os.Remove(vimrc); err != nil {
+		t.Fatal(err)
+	}
+
+	events.events = nil
+	result, err = m.Sync(types.SyncOptions{Mode: types.SyncPull})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Deployed) != 1 || result.Deployed[0] != vimrc || !events.has(EventLinked, vimrc) {
+		t.Errorf("Deployed = %q, want the pulled entry %s", result.Deployed, vimrc)
+	}
+	wantLink(t, m, vimrc, ".vimrc")
+}
@@ -0,0 +1,121 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error kinds, matched with errors.Is. Each maps to its own exit code.
+var (
+	ErrNotManaged     = errors.New("not managed by dotman")
+	ErrAlreadyManaged = errors.New("already managed by dotman")
+	ErrOutsideHome    = errors.New("outside the home directory")
+	ErrConflict       = errors.New("conflicting file")
+	ErrRepoMissing    = errors.New("dotman directory does not exist")
+	ErrGit            = errors.New("git command failed")
+)
+
+// Exit codes of the dotman command
+const (
+	ExitOK             = 0
+	ExitFailure        = 1 // Any error without a more specific code
+	ExitPartial        = 3 // Some paths succeeded and some failed
+	ExitNotManaged     = 4
+	ExitAlreadyManaged = 5
+	ExitOutsideHome    = 6
+	ExitConflict       = 7
+	ExitRepoMissing    = 8
+	ExitGit            = 9
+)
+
+// Error is an error of a known kind. The message is kept as written; Kind
+// only decides what errors.Is matches and which exit code is used.
+type Error struct {
+	Kind error
+	Err  error
+}
+
+// Errorf formats an error of the given kind. %w verbs wrap as usual.
+func Errorf(kind error, format string, args ...interface{}) error {
+	return &Error{Kind: kind, Err: fmt.Errorf(format, args...)}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the kind of e
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// BatchError reports a multi-path operation in which some paths failed
+type BatchError struct {
+	Succeeded int
+	Failed    []Operation
+}
+
+func (e *BatchError) Error() string {
+	if e.Partial() {
+		return fmt.Sprintf("%d of %d operations failed", len(e.Failed), e.Succeeded+len(e.Failed))
+	}
+	return "all operations failed"
+}
+
+// Unwrap returns the error of every failed path
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, op := range e.Failed {
+		errs = append(errs, op.Error)
+	}
+	return errs
+}
+
+// Partial reports whether at least one path succeeded
+func (e *BatchError) Partial() bool {
+	return e.Succeeded > 0
+}
+
+// ExitCode returns the exit code for err. A partial failure always gets
+// ExitPartial; a total failure gets the code its errors share, if any.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var batch *BatchError
+	if errors.As(err, &batch) {
+		if batch.Partial() {
+			return ExitPartial
+		}
+		code := ExitFailure
+		for i, op := range batch.Failed {
+			opCode := ExitCode(op.Error)
+			if i > 0 && opCode != code {
+				return ExitFailure
+			}
+			code = opCode
+		}
+		return code
+	}
+
+	switch {
+	case errors.Is(err, ErrNotManaged):
+		return ExitNotManaged
+	case errors.Is(err, ErrAlreadyManaged):
+		return ExitAlreadyManaged
+	case errors.Is(err, ErrOutsideHome):
+		return ExitOutsideHome
+	case errors.Is(err, ErrConflict):
+		return ExitConflict
+	case errors.Is(err, ErrRepoMissing):
+		return ExitRepoMissing
+	case errors.Is(err, ErrGit):
+		return ExitGit
+	}
+	return ExitFailure
+}
@@ -8,8 +8,40 @@ type ManagedFile struct {
 	RepoPath     string    `json:"repo_path"`     // Path within .dotman repo (e.g., .config/sway)
 	Type         FileType  `json:"type"`          // file or directory
 	AddedDate    time.Time `json:"added_date"`    // When it was added to management
+
+	Glob   *GlobTarget `json:"glob,omitempty"`   // Set when the target is discovered with a glob pattern
+	Digest string      `json:"digest,omitempty"` // Content digest recorded at the last dotman commit
+	Layers []string    `json:"layers,omitempty"` // Overlay layers holding a copy (e.g., hosts/laptop); base is implied
+
+	LayerDigests map[string]string `json:"layer_digests,omitempty"` // Content digests of the overlay copies by layer
+
+	Partial *PartialDir `json:"partial,omitempty"` // Set for a directory whose tracked children are linked one by one
 }
 
+// PartialDir selects the files of a directory entry that are tracking
+// candidates. The directory stays a real directory in $HOME and only the
+// files in the repo are linked into it.
+type PartialDir struct {
+	Include []string `json:"include"`           // Globs relative to the directory; ** spans directories
+	Exclude []string `json:"exclude,omitempty"` // Matching files and directories are never candidates
+}
+
+// GlobTarget describes an entry whose target location is not fixed, such as
+// a Firefox profile directory with a generated name
+type GlobTarget struct {
+	Pattern string      `json:"pattern"` // Home-relative pattern (e.g., .mozilla/firefox/*.default-release/chrome/userChrome.css)
+	Policy  MatchPolicy `json:"policy"`  // Which of the matches are deployed to
+}
+
+// MatchPolicy decides which glob matches an entry is deployed to
+type MatchPolicy string
+
+const (
+	MatchFirst      MatchPolicy = "first-match"  // Only the first match (in lexical order)
+	MatchAll        MatchPolicy = "all-matches"  // Every match; nothing to do if none
+	MatchFailIfNone MatchPolicy = "fail-if-none" // Every match; an error if none
+)
+
 // FileType represents whether the managed item is a file or directory
 type FileType string
 
@@ -29,6 +61,17 @@ type Config struct {
 	DotmanDir string // Path to .dotman directory (usually ~/.dotman)
 	HomeDir   string // User's home directory
 	IndexFile string // Path to index.json file
+	StateDir  string // Machine-local state directory (not tracked in the repo)
+	ConfigDir string // Machine-local configuration directory (sources.json)
+	Profile   string // Active deploy profile, exposed to hooks as DOTMAN_PROFILE
+	Hostname  string // Short host name, selects the hosts/<hostname> overlay layer
+}
+
+// Source is a dotfiles repository composed into $HOME alongside others
+type Source struct {
+	Name     string `json:"name"`
+	Dir      string `json:"dir"`      // Repository directory (e.g., ~/.dotman)
+	Priority int    `json:"priority"` // Higher priority wins target collisions
 }
 
 // Operation represents a file operation result
@@ -41,15 +84,99 @@ type Operation struct {
 
 // AddOptions represents options for the add command
 type AddOptions struct {
-	Force   bool // Force operation even if conflicts exist
-	DryRun  bool // Show what would happen without doing it
-	Backup  bool // Create backup before operation
+	Force   bool   // Force operation even if conflicts exist
+	DryRun  bool   // Show what would happen without doing it
+	Backup  bool   // Create backup before operation
 	Message string // Custom commit message
+
+	Layer   string      // Store the paths in this overlay layer (e.g., hosts/laptop) instead of the base
+	Merge   bool        // Fold managed entries below a directory into one directory entry
+	Split   bool        // Split the managed directory containing a path into separate entries
+	Partial *PartialDir // Manage directories partially
+
+	Policy   MatchPolicy // Match policy of a glob entry
+	RepoPath string      // Repo path of a glob entry; derived from the pattern when empty
+
+	Batch bool // Commit all paths in one commit instead of one commit per path
 }
 
 // DeployOptions represents options for the deploy command
 type DeployOptions struct {
-	Force   bool // Force deployment even if conflicts exist
-	DryRun  bool // Show what would happen without doing it
-	Backup  bool // Create backup before operation
+	Force  bool // Force deployment even if conflicts exist
+	DryRun bool // Show what would be done without doing it
+	Backup bool // Create backup before operation
+}
+
+// RemoveMode selects what happens to the home and repo copies of a removed entry
+type RemoveMode int
+
+const (
+	RemoveRestore    RemoveMode = iota // Move the repo content back to the original location
+	RemoveKeepInRepo                   // Copy the content back and archive the repo copy
+	RemoveDelete                       // Delete the content from both places
+	RemoveIndexOnly                    // Leave $HOME alone and archive the repo copy
+)
+
+// RemoveOptions represents options for the remove command
+type RemoveOptions struct {
+	Mode   RemoveMode
+	DryRun bool // Show what would be done without doing it
+}
+
+// FixOptions represents options for repairing links and cleaning up the index
+type FixOptions struct {
+	DryRun bool // Show what would be done without doing it
+	Backup bool // Replace files in the way of a link after saving them to the backup store
+
+	Paths []string // Only fix the entries with these original paths, or inside them; every entry when empty
+}
+
+// DiscoverOptions represents options for finding configuration to manage
+type DiscoverOptions struct {
+	MaxSize int64 // Skip paths larger than this many bytes; 0 means no limit
+}
+
+// ImportOptions represents options for planning an import from another
+// dotfiles manager
+type ImportOptions struct {
+	Force bool // Back up and replace targets that hold different content
+}
+
+// ServiceOptions represents options for installing the systemd user units
+type ServiceOptions struct {
+	Executable string        // Path of the dotman binary the units run
+	Interval   time.Duration // How often the sync timer fires
+	Push       bool          // Also push after pulling on every sync
+	Watch      bool          // Install a long-running watch service instead of the timer
+	Debounce   time.Duration // Debounce passed to 'dotman watch'
+	Enable     bool          // Enable and start the units
+}
+
+// BootstrapOptions represents options for unpacking a bundle
+type BootstrapOptions struct {
+	Git bool // Initialize a git repository after unpacking
+}
+
+// MoveOptions represents options for moving entries to another location or
+// layer
+type MoveOptions struct {
+	DryRun   bool // Show what would be done without doing it
+	KeepLink bool // Leave a link to the new location at the old one
+}
+
+// SyncMode selects what sync does
+type SyncMode int
+
+const (
+	SyncDiscover SyncMode = iota // Add repo files that are missing from the index
+	SyncPull                     // Pull from the remote and deploy new entries
+	SyncPush                     // Push to the remote and the push mirrors
+)
+
+// SyncOptions represents options for the sync command
+type SyncOptions struct {
+	Mode   SyncMode
+	DryRun bool   // Show what would be done without doing it
+	Remote string // Remote to pull from or push to; the configured one when empty
+	Branch string // Remote branch; the configured one when empty
 }